@@ -16,7 +16,9 @@ import (
 
 	"github.com/zopuu/soa-team-20/Backend/gateway/internal/config"
 	"github.com/zopuu/soa-team-20/Backend/gateway/internal/mw"
+	"github.com/zopuu/soa-team-20/Backend/gateway/internal/policy"
 	"github.com/zopuu/soa-team-20/Backend/gateway/internal/proxy"
+	"github.com/zopuu/soa-team-20/Backend/gateway/internal/tourapi"
 	followerspb "github.com/zopuu/soa-team-20/Backend/services/followers_service/proto/followerspb"
 	tourpb "github.com/zopuu/soa-team-20/Backend/services/tour/proto"
 )
@@ -62,6 +64,10 @@ func main() {
 		stakeProxy.ServeHTTP(w, r) // forward to users service
 	})
 
+	movedToAPI := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "moved to /api/tours", http.StatusGone)
+	})
+
 	// Health
 	r.Get("/healthz", func(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte("ok"))
@@ -73,20 +79,39 @@ func main() {
 		rr.Handle("/*", authProxy)
 	})
 
-	// Everything else requires JWT
+	// Everything else requires JWT, plus whatever role the route policy demands
 	secure := func(h http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			log.Printf("Request secured with JWT middleware: %s", r.Header.Get("Authorization"))
-			mw.AuthRequired(jwtCfg)(h).ServeHTTP(w, r)
+			mw.AuthRequired(jwtCfg)(mw.RequireRoles(policy.Default)(h)).ServeHTTP(w, r)
 		})
 	}
 
 	r.Group(func(pr chi.Router) {
 		pr.Route("/api/users", func(rr chi.Router) { rr.Handle("/*", secure(logProxy)) })
 		pr.Route("/blogs", func(rr chi.Router) { rr.Handle("/*", secure(blogProxy)) })
-		pr.Route("/tours", func(rr chi.Router) { rr.Handle("/*", secure(tourProxy)) })
-		pr.Route("/keyPoints", func(rr chi.Router) { rr.Handle("/*", secure(tourProxy)) })
+		// Tour and key point CRUD is served by /api/tours; the REST proxy keeps
+		// the routes the gRPC API does not cover.
+		pr.Route("/tours", func(rr chi.Router) {
+			rr.Method(http.MethodGet, "/", movedToAPI)
+			rr.Method(http.MethodPost, "/", movedToAPI)
+			rr.Method(http.MethodGet, "/users/{userId}", movedToAPI)
+			rr.Method(http.MethodGet, "/nearby", secure(tourProxy))
+			rr.Method(http.MethodGet, "/{id}", movedToAPI)
+			rr.Method(http.MethodPut, "/{id}", movedToAPI)
+			rr.Method(http.MethodDelete, "/{id}", movedToAPI)
+			rr.Handle("/*", secure(tourProxy))
+		})
+		pr.Route("/keyPoints", func(rr chi.Router) {
+			rr.Method(http.MethodPost, "/", movedToAPI)
+			rr.Method(http.MethodGet, "/tours/{tourId}", movedToAPI)
+			rr.Method(http.MethodPut, "/{id}", movedToAPI)
+			rr.Method(http.MethodDelete, "/{id}", movedToAPI)
+			rr.Handle("/*", secure(tourProxy))
+		})
 		pr.Route("/simulator", func(rr chi.Router) { rr.Handle("/*", secure(tourProxy)) })
+		pr.Route("/executions", func(rr chi.Router) { rr.Handle("/*", secure(tourProxy)) })
+		pr.Route("/shopping-cart", func(rr chi.Router) { rr.Handle("/*", secure(tourProxy)) })
+		pr.Route("/purchases", func(rr chi.Router) { rr.Handle("/*", secure(tourProxy)) })
 	})
 
 	// ---------- gRPC placeholder (next sprint) ----------
@@ -195,54 +220,10 @@ func main() {
 	})
 
 	// REST -> gRPC mappings for Tour service
+	tourAPI := &tourapi.Handler{Client: tourClient}
 	r.Route("/api/tours", func(rr chi.Router) {
 		rr.Use(secure) // require JWT auth
-
-			
-
-			rr.Post("/", func(w http.ResponseWriter, r *http.Request) {
-			var req struct {
-				AuthorId      string   `json:"authorId"`
-				Title         string   `json:"title"`
-				Description   string   `json:"description"`
-				Difficulty    int32    `json:"difficulty"`
-				Tags          []string `json:"tags"`
-				TransportType int32    `json:"transportType"`
-			}
-
-			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-				http.Error(w, err.Error(), http.StatusBadRequest)
-				return
-			}
-
-			resp, err := tourClient.CreateTour(r.Context(), &tourpb.CreateTourRequest{
-				AuthorId:      req.AuthorId, 
-				Title:         req.Title, 
-				Description:   req.Description, 
-				Difficulty:    tourpb.TourDifficulty(req.Difficulty), 
-				Tags:          req.Tags,
-				TransportType: tourpb.TransportType(req.TransportType),
-			})
-
-			if err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
-				return
-			}
-			
-			json.NewEncoder(w).Encode(resp)
-		})
-
-		rr.Delete("/{id}", func(w http.ResponseWriter, r *http.Request) {
-			id := chi.URLParam(r, "id")
-
-			resp, err := tourClient.DeleteTour(r.Context(), &tourpb.DeleteTourRequest{Id: id})
-			if err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
-				return
-			}
-			
-			json.NewEncoder(w).Encode(resp)
-		})
+		tourAPI.Routes(rr)
 	})
 
 	addr := ":" + cfg.Port
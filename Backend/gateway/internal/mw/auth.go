@@ -1,11 +1,14 @@
 package mw
 
 import (
+	"context"
 	"log"
 	"net/http"
 	"strings"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/zopuu/soa-team-20/Backend/gateway/internal/policy"
+	"google.golang.org/grpc/metadata"
 )
 
 type JWTConfig struct {
@@ -14,11 +17,24 @@ type JWTConfig struct {
 	Audience string
 }
 
+// parse verifies the token's HMAC signature, issuer and audience. Only HS256,
+// the algorithm the auth service signs with, is accepted.
+func (cfg JWTConfig) parse(tokenStr string) (*jwt.Token, error) {
+	return jwt.Parse(tokenStr, func(t *jwt.Token) (interface{}, error) {
+		return cfg.Secret, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithAudience(cfg.Audience),
+		jwt.WithIssuer(cfg.Issuer),
+	)
+}
+
 // AuthOptional lets requests pass through but, if a token is present, validates it
 // and injects X-User-Id / X-Roles.
 func AuthOptional(cfg JWTConfig) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			clearIdentity(r)
 			auth := r.Header.Get("Authorization")
 			if auth == "" || !strings.HasPrefix(strings.ToLower(auth), "bearer ") {
 				next.ServeHTTP(w, r)
@@ -26,32 +42,10 @@ func AuthOptional(cfg JWTConfig) func(http.Handler) http.Handler {
 			}
 
 			tokenStr := strings.TrimSpace(auth[len("Bearer "):])
-			tok, err := jwt.Parse(tokenStr, func(t *jwt.Token) (interface{}, error) {
-				return cfg.Secret, nil
-			}, jwt.WithAudience(cfg.Audience), jwt.WithIssuer(cfg.Issuer))
+			tok, err := cfg.parse(tokenStr)
 			if err == nil && tok != nil && tok.Valid {
 				if claims, ok := tok.Claims.(jwt.MapClaims); ok {
-					// Map your claim keys as used by Auth service
-					if sub, ok := claims["sub"].(string); ok {
-						r.Header.Set("X-User-Id", sub)
-					} else if uid, ok := claims["uid"].(string); ok {
-						r.Header.Set("X-User-Id", uid)
-					}
-					// roles could be array or string
-					if roles, ok := claims["role"]; ok {
-						switch v := roles.(type) {
-						case []any:
-							var s []string
-							for _, it := range v {
-								if str, ok := it.(string); ok {
-									s = append(s, str)
-								}
-							}
-							r.Header.Set("X-Roles", strings.Join(s, ","))
-						case string:
-							r.Header.Set("X-Roles", v)
-						}
-					}
+					setIdentity(r, claims)
 				}
 			}
 			next.ServeHTTP(w, r)
@@ -59,24 +53,104 @@ func AuthOptional(cfg JWTConfig) func(http.Handler) http.Handler {
 	}
 }
 
-// AuthRequired rejects if there is no valid JWT.
+// AuthRequired rejects if there is no valid JWT and otherwise injects
+// X-User-Id / X-Roles like AuthOptional.
 func AuthRequired(cfg JWTConfig) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			clearIdentity(r)
 			auth := r.Header.Get("Authorization")
 			if auth == "" || !strings.HasPrefix(strings.ToLower(auth), "bearer ") {
 				http.Error(w, "Unauthorized", http.StatusUnauthorized)
 				return
 			}
 			tokenStr := strings.TrimSpace(auth[len("Bearer "):])
-			_, err := jwt.Parse(tokenStr, func(t *jwt.Token) (interface{}, error) {
-				return cfg.Secret, nil
-			}, jwt.WithAudience(cfg.Audience), jwt.WithIssuer(cfg.Issuer))
+			tok, err := cfg.parse(tokenStr)
 			if err != nil {
 				log.Printf("JWT parse error: %v", err) // Add this line
 				http.Error(w, "Unauthorized", http.StatusUnauthorized)
 				return
 			}
+			if claims, ok := tok.Claims.(jwt.MapClaims); ok {
+				setIdentity(r, claims)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Claim keys as written by the Auth service. JwtSecurityTokenHandler shortens
+// the ClaimTypes URIs by default, but accept both forms.
+var (
+	userIdClaims = []string{"sub", "uid", "nameid", "http://schemas.xmlsoap.org/ws/2005/05/identity/claims/nameidentifier"}
+	roleClaims   = []string{"role", "http://schemas.microsoft.com/ws/2008/06/identity/claims/role"}
+	nameClaims   = []string{"unique_name", "name", "http://schemas.xmlsoap.org/ws/2005/05/identity/claims/name"}
+	emailClaims  = []string{"email", "http://schemas.xmlsoap.org/ws/2005/05/identity/claims/emailaddress"}
+)
+
+// clearIdentity drops identity headers sent by the client; downstream services
+// must only ever see the ones derived from a verified token.
+func clearIdentity(r *http.Request) {
+	r.Header.Del("X-User-Id")
+	r.Header.Del("X-User-Name")
+	r.Header.Del("X-User-Email")
+	r.Header.Del("X-Roles")
+}
+
+func setFirstClaim(r *http.Request, header string, claims jwt.MapClaims, keys []string) {
+	for _, key := range keys {
+		if v, ok := claims[key].(string); ok && v != "" {
+			r.Header.Set(header, v)
+			return
+		}
+	}
+}
+
+func setIdentity(r *http.Request, claims jwt.MapClaims) {
+	setFirstClaim(r, "X-User-Id", claims, userIdClaims)
+	setFirstClaim(r, "X-User-Name", claims, nameClaims)
+	setFirstClaim(r, "X-User-Email", claims, emailClaims)
+	// roles could be array or string
+	for _, key := range roleClaims {
+		switch v := claims[key].(type) {
+		case []any:
+			var s []string
+			for _, it := range v {
+				if str, ok := it.(string); ok {
+					s = append(s, str)
+				}
+			}
+			r.Header.Set("X-Roles", strings.Join(s, ","))
+			return
+		case string:
+			r.Header.Set("X-Roles", v)
+			return
+		}
+	}
+}
+
+// OutgoingIdentity forwards the identity injected by AuthRequired/AuthOptional
+// to gRPC backends as x-user-id / x-roles metadata.
+func OutgoingIdentity(r *http.Request) context.Context {
+	return metadata.AppendToOutgoingContext(r.Context(),
+		"x-user-id", r.Header.Get("X-User-Id"),
+		"x-roles", r.Header.Get("X-Roles"),
+	)
+}
+
+// RequireRoles enforces the route policy table using the X-Roles header set by
+// AuthRequired, so it must run after it.
+func RequireRoles(table policy.Table) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var roles []string
+			if header := r.Header.Get("X-Roles"); header != "" {
+				roles = strings.Split(header, ",")
+			}
+			if !table.Allows(r.Method, r.URL.Path, roles) {
+				http.Error(w, "Forbidden", http.StatusForbidden)
+				return
+			}
 			next.ServeHTTP(w, r)
 		})
 	}
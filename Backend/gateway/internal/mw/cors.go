@@ -8,8 +8,9 @@ func CORS(origins []string) *cors.Cors {
 	return cors.New(cors.Options{
 		AllowedOrigins:   origins,
 		AllowedMethods:   []string{"GET","POST","PUT","PATCH","DELETE","OPTIONS"},
-		AllowedHeaders:   []string{"Accept","Authorization","Content-Type","X-CSRF-Token"},
-		ExposedHeaders:   []string{"Link"},
+		AllowedHeaders:   []string{"Accept","Authorization","Content-Type","X-CSRF-Token","If-None-Match","If-Range","Range"},
+		// caching and range headers of image responses, so the frontend can see them
+		ExposedHeaders:   []string{"Link","ETag","Content-Length","Content-Range","Accept-Ranges"},
 		AllowCredentials: true,
 		MaxAge:           300,
 	})
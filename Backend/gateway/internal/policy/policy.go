@@ -0,0 +1,124 @@
+package policy
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Rule restricts requests matching Method and Pattern to callers holding at
+// least one of Roles.
+//
+// Pattern uses chi-style segments: "{name}" matches exactly one path segment
+// and a trailing "*" matches the rest of the path. An empty Method matches
+// every method.
+type Rule struct {
+	Method  string
+	Pattern string
+	Roles   []string
+}
+
+// Table is evaluated top to bottom; the first matching rule decides. Requests
+// that match no rule only need a valid token.
+type Table []Rule
+
+// Match returns the first rule that applies to the request.
+func (t Table) Match(method, path string) (Rule, bool) {
+	for _, rule := range t {
+		if rule.Method != "" && !strings.EqualFold(rule.Method, method) {
+			continue
+		}
+		if matchPath(rule.Pattern, path) {
+			return rule, true
+		}
+	}
+	return Rule{}, false
+}
+
+// Allows reports whether a caller with roles may make the request.
+func (t Table) Allows(method, path string, roles []string) bool {
+	rule, ok := t.Match(method, path)
+	if !ok {
+		return true
+	}
+	for _, want := range rule.Roles {
+		for _, have := range roles {
+			if strings.EqualFold(strings.TrimSpace(have), want) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func matchPath(pattern, path string) bool {
+	patternSegs := split(pattern)
+	pathSegs := split(path)
+
+	for i, seg := range patternSegs {
+		if seg == "*" {
+			return true
+		}
+		if i >= len(pathSegs) {
+			return false
+		}
+		if strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}") {
+			continue
+		}
+		if seg != pathSegs[i] {
+			return false
+		}
+	}
+	return len(patternSegs) == len(pathSegs)
+}
+
+func split(p string) []string {
+	p = strings.Trim(p, "/")
+	if p == "" {
+		return nil
+	}
+	return strings.Split(p, "/")
+}
+
+const (
+	Admin   = "admin"
+	Guide   = "guide"
+	Tourist = "tourist"
+)
+
+// Default is the gateway's route policy. Role names match the Auth service's
+// role claim (Admin, Guide, Tourist) case-insensitively.
+var Default = Table{
+	// users
+	{Method: http.MethodGet, Pattern: "/api/users/allUsers", Roles: []string{Admin}},
+
+	// tours
+	{Method: http.MethodPost, Pattern: "/api/tours", Roles: []string{Guide}},
+	{Method: http.MethodPost, Pattern: "/tours/import", Roles: []string{Guide}},
+	{Method: http.MethodPut, Pattern: "/api/tours/{id}", Roles: []string{Guide, Admin}},
+	{Method: http.MethodDelete, Pattern: "/api/tours/{id}", Roles: []string{Guide, Admin}},
+	{Method: http.MethodPost, Pattern: "/tours/{id}/publish", Roles: []string{Guide, Admin}},
+	{Method: http.MethodPost, Pattern: "/tours/{id}/archive", Roles: []string{Guide, Admin}},
+	{Method: http.MethodPost, Pattern: "/tours/{id}/reactivate", Roles: []string{Guide, Admin}},
+	{Method: http.MethodPost, Pattern: "/tours/{id}/clone", Roles: []string{Guide, Admin}},
+	{Method: http.MethodPost, Pattern: "/tours/{id}/revisions", Roles: []string{Guide, Admin}},
+	{Method: http.MethodGet, Pattern: "/tours/{id}/revisions", Roles: []string{Guide, Admin}},
+	{Method: http.MethodPost, Pattern: "/tours/{id}/reviews", Roles: []string{Tourist}},
+	{Method: http.MethodPut, Pattern: "/tours/{id}/reviews/{reviewId}", Roles: []string{Tourist}},
+	{Method: http.MethodPost, Pattern: "/tours/{id}/reviews/{reviewId}/images", Roles: []string{Tourist}},
+	{Method: http.MethodDelete, Pattern: "/tours/{id}/reviews/{reviewId}/images/{imageId}", Roles: []string{Tourist}},
+	{Method: http.MethodPut, Pattern: "/tours/{id}/reviews/{reviewId}/reply", Roles: []string{Guide}},
+	{Method: http.MethodDelete, Pattern: "/tours/{id}/reviews/{reviewId}/reply", Roles: []string{Guide, Admin}},
+
+	// key points
+	{Method: http.MethodGet, Pattern: "/keyPoints", Roles: []string{Admin}},
+	{Method: http.MethodPut, Pattern: "/keyPoints/*", Roles: []string{Guide, Admin}},
+	{Method: http.MethodDelete, Pattern: "/keyPoints/*", Roles: []string{Guide, Admin}},
+	{Method: http.MethodPost, Pattern: "/api/tours/{id}/keyPoints", Roles: []string{Guide, Admin}},
+	{Method: http.MethodPut, Pattern: "/api/tours/keyPoints/{id}", Roles: []string{Guide, Admin}},
+	{Method: http.MethodDelete, Pattern: "/api/tours/keyPoints/{id}", Roles: []string{Guide, Admin}},
+
+	// buying and walking tours
+	{Pattern: "/shopping-cart/*", Roles: []string{Tourist}},
+	{Pattern: "/purchases", Roles: []string{Tourist}},
+	{Method: http.MethodPost, Pattern: "/executions/*", Roles: []string{Tourist}},
+}
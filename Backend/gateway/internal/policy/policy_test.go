@@ -0,0 +1,75 @@
+package policy
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestDefaultAllows(t *testing.T) {
+	admin := []string{"Admin"}
+	guide := []string{"Guide"}
+	tourist := []string{"Tourist"}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		roles  []string
+		want   bool
+	}{
+		{"admin-only route for admin", http.MethodGet, "/api/users/allUsers", admin, true},
+		{"admin-only route for guide", http.MethodGet, "/api/users/allUsers", guide, false},
+		{"admin-only route without roles", http.MethodGet, "/api/users/allUsers", nil, false},
+		{"role names ignore case and spaces", http.MethodGet, "/api/users/allUsers", []string{" admin "}, true},
+
+		{"guide creates tour", http.MethodPost, "/api/tours", guide, true},
+		{"tourist creates tour", http.MethodPost, "/api/tours", tourist, false},
+		{"admin creates tour", http.MethodPost, "/api/tours", admin, false},
+		{"any of several roles", http.MethodPost, "/api/tours", []string{"Tourist", "Guide"}, true},
+
+		{"tourist posts review", http.MethodPost, "/tours/42/reviews", tourist, true},
+		{"guide posts review", http.MethodPost, "/tours/42/reviews", guide, false},
+		{"guide replies to review", http.MethodPut, "/tours/42/reviews/7/reply", guide, true},
+		{"tourist replies to review", http.MethodPut, "/tours/42/reviews/7/reply", tourist, false},
+
+		{"method mismatch falls through", http.MethodGet, "/api/tours", tourist, true},
+		{"method matches case-insensitively", "post", "/api/tours", tourist, false},
+		{"no rule for POST on a tour id", http.MethodPost, "/api/tours/42", tourist, true},
+		{"placeholder matches one segment only", http.MethodPut, "/api/tours/42/extra", tourist, true},
+		{"trailing slash is ignored", http.MethodPost, "/api/tours/", tourist, false},
+
+		{"wildcard matches nested path", http.MethodPut, "/keyPoints/1/order", tourist, false},
+		{"wildcard allows listed role", http.MethodPut, "/keyPoints/1/order", guide, true},
+		{"wildcard matches the prefix itself", http.MethodPost, "/executions", guide, false},
+		{"wildcard with any method", http.MethodDelete, "/shopping-cart/items/3", guide, false},
+		{"wildcard with any method for tourist", http.MethodGet, "/shopping-cart/items/3", tourist, true},
+		{"wildcard method mismatch", http.MethodGet, "/executions/active", guide, true},
+
+		{"unlisted route only needs a token", http.MethodGet, "/blogs", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Default.Allows(tt.method, tt.path, tt.roles); got != tt.want {
+				t.Errorf("Allows(%s %s, %v) = %v, want %v", tt.method, tt.path, tt.roles, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchFirstRuleWins(t *testing.T) {
+	table := Table{
+		{Method: http.MethodGet, Pattern: "/a/{id}", Roles: []string{Admin}},
+		{Pattern: "/a/*", Roles: []string{Guide}},
+	}
+	rule, ok := table.Match(http.MethodGet, "/a/1")
+	if !ok || rule.Pattern != "/a/{id}" {
+		t.Fatalf("Match(GET /a/1) = %+v, %v; want the /a/{id} rule", rule, ok)
+	}
+	rule, ok = table.Match(http.MethodPost, "/a/1")
+	if !ok || rule.Pattern != "/a/*" {
+		t.Fatalf("Match(POST /a/1) = %+v, %v; want the /a/* rule", rule, ok)
+	}
+	if _, ok := table.Match(http.MethodGet, "/b"); ok {
+		t.Fatal("Match(GET /b) matched a rule")
+	}
+}
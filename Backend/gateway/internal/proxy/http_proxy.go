@@ -31,6 +31,10 @@ func NewHTTPReverseProxy(opt Options) (*httputil.ReverseProxy, error) {
 		ResponseHeaderTimeout: opt.ProxyTimeout,
 		TLSClientConfig:       &tls.Config{InsecureSkipVerify: false}, // change if needed
 		Proxy:                 http.ProxyFromEnvironment,
+		// Pass bodies through as the service encoded them. Otherwise the
+		// transport asks for gzip on its own and decompresses the response,
+		// which drops Content-Length and breaks ranges against the ETag.
+		DisableCompression: true,
 	}
 
 	rp := httputil.NewSingleHostReverseProxy(u)
@@ -0,0 +1,523 @@
+// Package tourapi exposes the tour service's gRPC contract as the gateway's
+// /api/tours REST routes.
+//
+// Request and response bodies keep the shape of the tour service's own REST
+// API, so enum values are the zero-based model values; the proto enums reserve
+// 0 for UNSPECIFIED and are shifted by one here.
+package tourapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/zopuu/soa-team-20/Backend/gateway/internal/mw"
+	tourpb "github.com/zopuu/soa-team-20/Backend/services/tour/proto"
+)
+
+type Handler struct {
+	Client tourpb.TourServiceClient
+}
+
+// Routes mounts the handlers on r, which is expected to be the /api/tours sub-router.
+func (h *Handler) Routes(r chi.Router) {
+	r.Get("/", h.list)
+	r.Post("/", h.create)
+	r.Get("/users/{userId}", h.listByAuthor)
+	r.Put("/keyPoints/{keyPointId}", h.updateKeyPoint)
+	r.Delete("/keyPoints/{keyPointId}", h.deleteKeyPoint)
+	r.Get("/{id}", h.get)
+	r.Put("/{id}", h.update)
+	r.Delete("/{id}", h.delete)
+	r.Get("/{id}/keyPoints", h.listKeyPoints)
+	r.Post("/{id}/keyPoints", h.createKeyPoint)
+}
+
+type tourJSON struct {
+	Id          string          `json:"id"`
+	AuthorId    string          `json:"authorId"`
+	Title       string          `json:"title"`
+	Description string          `json:"description"`
+	Difficulty  int32           `json:"difficulty"`
+	Tags        []string        `json:"tags"`
+	Status      int32           `json:"status"`
+	Price       float64         `json:"price"`
+	Distance    float64         `json:"distance"`
+	PublishedAt time.Time       `json:"publishedAt"`
+	ArchivedAt  time.Time       `json:"archivedAt"`
+	Durations   []durationJSON  `json:"durations"`
+	RatingStats ratingStatsJSON `json:"ratingStats"`
+	Revision    int32           `json:"revision"`
+	RevisionOf  string          `json:"revisionOf,omitempty"`
+}
+
+type ratingStatsJSON struct {
+	Average   float64 `json:"average"`
+	Count     int32   `json:"count"`
+	Histogram []int32 `json:"histogram"`
+}
+
+type durationJSON struct {
+	TransportType int32   `json:"transportType"`
+	Minutes       float64 `json:"minutes"`
+}
+
+type tourPageJSON struct {
+	Items    []tourJSON `json:"items"`
+	Page     int32      `json:"page"`
+	PageSize int32      `json:"pageSize"`
+	Total    int64      `json:"total"`
+}
+
+type coordinatesJSON struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+type imageJSON struct {
+	Data     []byte `json:"data"`
+	MimeType string `json:"mimeType"`
+	Filename string `json:"filename"`
+}
+
+type keyPointJSON struct {
+	Id          string          `json:"id"`
+	TourId      string          `json:"tourId"`
+	Coordinates coordinatesJSON `json:"coordinates"`
+	Title       string          `json:"title"`
+	Description string          `json:"description"`
+	Order       int32           `json:"order"`
+	CreatedAt   time.Time       `json:"createdAt"`
+	ImageUrl    string          `json:"imageUrl,omitempty"`
+	// Image mirrors the image object of the REST API, carrying only its URL.
+	Image *imageRefJSON `json:"image,omitempty"`
+}
+
+type imageRefJSON struct {
+	Url string `json:"url"`
+}
+
+type keyPointInput struct {
+	Coordinates coordinatesJSON `json:"coordinates"`
+	Title       string          `json:"title"`
+	Description string          `json:"description"`
+	Image       *imageJSON      `json:"image"`
+}
+
+func (h *Handler) list(w http.ResponseWriter, r *http.Request) {
+	req, err := parseListToursRequest(r.URL.Query())
+	if err != nil {
+		writeErrorJSON(w, http.StatusBadRequest, errorJSON{Error: err.Error()})
+		return
+	}
+
+	resp, err := h.Client.ListTours(mw.OutgoingIdentity(r), req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toTourPage(resp))
+}
+
+// parseListToursRequest reads the tour listing filters, rejecting malformed
+// numbers instead of silently dropping them.
+func parseListToursRequest(q url.Values) (*tourpb.ListToursRequest, error) {
+	req := &tourpb.ListToursRequest{
+		Tag:        q.Get("tag"),
+		AuthorId:   q.Get("authorId"),
+		SortBy:     q.Get("sort"),
+		Descending: q.Get("order") == "desc",
+	}
+	var err error
+	if req.Page, err = queryInt32(q, "page"); err != nil {
+		return nil, err
+	}
+	if req.PageSize, err = queryInt32(q, "pageSize"); err != nil {
+		return nil, err
+	}
+	if req.MinPrice, err = queryFloat(q, "minPrice"); err != nil {
+		return nil, err
+	}
+	if req.MaxPrice, err = queryFloat(q, "maxPrice"); err != nil {
+		return nil, err
+	}
+	if req.MaxDuration, err = queryFloat(q, "maxDuration"); err != nil {
+		return nil, err
+	}
+
+	status, err := queryEnum(q, "status")
+	if err != nil {
+		return nil, err
+	}
+	difficulty, err := queryEnum(q, "difficulty")
+	if err != nil {
+		return nil, err
+	}
+	transportType, err := queryEnum(q, "transportType")
+	if err != nil {
+		return nil, err
+	}
+	req.Status = tourpb.TourStatus(status)
+	req.Difficulty = tourpb.TourDifficulty(difficulty)
+	req.TransportType = tourpb.TransportType(transportType)
+	return req, nil
+}
+
+func (h *Handler) listByAuthor(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	page, err := queryInt32(q, "page")
+	if err != nil {
+		writeErrorJSON(w, http.StatusBadRequest, errorJSON{Error: err.Error()})
+		return
+	}
+	pageSize, err := queryInt32(q, "pageSize")
+	if err != nil {
+		writeErrorJSON(w, http.StatusBadRequest, errorJSON{Error: err.Error()})
+		return
+	}
+	resp, err := h.Client.ListToursByAuthor(mw.OutgoingIdentity(r), &tourpb.ListToursByAuthorRequest{
+		AuthorId: chi.URLParam(r, "userId"),
+		Page:     page,
+		PageSize: pageSize,
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toTourPage(resp))
+}
+
+func (h *Handler) get(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.Client.GetTour(mw.OutgoingIdentity(r), &tourpb.GetTourRequest{Id: chi.URLParam(r, "id")})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toTour(resp.Tour))
+}
+
+func (h *Handler) create(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Title       string         `json:"title"`
+		Description string         `json:"description"`
+		Difficulty  int32          `json:"difficulty"`
+		Tags        []string       `json:"tags"`
+		Durations   []durationJSON `json:"durations"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErrorJSON(w, http.StatusBadRequest, errorJSON{Error: err.Error()})
+		return
+	}
+
+	resp, err := h.Client.CreateTour(mw.OutgoingIdentity(r), &tourpb.CreateTourRequest{
+		Title:       req.Title,
+		Description: req.Description,
+		Difficulty:  tourpb.TourDifficulty(req.Difficulty + 1),
+		Tags:        req.Tags,
+		Durations:   toProtoDurations(req.Durations),
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, toTour(resp.Tour))
+}
+
+func (h *Handler) update(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Title       string         `json:"title"`
+		Description string         `json:"description"`
+		Difficulty  int32          `json:"difficulty"`
+		Tags        []string       `json:"tags"`
+		Price       float64        `json:"price"`
+		Durations   []durationJSON `json:"durations"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErrorJSON(w, http.StatusBadRequest, errorJSON{Error: err.Error()})
+		return
+	}
+
+	resp, err := h.Client.UpdateTour(mw.OutgoingIdentity(r), &tourpb.UpdateTourRequest{
+		Id:          chi.URLParam(r, "id"),
+		Title:       req.Title,
+		Description: req.Description,
+		Difficulty:  tourpb.TourDifficulty(req.Difficulty + 1),
+		Tags:        req.Tags,
+		Price:       req.Price,
+		Durations:   toProtoDurations(req.Durations),
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toTour(resp.Tour))
+}
+
+func (h *Handler) delete(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.Client.DeleteTour(mw.OutgoingIdentity(r), &tourpb.DeleteTourRequest{Id: chi.URLParam(r, "id")})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *Handler) listKeyPoints(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.Client.ListKeyPoints(mw.OutgoingIdentity(r), &tourpb.ListKeyPointsRequest{TourId: chi.URLParam(r, "id")})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	keyPoints := make([]keyPointJSON, 0, len(resp.KeyPoints))
+	for _, kp := range resp.KeyPoints {
+		keyPoints = append(keyPoints, toKeyPoint(kp))
+	}
+	writeJSON(w, http.StatusOK, keyPoints)
+}
+
+func (h *Handler) createKeyPoint(w http.ResponseWriter, r *http.Request) {
+	var req keyPointInput
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErrorJSON(w, http.StatusBadRequest, errorJSON{Error: err.Error()})
+		return
+	}
+
+	resp, err := h.Client.CreateKeyPoint(mw.OutgoingIdentity(r), &tourpb.CreateKeyPointRequest{
+		TourId:      chi.URLParam(r, "id"),
+		Latitude:    req.Coordinates.Latitude,
+		Longitude:   req.Coordinates.Longitude,
+		Title:       req.Title,
+		Description: req.Description,
+		Image:       toProtoImage(req.Image),
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, toKeyPoint(resp.KeyPoint))
+}
+
+func (h *Handler) updateKeyPoint(w http.ResponseWriter, r *http.Request) {
+	var req keyPointInput
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErrorJSON(w, http.StatusBadRequest, errorJSON{Error: err.Error()})
+		return
+	}
+
+	resp, err := h.Client.UpdateKeyPoint(mw.OutgoingIdentity(r), &tourpb.UpdateKeyPointRequest{
+		Id:          chi.URLParam(r, "keyPointId"),
+		Latitude:    req.Coordinates.Latitude,
+		Longitude:   req.Coordinates.Longitude,
+		Title:       req.Title,
+		Description: req.Description,
+		Image:       toProtoImage(req.Image),
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toKeyPoint(resp.KeyPoint))
+}
+
+func (h *Handler) deleteKeyPoint(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.Client.DeleteKeyPoint(mw.OutgoingIdentity(r), &tourpb.DeleteKeyPointRequest{Id: chi.URLParam(r, "keyPointId")})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func toTour(t *tourpb.Tour) tourJSON {
+	out := tourJSON{
+		Id:          t.GetId(),
+		AuthorId:    t.GetAuthorId(),
+		Title:       t.GetTitle(),
+		Description: t.GetDescription(),
+		Difficulty:  fromProtoEnum(int32(t.GetDifficulty())),
+		Tags:        t.GetTags(),
+		Status:      fromProtoEnum(int32(t.GetStatus())),
+		Price:       t.GetPrice(),
+		Distance:    t.GetDistance(),
+		Durations:   make([]durationJSON, 0, len(t.GetDurations())),
+		RatingStats: ratingStatsJSON{
+			Average:   t.GetRatingStats().GetAverage(),
+			Count:     t.GetRatingStats().GetCount(),
+			Histogram: t.GetRatingStats().GetHistogram(),
+		},
+		Revision:   t.GetRevision(),
+		RevisionOf: t.GetRevisionOf(),
+	}
+	if out.RatingStats.Histogram == nil {
+		out.RatingStats.Histogram = []int32{}
+	}
+	for _, d := range t.GetDurations() {
+		out.Durations = append(out.Durations, durationJSON{
+			TransportType: fromProtoEnum(int32(d.GetTransportType())),
+			Minutes:       d.GetMinutes(),
+		})
+	}
+	if t.GetPublishedAt() != nil {
+		out.PublishedAt = t.GetPublishedAt().AsTime()
+	}
+	if t.GetArchivedAt() != nil {
+		out.ArchivedAt = t.GetArchivedAt().AsTime()
+	}
+	return out
+}
+
+func toProtoDurations(durations []durationJSON) []*tourpb.TransportDuration {
+	out := make([]*tourpb.TransportDuration, 0, len(durations))
+	for _, d := range durations {
+		out = append(out, &tourpb.TransportDuration{
+			TransportType: tourpb.TransportType(d.TransportType + 1),
+			Minutes:       d.Minutes,
+		})
+	}
+	return out
+}
+
+func toTourPage(resp *tourpb.ListToursResponse) tourPageJSON {
+	items := make([]tourJSON, 0, len(resp.Tours))
+	for _, t := range resp.Tours {
+		items = append(items, toTour(t))
+	}
+	return tourPageJSON{Items: items, Page: resp.Page, PageSize: resp.PageSize, Total: resp.Total}
+}
+
+func toKeyPoint(kp *tourpb.KeyPoint) keyPointJSON {
+	out := keyPointJSON{
+		Id:          kp.GetId(),
+		TourId:      kp.GetTourId(),
+		Coordinates: coordinatesJSON{Latitude: kp.GetLatitude(), Longitude: kp.GetLongitude()},
+		Title:       kp.GetTitle(),
+		Description: kp.GetDescription(),
+		Order:       kp.GetOrder(),
+		ImageUrl:    kp.GetImageUrl(),
+	}
+	if kp.GetCreatedAt() != nil {
+		out.CreatedAt = kp.GetCreatedAt().AsTime()
+	}
+	if out.ImageUrl != "" {
+		out.Image = &imageRefJSON{Url: out.ImageUrl}
+	}
+	return out
+}
+
+func toProtoImage(img *imageJSON) *tourpb.Image {
+	if img == nil {
+		return nil
+	}
+	return &tourpb.Image{Data: img.Data, MimeType: img.MimeType, Filename: img.Filename}
+}
+
+// fromProtoEnum undoes the UNSPECIFIED shift; UNSPECIFIED itself maps to the first model value.
+func fromProtoEnum(v int32) int32 {
+	if v <= 0 {
+		return 0
+	}
+	return v - 1
+}
+
+// queryInt32 reads an optional integer parameter; a missing one is 0.
+func queryInt32(q url.Values, name string) (int32, error) {
+	raw := q.Get(name)
+	if raw == "" {
+		return 0, nil
+	}
+	v, err := strconv.ParseInt(raw, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s", name)
+	}
+	return int32(v), nil
+}
+
+// queryEnum reads an optional zero-based model enum value and shifts it to
+// the proto enum; a missing one is UNSPECIFIED.
+func queryEnum(q url.Values, name string) (int32, error) {
+	if q.Get(name) == "" {
+		return 0, nil
+	}
+	v, err := queryInt32(q, name)
+	if err != nil || v < 0 {
+		return 0, fmt.Errorf("invalid %s", name)
+	}
+	return v + 1, nil
+}
+
+// queryFloat reads an optional number parameter; a missing one is nil.
+func queryFloat(q url.Values, name string) (*float64, error) {
+	raw := q.Get(name)
+	if raw == "" {
+		return nil, nil
+	}
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s", name)
+	}
+	return &v, nil
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+// errorJSON matches the error body of the tour service's REST endpoints.
+type errorJSON struct {
+	Status int         `json:"status"`
+	Error  string      `json:"error"`
+	Fields []fieldJSON `json:"fields,omitempty"`
+}
+
+type fieldJSON struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// writeError translates a gRPC status into the matching HTTP status, keeping
+// the rejected fields of an invalid request.
+func writeError(w http.ResponseWriter, err error) {
+	st, _ := status.FromError(err)
+	code := http.StatusInternalServerError
+	switch st.Code() {
+	case codes.InvalidArgument:
+		code = http.StatusBadRequest
+	case codes.Unauthenticated:
+		code = http.StatusUnauthorized
+	case codes.PermissionDenied:
+		code = http.StatusForbidden
+	case codes.NotFound:
+		code = http.StatusNotFound
+	case codes.FailedPrecondition, codes.AlreadyExists:
+		code = http.StatusConflict
+	case codes.Unavailable:
+		code = http.StatusBadGateway
+	}
+
+	body := errorJSON{Error: st.Message()}
+	for _, detail := range st.Details() {
+		if badRequest, ok := detail.(*errdetails.BadRequest); ok {
+			for _, violation := range badRequest.FieldViolations {
+				body.Fields = append(body.Fields, fieldJSON{Field: violation.Field, Message: violation.Description})
+			}
+		}
+	}
+	writeErrorJSON(w, code, body)
+}
+
+func writeErrorJSON(w http.ResponseWriter, code int, body errorJSON) {
+	body.Status = code
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	writeJSON(w, code, body)
+}
@@ -0,0 +1,63 @@
+package tourapi
+
+import (
+	"net/url"
+	"testing"
+
+	tourpb "github.com/zopuu/soa-team-20/Backend/services/tour/proto"
+)
+
+func TestParseListToursRequest(t *testing.T) {
+	req, err := parseListToursRequest(url.Values{
+		"page":        {"2"},
+		"pageSize":    {"10"},
+		"minPrice":    {"5.5"},
+		"status":      {"1"},
+		"difficulty":  {"0"},
+		"authorId":    {"7"},
+		"order":       {"desc"},
+		"maxDuration": {""},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if req.Page != 2 || req.PageSize != 10 || req.AuthorId != "7" || !req.Descending {
+		t.Errorf("unexpected request %+v", req)
+	}
+	if req.MinPrice == nil || *req.MinPrice != 5.5 || req.MaxPrice != nil || req.MaxDuration != nil {
+		t.Errorf("unexpected price bounds %v %v %v", req.MinPrice, req.MaxPrice, req.MaxDuration)
+	}
+	if req.Status != tourpb.TourStatus_TOUR_STATUS_PUBLISHED || req.Difficulty != tourpb.TourDifficulty_TOUR_DIFFICULTY_BEGINNER {
+		t.Errorf("unexpected enums %v %v", req.Status, req.Difficulty)
+	}
+	if req.TransportType != tourpb.TransportType_TRANSPORT_TYPE_UNSPECIFIED {
+		t.Errorf("transport type should be unspecified, got %v", req.TransportType)
+	}
+}
+
+func TestParseListToursRequestRejectsMalformedValues(t *testing.T) {
+	tests := []struct {
+		param string
+		value string
+	}{
+		{"page", "two"},
+		{"pageSize", "1.5"},
+		{"pageSize", "99999999999"},
+		{"minPrice", "cheap"},
+		{"maxPrice", "10eur"},
+		{"maxDuration", "1h"},
+		{"status", "published"},
+		{"difficulty", "-1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.param+"="+tt.value, func(t *testing.T) {
+			_, err := parseListToursRequest(url.Values{tt.param: {tt.value}})
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if want := "invalid " + tt.param; err.Error() != want {
+				t.Errorf("got %q, want %q", err, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+// Package blobstore keeps binary objects such as uploaded images outside of
+// the service databases. Documents only store the key an object was saved
+// under, together with the metadata returned by Put.
+package blobstore
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrNotFound   = errors.New("blob not found")
+	ErrInvalidKey = errors.New("invalid blob key")
+)
+
+// Object describes a stored blob. Checksum is the hex encoded SHA-256 of the
+// content; it is only known right after Put.
+type Object struct {
+	Key         string
+	ContentType string
+	Size        int64
+	Checksum    string
+}
+
+// Store saves and serves blobs by key. Keys are slash separated paths such as
+// "keypoints/<id>".
+type Store interface {
+	// Put streams r into the store. size may be -1 when it is not known up front.
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) (Object, error)
+	// Get opens the blob for reading; the caller must close it.
+	Get(ctx context.Context, key string) (io.ReadSeekCloser, Object, error)
+	// Delete removes the blob. Deleting a missing blob is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// Config selects and configures a Store. Kind is "local" or "s3".
+type Config struct {
+	Kind string
+
+	LocalDir string
+
+	S3Endpoint  string
+	S3AccessKey string
+	S3SecretKey string
+	S3Bucket    string
+	S3UseSSL    bool
+}
+
+// ConfigFromEnv reads BLOB_STORE, BLOB_LOCAL_DIR and the BLOB_S3_* variables,
+// falling back to a local store in defaultDir and to defaultBucket on S3.
+func ConfigFromEnv(defaultDir, defaultBucket string) Config {
+	useSSL, _ := strconv.ParseBool(os.Getenv("BLOB_S3_USE_SSL"))
+	cfg := Config{
+		Kind:        strings.ToLower(os.Getenv("BLOB_STORE")),
+		LocalDir:    os.Getenv("BLOB_LOCAL_DIR"),
+		S3Endpoint:  os.Getenv("BLOB_S3_ENDPOINT"),
+		S3AccessKey: os.Getenv("BLOB_S3_ACCESS_KEY"),
+		S3SecretKey: os.Getenv("BLOB_S3_SECRET_KEY"),
+		S3Bucket:    os.Getenv("BLOB_S3_BUCKET"),
+		S3UseSSL:    useSSL,
+	}
+	if cfg.Kind == "" {
+		cfg.Kind = "local"
+	}
+	if cfg.LocalDir == "" {
+		cfg.LocalDir = defaultDir
+	}
+	if cfg.S3Bucket == "" {
+		cfg.S3Bucket = defaultBucket
+	}
+	return cfg
+}
+
+// New builds the store described by cfg.
+func New(ctx context.Context, cfg Config) (Store, error) {
+	switch cfg.Kind {
+	case "local":
+		return NewFileStore(cfg.LocalDir)
+	case "s3":
+		return NewS3Store(ctx, cfg.S3Endpoint, cfg.S3AccessKey, cfg.S3SecretKey, cfg.S3Bucket, cfg.S3UseSSL)
+	default:
+		return nil, fmt.Errorf("unknown blob store %q", cfg.Kind)
+	}
+}
+
+func validKey(key string) bool {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return false
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return false
+		}
+	}
+	return true
+}
+
+// checksumReader hashes everything read through it.
+type checksumReader struct {
+	r    io.Reader
+	hash hash.Hash
+	n    int64
+}
+
+func newChecksumReader(r io.Reader) *checksumReader {
+	h := sha256.New()
+	return &checksumReader{r: io.TeeReader(r, h), hash: h}
+}
+
+func (c *checksumReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func (c *checksumReader) Sum() string {
+	return hex.EncodeToString(c.hash.Sum(nil))
+}
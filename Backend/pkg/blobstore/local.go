@@ -0,0 +1,90 @@
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// FileStore keeps blobs as files below a root directory.
+type FileStore struct {
+	Root string
+}
+
+func NewFileStore(root string) (*FileStore, error) {
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileStore{Root: root}, nil
+}
+
+func (s *FileStore) path(key string) string {
+	return filepath.Join(s.Root, filepath.FromSlash(key))
+}
+
+func (s *FileStore) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) (Object, error) {
+	if !validKey(key) {
+		return Object{}, ErrInvalidKey
+	}
+	target := s.path(key)
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return Object{}, err
+	}
+
+	// Write next to the target and rename, so readers never see a partial file.
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".upload-*")
+	if err != nil {
+		return Object{}, err
+	}
+	defer os.Remove(tmp.Name())
+
+	body := newChecksumReader(r)
+	if _, err := io.Copy(tmp, body); err != nil {
+		tmp.Close()
+		return Object{}, err
+	}
+	if err := tmp.Close(); err != nil {
+		return Object{}, err
+	}
+	if err := ctx.Err(); err != nil {
+		return Object{}, err
+	}
+	if err := os.Rename(tmp.Name(), target); err != nil {
+		return Object{}, err
+	}
+
+	return Object{Key: key, ContentType: contentType, Size: body.n, Checksum: body.Sum()}, nil
+}
+
+func (s *FileStore) Get(ctx context.Context, key string) (io.ReadSeekCloser, Object, error) {
+	if !validKey(key) {
+		return nil, Object{}, ErrInvalidKey
+	}
+	file, err := os.Open(s.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, Object{}, ErrNotFound
+	}
+	if err != nil {
+		return nil, Object{}, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, Object{}, err
+	}
+	return file, Object{Key: key, Size: info.Size()}, nil
+}
+
+func (s *FileStore) Delete(ctx context.Context, key string) error {
+	if !validKey(key) {
+		return ErrInvalidKey
+	}
+	err := os.Remove(s.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,83 @@
+package blobstore
+
+import (
+	"context"
+	"io"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// S3Store keeps blobs in a bucket of an S3-compatible server such as MinIO.
+type S3Store struct {
+	Client *minio.Client
+	Bucket string
+}
+
+// NewS3Store connects to endpoint (host:port) and creates the bucket if needed.
+func NewS3Store(ctx context.Context, endpoint, accessKey, secretKey, bucket string, useSSL bool) (*S3Store, error) {
+	client, err := minio.New(endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(accessKey, secretKey, ""),
+		Secure: useSSL,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	exists, err := client.BucketExists(ctx, bucket)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		if err := client.MakeBucket(ctx, bucket, minio.MakeBucketOptions{}); err != nil {
+			return nil, err
+		}
+	}
+	return &S3Store{Client: client, Bucket: bucket}, nil
+}
+
+func (s *S3Store) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) (Object, error) {
+	if !validKey(key) {
+		return Object{}, ErrInvalidKey
+	}
+	body := newChecksumReader(r)
+	info, err := s.Client.PutObject(ctx, s.Bucket, key, body, size, minio.PutObjectOptions{ContentType: contentType})
+	if err != nil {
+		return Object{}, err
+	}
+	return Object{Key: key, ContentType: contentType, Size: info.Size, Checksum: body.Sum()}, nil
+}
+
+func (s *S3Store) Get(ctx context.Context, key string) (io.ReadSeekCloser, Object, error) {
+	if !validKey(key) {
+		return nil, Object{}, ErrInvalidKey
+	}
+	object, err := s.Client.GetObject(ctx, s.Bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, Object{}, translate(err)
+	}
+	// GetObject is lazy; Stat is the first call that reaches the server.
+	info, err := object.Stat()
+	if err != nil {
+		object.Close()
+		return nil, Object{}, translate(err)
+	}
+	return object, Object{Key: key, ContentType: info.ContentType, Size: info.Size}, nil
+}
+
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	if !validKey(key) {
+		return ErrInvalidKey
+	}
+	return translate(s.Client.RemoveObject(ctx, s.Bucket, key, minio.RemoveObjectOptions{}))
+}
+
+func translate(err error) error {
+	if err == nil {
+		return nil
+	}
+	if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+		return ErrNotFound
+	}
+	return err
+}
@@ -0,0 +1,5 @@
+module github.com/zopuu/soa-team-20/Backend/pkg/imaging
+
+go 1.24.6
+
+require golang.org/x/image v0.30.0
@@ -0,0 +1,182 @@
+// Package imaging validates uploaded images by their content, strips the
+// metadata they carry and renders the smaller variants served in listings.
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+var (
+	ErrUnsupported = errors.New("unsupported image format")
+	ErrTooLarge    = errors.New("image dimensions too large")
+	ErrUnknownSize = errors.New("unknown image size")
+)
+
+// MaxPixels bounds the decoded size of an upload, so that a small, highly
+// compressed file cannot make the service allocate gigabytes.
+const MaxPixels = 40_000_000
+
+// Size names a rendition of an image.
+type Size string
+
+const (
+	Original Size = "original"
+	Medium   Size = "medium"
+	Thumb    Size = "thumb"
+)
+
+// Variants are the smaller renditions generated on upload, with the longest
+// side they are scaled down to.
+var Variants = map[Size]int{
+	Medium: 960,
+	Thumb:  240,
+}
+
+// ParseSize reads a size query value; an empty value means the original.
+func ParseSize(value string) (Size, error) {
+	switch Size(value) {
+	case "", Original:
+		return Original, nil
+	case Medium, Thumb:
+		return Size(value), nil
+	}
+	return "", ErrUnknownSize
+}
+
+// Encoded is image data ready to be stored.
+type Encoded struct {
+	Data        []byte
+	ContentType string
+}
+
+// Result is a processed upload: the original without metadata and the
+// variants that are smaller than it.
+type Result struct {
+	Original Encoded
+	Variants map[Size]Encoded
+	Width    int
+	Height   int
+}
+
+// Sniff detects the image type from the data itself, ignoring whatever
+// content type the client declared.
+func Sniff(data []byte) (string, error) {
+	contentType := http.DetectContentType(data)
+	switch contentType {
+	case "image/jpeg", "image/png", "image/gif", "image/webp":
+		return contentType, nil
+	}
+	return "", ErrUnsupported
+}
+
+// Process checks that data is a JPEG, PNG, GIF or WebP image, removes its
+// metadata and renders the variants.
+//
+// JPEGs are re-encoded after applying their EXIF orientation, which drops
+// EXIF data including GPS tags; PNGs are re-encoded, which drops their text
+// and eXIf chunks; WebP files lose their EXIF and XMP chunks, or are
+// re-encoded as PNG when their chunks can't be walked. GIFs carry no
+// such metadata and are kept as uploaded, animation included.
+func Process(data []byte) (Result, error) {
+	contentType, err := Sniff(data)
+	if err != nil {
+		return Result{}, err
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return Result{}, ErrUnsupported
+	}
+	if config.Width*config.Height > MaxPixels {
+		return Result{}, ErrTooLarge
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return Result{}, ErrUnsupported
+	}
+
+	result := Result{Variants: map[Size]Encoded{}}
+	switch contentType {
+	case "image/jpeg":
+		img = orient(img, jpegOrientation(data))
+		result.Original, err = encodeJPEG(img, 90)
+	case "image/png":
+		result.Original, err = encodePNG(img)
+	case "image/webp":
+		// there is no WebP encoder, so files the chunk walk can't follow
+		// are stored as PNG rather than with their metadata
+		if stripped, ok := stripWebPMetadata(data); ok {
+			result.Original = Encoded{Data: stripped, ContentType: contentType}
+		} else {
+			result.Original, err = encodePNG(img)
+		}
+	default:
+		result.Original = Encoded{Data: data, ContentType: contentType}
+	}
+	if err != nil {
+		return Result{}, err
+	}
+
+	bounds := img.Bounds()
+	result.Width, result.Height = bounds.Dx(), bounds.Dy()
+	for size, longest := range Variants {
+		scaled, ok := fit(img, longest)
+		if !ok {
+			continue
+		}
+		// Photos stay JPEG; anything that may be transparent becomes PNG.
+		if contentType == "image/jpeg" {
+			result.Variants[size], err = encodeJPEG(scaled, 85)
+		} else {
+			result.Variants[size], err = encodePNG(scaled)
+		}
+		if err != nil {
+			return Result{}, err
+		}
+	}
+	return result, nil
+}
+
+// fit scales img down so that its longest side is at most longest; it
+// reports false when img already fits.
+func fit(img image.Image, longest int) (image.Image, bool) {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width <= longest && height <= longest {
+		return nil, false
+	}
+	if width >= height {
+		height = max(1, height*longest/width)
+		width = longest
+	} else {
+		width = max(1, width*longest/height)
+		height = longest
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Src, nil)
+	return dst, true
+}
+
+func encodeJPEG(img image.Image, quality int) (Encoded, error) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+		return Encoded{}, err
+	}
+	return Encoded{Data: buf.Bytes(), ContentType: "image/jpeg"}, nil
+}
+
+func encodePNG(img image.Image) (Encoded, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return Encoded{}, err
+	}
+	return Encoded{Data: buf.Bytes(), ContentType: "image/png"}, nil
+}
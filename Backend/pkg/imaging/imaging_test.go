@@ -0,0 +1,119 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+)
+
+// gpsExif is a little-endian TIFF structure whose first IFD points to a GPS
+// IFD holding a latitude reference, as cameras and phones write it.
+func gpsExif() []byte {
+	tiff := []byte("II*\x00")
+	tiff = binary.LittleEndian.AppendUint32(tiff, 8)
+	// IFD0: GPSInfo pointing to the GPS IFD right after it
+	tiff = binary.LittleEndian.AppendUint16(tiff, 1)
+	tiff = appendIFDEntry(tiff, 0x8825, 4, 1, []byte{26, 0, 0, 0})
+	tiff = binary.LittleEndian.AppendUint32(tiff, 0)
+	// GPS IFD: GPSLatitudeRef "N"
+	tiff = binary.LittleEndian.AppendUint16(tiff, 1)
+	tiff = appendIFDEntry(tiff, 0x0001, 2, 2, []byte{'N', 0, 0, 0})
+	return binary.LittleEndian.AppendUint32(tiff, 0)
+}
+
+func appendIFDEntry(tiff []byte, tag, kind uint16, count uint32, value []byte) []byte {
+	tiff = binary.LittleEndian.AppendUint16(tiff, tag)
+	tiff = binary.LittleEndian.AppendUint16(tiff, kind)
+	tiff = binary.LittleEndian.AppendUint32(tiff, count)
+	return append(tiff, value...)
+}
+
+// jpegWithGPS encodes a small photo and inserts an EXIF segment with GPS tags
+// right after the start of image marker.
+func jpegWithGPS(t *testing.T, exif []byte) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, 16, 16))
+	for x := 0; x < 16; x++ {
+		img.Set(x, x, color.RGBA{R: 200, A: 255})
+	}
+	var encoded bytes.Buffer
+	if err := jpeg.Encode(&encoded, img, nil); err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	data := encoded.Bytes()
+
+	segment := []byte{0xFF, 0xE1}
+	segment = binary.BigEndian.AppendUint16(segment, uint16(2+6+len(exif)))
+	segment = append(segment, "Exif\x00\x00"...)
+	segment = append(segment, exif...)
+	return append(append(append([]byte{}, data[:2]...), segment...), data[2:]...)
+}
+
+// lossless1x1 is the smallest lossless WebP image: one transparent pixel.
+const lossless1x1 = "UklGRhoAAABXRUJQVlA4TA0AAAAvAAAAEAcQERGIiP4HAA=="
+
+// webpWithGPS wraps the 1x1 image in the extended format with an EXIF chunk.
+func webpWithGPS(t *testing.T, exif []byte) []byte {
+	t.Helper()
+	simple, err := base64.StdEncoding.DecodeString(lossless1x1)
+	if err != nil {
+		t.Fatalf("decode fixture: %v", err)
+	}
+
+	data := []byte("RIFF\x00\x00\x00\x00WEBP")
+	data = append(data, "VP8X"...)
+	data = binary.LittleEndian.AppendUint32(data, 10)
+	data = append(data, 0x08, 0, 0, 0)    // EXIF present
+	data = append(data, 0, 0, 0, 0, 0, 0) // canvas width and height minus one
+	data = append(data, simple[12:]...)
+	data = append(data, "EXIF"...)
+	data = binary.LittleEndian.AppendUint32(data, uint32(len(exif)))
+	data = append(data, exif...)
+	if len(exif)%2 == 1 {
+		data = append(data, 0)
+	}
+	binary.LittleEndian.PutUint32(data[4:], uint32(len(data)-8))
+	return data
+}
+
+func TestProcessRemovesGPSTags(t *testing.T) {
+	exif := gpsExif()
+	tests := []struct {
+		name        string
+		data        []byte
+		contentType string
+	}{
+		{"jpeg", jpegWithGPS(t, exif), "image/jpeg"},
+		{"webp", webpWithGPS(t, exif), "image/webp"},
+		// trailing bytes the chunk walk can't account for
+		{"malformed webp", append(webpWithGPS(t, exif), 1, 2, 3), "image/png"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !bytes.Contains(tt.data, exif) {
+				t.Fatal("fixture has no EXIF data")
+			}
+			result, err := Process(tt.data)
+			if err != nil {
+				t.Fatalf("process: %v", err)
+			}
+			original := result.Original
+			if original.ContentType != tt.contentType {
+				t.Errorf("content type %q, want %q", original.ContentType, tt.contentType)
+			}
+			if bytes.Contains(original.Data, exif) || bytes.Contains(original.Data, []byte("Exif\x00\x00")) {
+				t.Error("GPS tags are kept")
+			}
+			if original.ContentType == "image/webp" && original.Data[20]&0x08 != 0 {
+				t.Error("EXIF flag is still set in the VP8X header")
+			}
+			if _, _, err := image.Decode(bytes.NewReader(original.Data)); err != nil {
+				t.Errorf("stored image doesn't decode: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package imaging
+
+import "encoding/binary"
+
+// stripWebPMetadata removes the EXIF and XMP chunks from a WebP file and
+// clears the matching flags in its extended header. It reports false when
+// data is not a well-formed RIFF container, since its metadata can't be
+// found then.
+func stripWebPMetadata(data []byte) ([]byte, bool) {
+	if len(data) < 12 || string(data[:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return nil, false
+	}
+
+	out := make([]byte, 12, len(data))
+	copy(out, data[:12])
+	for i := 12; i < len(data); {
+		if i+8 > len(data) {
+			return nil, false
+		}
+		fourCC := string(data[i : i+4])
+		size := int(binary.LittleEndian.Uint32(data[i+4:]))
+		end := i + 8 + size + size%2 // chunks are padded to an even length
+		if end > len(data) {
+			// some encoders leave out the padding of the last chunk
+			if end-size%2 != len(data) {
+				return nil, false
+			}
+			end = len(data)
+		}
+
+		switch fourCC {
+		case "EXIF", "XMP ":
+		case "VP8X":
+			start := len(out)
+			out = append(out, data[i:end]...)
+			if size > 0 {
+				out[start+8] &^= 0x08 | 0x04 // EXIF and XMP present flags
+			}
+		default:
+			out = append(out, data[i:end]...)
+		}
+		i = end
+	}
+	binary.LittleEndian.PutUint32(out[4:], uint32(len(out)-8))
+	return out, true
+}
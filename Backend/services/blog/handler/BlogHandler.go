@@ -2,9 +2,10 @@ package handler
 
 import (
 	"encoding/json"
-	"io"
+	"errors"
 	"log"
 	"net/http"
+	"strconv"
 
 	"blog.xws.com/model"
 	"blog.xws.com/service"
@@ -14,10 +15,14 @@ import (
 
 type BlogHandler struct {
 	BlogService *service.BlogService
+	Images      *service.ImageService
 }
 
+// maxBlogUpload bounds a blog upload with all of its images.
+const maxBlogUpload = 50 << 20
+
 func (handler *BlogHandler) GetAll(writer http.ResponseWriter, req *http.Request) {
-	blogs, err := handler.BlogService.GetAllBlogs()
+	blogs, err := handler.BlogService.GetAllBlogs(callerId(req))
 	writer.Header().Set("Content-Type", "application/json")
 	if err != nil {
 		http.Error(writer, err.Error(), http.StatusInternalServerError)
@@ -28,6 +33,42 @@ func (handler *BlogHandler) GetAll(writer http.ResponseWriter, req *http.Request
 	json.NewEncoder(writer).Encode(blogs)
 }
 
+// GetFeed returns one page of the blogs of the users the caller follows,
+// selected with the page and pageSize query parameters.
+func (handler *BlogHandler) GetFeed(writer http.ResponseWriter, req *http.Request) {
+	viewerId := callerId(req)
+	if viewerId == "" {
+		http.Error(writer, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	paging := map[string]int{"page": 0, "pageSize": 0}
+	for name := range paging {
+		if raw := req.URL.Query().Get(name); raw != "" {
+			v, err := strconv.Atoi(raw)
+			if err != nil {
+				http.Error(writer, "Invalid "+name, http.StatusBadRequest)
+				return
+			}
+			paging[name] = v
+		}
+	}
+
+	feed, err := handler.BlogService.GetFeed(viewerId, paging["page"], paging["pageSize"])
+	if errors.Is(err, service.ErrFollowersUnavailable) {
+		http.Error(writer, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	if err != nil {
+		http.Error(writer, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	json.NewEncoder(writer).Encode(feed)
+}
+
 func (handler *BlogHandler) GetById(writer http.ResponseWriter, req *http.Request) {
 	idStr := mux.Vars(req)["id"]
 	log.Printf("Blog sa id-em %s", idStr)
@@ -38,7 +79,7 @@ func (handler *BlogHandler) GetById(writer http.ResponseWriter, req *http.Reques
 		return
 	}
 
-	blog, err := handler.BlogService.GetById(id) // make sure your service takes uuid.UUID
+	blog, err := handler.BlogService.GetById(id, caller(req))
 	writer.Header().Set("Content-Type", "application/json")
 	if err != nil {
 		writer.WriteHeader(http.StatusNotFound)
@@ -54,6 +95,7 @@ func (handler *BlogHandler) Create(writer http.ResponseWriter, req *http.Request
 	contentType := req.Header.Get("Content-Type")
 	
 	var blog *model.Blog
+	var uploads *uploadForm
 	var err error
 
 	if contentType == "application/json" {
@@ -65,60 +107,45 @@ func (handler *BlogHandler) Create(writer http.ResponseWriter, req *http.Request
 			writer.WriteHeader(http.StatusBadRequest)
 			return
 		}
+		// images are only accepted as uploads
+		blogData.Images = nil
 		blog = &blogData
 	} else {
-		// Handle multipart form data (new behavior for file uploads)
-		err = req.ParseMultipartForm(50 << 20) // 50MB limit for multiple images
+		// Handle multipart form data, streaming the images into the store
+		form, err := readUploadForm(writer, req, handler.Images, "blogs", maxBlogUpload, "images")
 		if err != nil {
-			http.Error(writer, "Failed to parse multipart form", http.StatusBadRequest)
+			writeUploadError(writer, err)
 			return
 		}
+		defer form.Close()
+		uploads = form
 
 		// Extract form fields
-		userId := req.FormValue("userId")
-		title := req.FormValue("title")
-		description := req.FormValue("description")
-
-		// Handle multiple image uploads
-		var images []model.Image
-		files := req.MultipartForm.File["images"]
-		for _, fileHeader := range files {
-			file, err := fileHeader.Open()
-			if err != nil {
-				continue // Skip files that can't be opened
-			}
-			defer file.Close()
-
-			// Read image data
-			imageData, err := io.ReadAll(file)
-			if err != nil {
-				continue // Skip files that can't be read
-			}
-
-			// Validate image type
-			mimeType := fileHeader.Header.Get("Content-Type")
-			if mimeType != "image/jpeg" && mimeType != "image/png" && mimeType != "image/gif" && mimeType != "image/webp" {
-				continue // Skip invalid image formats
-			}
-
-			images = append(images, model.Image{
-				Data:     imageData,
-				MimeType: mimeType,
-				Filename: fileHeader.Filename,
-			})
-		}
+		userId := form.Values.Get("userId")
+		title := form.Values.Get("title")
+		description := form.Values.Get("description")
+		images := form.Files["images"]
 
 		blog = model.BeforeCreateTour(userId, title, description, images)
 	}
 
+	// the author is whoever the gateway authenticated, when known
+	if userId := callerId(req); userId != "" {
+		blog.UserId = userId
+	}
 	err = handler.BlogService.Create(blog)
 	if err != nil {
 		println("Error while creating a new blog")
 		writer.WriteHeader(http.StatusExpectationFailed)
 		return
 	}
-	writer.WriteHeader(http.StatusCreated)
+	if uploads != nil {
+		uploads.Keep()
+	}
+	// the response carries the rendered description, as GET /blogs/{id} does
 	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusCreated)
+	json.NewEncoder(writer).Encode(blog)
 	println("Blog successfully created")
 }
 
@@ -132,7 +159,11 @@ func (handler *BlogHandler) Delete(writer http.ResponseWriter, req *http.Request
 		return
 	}
 
-	err = handler.BlogService.Delete(id)
+	err = handler.BlogService.Delete(id, caller(req))
+	if errors.Is(err, service.ErrForbidden) {
+		http.Error(writer, err.Error(), http.StatusForbidden)
+		return
+	}
 	if err != nil {
 		http.Error(writer, err.Error(), http.StatusNotFound)
 		return
@@ -152,6 +183,7 @@ func (handler *BlogHandler) Update(writer http.ResponseWriter, req *http.Request
 		return
 	}
 
+	// images lists the existing images to keep, by id
 	var input struct {
 		Title       string        `json:"title"`
 		Description string        `json:"description"`
@@ -169,7 +201,11 @@ func (handler *BlogHandler) Update(writer http.ResponseWriter, req *http.Request
 		Images:      input.Images,
 	}
 
-	err = handler.BlogService.Update(id, updatedBlog)
+	err = handler.BlogService.Update(id, updatedBlog, caller(req))
+	if errors.Is(err, service.ErrForbidden) {
+		http.Error(writer, err.Error(), http.StatusForbidden)
+		return
+	}
 	if err != nil {
 		http.Error(writer, "Blog not found", http.StatusNotFound)
 		return
@@ -179,12 +215,33 @@ func (handler *BlogHandler) Update(writer http.ResponseWriter, req *http.Request
 	json.NewEncoder(writer).Encode(map[string]string{"message": "Blog updated successfully"})
 }
 
+// GetImage streams one of the blog's stored images.
+func (handler *BlogHandler) GetImage(writer http.ResponseWriter, req *http.Request) {
+	id, err := uuid.Parse(mux.Vars(req)["id"])
+	if err != nil {
+		http.Error(writer, "Invalid UUID", http.StatusBadRequest)
+		return
+	}
+	imageId, err := uuid.Parse(mux.Vars(req)["imageId"])
+	if err != nil {
+		http.Error(writer, "Invalid image id", http.StatusBadRequest)
+		return
+	}
+
+	image, err := handler.BlogService.GetImage(id, imageId, caller(req))
+	if err != nil {
+		http.Error(writer, err.Error(), http.StatusNotFound)
+		return
+	}
+	writeImage(writer, req, handler.Images, image)
+}
+
 func (handler *BlogHandler) GetAllByUser(writer http.ResponseWriter, req *http.Request) {
 	userID := mux.Vars(req)["userId"]
 
 	writer.Header().Set("Content-Type", "application/json")
 
-	blogs, err := handler.BlogService.GetAllByUser(userID)
+	blogs, err := handler.BlogService.GetAllByUser(userID, callerId(req))
 	if err != nil {
 		http.Error(writer, err.Error(), http.StatusInternalServerError)
 		return
@@ -193,3 +250,43 @@ func (handler *BlogHandler) GetAllByUser(writer http.ResponseWriter, req *http.R
 	writer.WriteHeader(http.StatusOK)
 	json.NewEncoder(writer).Encode(blogs)
 }
+
+func (handler *BlogHandler) Publish(writer http.ResponseWriter, req *http.Request) {
+	handler.changeStatus(writer, req, handler.BlogService.Publish)
+}
+
+func (handler *BlogHandler) Close(writer http.ResponseWriter, req *http.Request) {
+	handler.changeStatus(writer, req, handler.BlogService.Close)
+}
+
+func (handler *BlogHandler) Reopen(writer http.ResponseWriter, req *http.Request) {
+	handler.changeStatus(writer, req, handler.BlogService.Reopen)
+}
+
+func (handler *BlogHandler) changeStatus(writer http.ResponseWriter, req *http.Request, transition func(uuid.UUID, service.Caller) (model.Blog, error)) {
+	idStr := mux.Vars(req)["id"]
+	log.Printf("Changing status of blog with ID: %s", idStr)
+
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		http.Error(writer, "Invalid UUID", http.StatusBadRequest)
+		return
+	}
+
+	blog, err := transition(id, caller(req))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrForbidden):
+			http.Error(writer, err.Error(), http.StatusForbidden)
+		case errors.Is(err, service.ErrInvalidStatusTransition):
+			http.Error(writer, err.Error(), http.StatusConflict)
+		default:
+			http.Error(writer, err.Error(), http.StatusNotFound)
+		}
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	json.NewEncoder(writer).Encode(blog)
+}
@@ -2,10 +2,13 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 
 	"blog.xws.com/model"
+	"blog.xws.com/repository"
 	"blog.xws.com/service"
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
@@ -16,19 +19,23 @@ type CommentHandler struct {
 }
 
 func (handler *CommentHandler) Create(writer http.ResponseWriter, req *http.Request) {
+	// the commenter is whoever the gateway authenticated, never the body's userId
+	userId := callerId(req)
+	if userId == "" {
+		http.Error(writer, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
 	var comment model.Comment
 	err := json.NewDecoder(req.Body).Decode(&comment)
-	fmt.Printf("Handler: Create: %+v\n", comment.UserId)
-	println("Comment userId: ", comment.UserId)
-
 	if err != nil {
 		writer.WriteHeader(http.StatusBadRequest)
 		return
 	}
-	err = handler.CommentService.CreateComment(&comment)
+	err = handler.CommentService.CreateComment(&comment, userId)
 	if err != nil {
 		println(err.Error())
-		writer.WriteHeader(http.StatusExpectationFailed)
+		writeCommentError(writer, err)
 		return
 	}
 	writer.Header().Set("Content-Type", "application/json")
@@ -37,18 +44,25 @@ func (handler *CommentHandler) Create(writer http.ResponseWriter, req *http.Requ
 }
 
 func (handler *CommentHandler) Update(writer http.ResponseWriter, req *http.Request) {
+	id, err := uuid.Parse(mux.Vars(req)["id"])
+	if err != nil {
+		http.Error(writer, "Invalid comment ID", http.StatusBadRequest)
+		return
+	}
+
 	var comment model.Comment
-	err := json.NewDecoder(req.Body).Decode(&comment)
+	err = json.NewDecoder(req.Body).Decode(&comment)
 	if err != nil {
 		fmt.Println("Error while parsing json:", err)
 		writer.WriteHeader(http.StatusBadRequest)
 		return
 	}
+	comment.ID = id
 
-	err = handler.CommentService.UpdateComment(&comment)
+	err = handler.CommentService.UpdateComment(&comment, caller(req))
 	if err != nil {
-		fmt.Println("Error while updating the blog:", err)
-		writer.WriteHeader(http.StatusInternalServerError)
+		fmt.Println("Error while updating the comment:", err)
+		writeCommentError(writer, err)
 		return
 	}
 
@@ -75,6 +89,8 @@ func (handler *CommentHandler) GetById(writer http.ResponseWriter, req *http.Req
 	writer.WriteHeader(http.StatusOK)
 	json.NewEncoder(writer).Encode(blog)
 }
+
+// Delete soft-deletes the comment; its replies stay in the thread.
 func (handler *CommentHandler) Delete(writer http.ResponseWriter, req *http.Request) {
 	idV := mux.Vars(req)["id"]
 
@@ -84,25 +100,47 @@ func (handler *CommentHandler) Delete(writer http.ResponseWriter, req *http.Requ
 		http.Error(writer, "Invalid comment ID", http.StatusBadRequest)
 		return
 	}
-	err = handler.CommentService.Delete(id)
+	err = handler.CommentService.Delete(id, caller(req))
 	if err != nil {
-		println("Error while deleting a facility")
-		writer.WriteHeader(http.StatusInternalServerError)
+		println("Error while deleting a comment")
+		writeCommentError(writer, err)
 		return
 	}
 	writer.WriteHeader(http.StatusAccepted)
 }
 
+// GetByBlogId returns one page of the blog's comment threads, selected with
+// the sort (newest, oldest or top), page and pageSize query parameters.
 func (handler *CommentHandler) GetByBlogId(writer http.ResponseWriter, req *http.Request) {
 	idV := mux.Vars(req)["blogId"]
+	if idV == "" {
+		idV = mux.Vars(req)["id"]
+	}
 
 	id, err := uuid.Parse(idV)
 	if err != nil {
-		http.Error(writer, "Invalid blog status", http.StatusBadRequest)
+		http.Error(writer, "Invalid blog ID", http.StatusBadRequest)
 		return
 	}
 
-	comments, err := handler.CommentService.GetByBlogId(id)
+	paging := map[string]int{"page": 0, "pageSize": 0}
+	for name := range paging {
+		if raw := req.URL.Query().Get(name); raw != "" {
+			v, err := strconv.Atoi(raw)
+			if err != nil {
+				http.Error(writer, "Invalid "+name, http.StatusBadRequest)
+				return
+			}
+			paging[name] = v
+		}
+	}
+	order := repository.CommentSort(req.URL.Query().Get("sort"))
+
+	comments, err := handler.CommentService.GetByBlogId(id, callerId(req), order, paging["page"], paging["pageSize"])
+	if errors.Is(err, service.ErrInvalidCommentQuery) {
+		http.Error(writer, err.Error(), http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		writer.WriteHeader(http.StatusInternalServerError)
 		return
@@ -116,3 +154,52 @@ func (handler *CommentHandler) GetByBlogId(writer http.ResponseWriter, req *http
 		return
 	}
 }
+
+func (handler *CommentHandler) Like(writer http.ResponseWriter, req *http.Request) {
+	handler.changeLike(writer, req, handler.CommentService.LikeComment)
+}
+
+func (handler *CommentHandler) Unlike(writer http.ResponseWriter, req *http.Request) {
+	handler.changeLike(writer, req, handler.CommentService.UnlikeComment)
+}
+
+// changeLike likes or unlikes the comment for the caller and returns it with
+// its new like count.
+func (handler *CommentHandler) changeLike(writer http.ResponseWriter, req *http.Request, change func(uuid.UUID, string) (model.Comment, error)) {
+	id, err := uuid.Parse(mux.Vars(req)["id"])
+	if err != nil {
+		http.Error(writer, "Invalid comment ID", http.StatusBadRequest)
+		return
+	}
+	userId := callerId(req)
+	if userId == "" {
+		http.Error(writer, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	comment, err := change(id, userId)
+	if err != nil {
+		writeCommentError(writer, err)
+		return
+	}
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	json.NewEncoder(writer).Encode(comment)
+}
+
+func writeCommentError(writer http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrCommentNotFound), errors.Is(err, service.ErrBlogNotFound):
+		http.Error(writer, err.Error(), http.StatusNotFound)
+	case errors.Is(err, service.ErrNotCommentAuthor), errors.Is(err, service.ErrNotFollowing):
+		http.Error(writer, err.Error(), http.StatusForbidden)
+	case errors.Is(err, service.ErrBlogClosed), errors.Is(err, service.ErrCommentDeleted):
+		http.Error(writer, err.Error(), http.StatusConflict)
+	case errors.Is(err, service.ErrCommentTooDeep):
+		http.Error(writer, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, service.ErrFollowersUnavailable):
+		http.Error(writer, err.Error(), http.StatusServiceUnavailable)
+	default:
+		http.Error(writer, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,22 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"blog.xws.com/service"
+)
+
+// callerId returns the authenticated user the gateway forwarded the request for.
+func callerId(req *http.Request) string {
+	return req.Header.Get("X-User-Id")
+}
+
+// caller returns the forwarded user together with their roles.
+func caller(req *http.Request) service.Caller {
+	var roles []string
+	if header := req.Header.Get("X-Roles"); header != "" {
+		roles = strings.Split(header, ",")
+	}
+	return service.Caller{Id: callerId(req), Roles: roles}
+}
@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"blog.xws.com/model"
+	"blog.xws.com/service"
+	"github.com/zopuu/soa-team-20/Backend/pkg/imaging"
+)
+
+// uploadForm is a multipart form whose images were streamed straight into the
+// blob store. Uploads are deleted again on Close unless Keep was called.
+type uploadForm struct {
+	Values url.Values
+	Files  map[string][]model.Image
+	images *service.ImageService
+	kept   bool
+}
+
+// readUploadForm streams the request's multipart body, storing the parts named
+// in fileFields as images below prefix and collecting the other fields. Files
+// that are not supported images are skipped.
+func readUploadForm(writer http.ResponseWriter, req *http.Request, images *service.ImageService, prefix string, maxBytes int64, fileFields ...string) (*uploadForm, error) {
+	req.Body = http.MaxBytesReader(writer, req.Body, maxBytes)
+	reader, err := req.MultipartReader()
+	if err != nil {
+		return nil, err
+	}
+
+	form := &uploadForm{Values: url.Values{}, Files: map[string][]model.Image{}, images: images}
+	isFile := map[string]bool{}
+	for _, field := range fileFields {
+		isFile[field] = true
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return form, nil
+		}
+		if err != nil {
+			form.Close()
+			return nil, err
+		}
+
+		name := part.FormName()
+		if !isFile[name] || part.FileName() == "" {
+			value, err := io.ReadAll(io.LimitReader(part, 1<<20))
+			part.Close()
+			if err != nil {
+				form.Close()
+				return nil, err
+			}
+			form.Values.Add(name, string(value))
+			continue
+		}
+
+		// the declared Content-Type is ignored; Save detects the type from the content
+		image, err := images.Save(req.Context(), prefix, part.FileName(), part)
+		part.Close()
+		if errors.Is(err, service.ErrUnsupportedImageType) || errors.Is(err, service.ErrImageTooLarge) {
+			continue
+		}
+		if err != nil {
+			form.Close()
+			return nil, err
+		}
+		form.Files[name] = append(form.Files[name], image)
+	}
+}
+
+// Keep marks the uploads as referenced by a stored document.
+func (form *uploadForm) Keep() {
+	form.kept = true
+}
+
+func (form *uploadForm) Close() {
+	if form.kept {
+		return
+	}
+	for _, files := range form.Files {
+		form.images.Delete(files...)
+	}
+}
+
+// writeUploadError reports a multipart body readUploadForm rejected.
+func writeUploadError(writer http.ResponseWriter, err error) {
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge):
+		http.Error(writer, "Upload too large", http.StatusRequestEntityTooLarge)
+	case errors.Is(err, http.ErrNotMultipart), errors.Is(err, http.ErrMissingBoundary):
+		http.Error(writer, "Failed to parse multipart form", http.StatusBadRequest)
+	default:
+		log.Printf("upload failed: %v", err)
+		http.Error(writer, "Failed to store uploaded image", http.StatusInternalServerError)
+	}
+}
+
+// writeImage streams the stored image to the client in the rendition asked
+// for by the size query parameter (thumb, medium or original).
+func writeImage(writer http.ResponseWriter, req *http.Request, images *service.ImageService, image model.Image) {
+	size, err := imaging.ParseSize(req.URL.Query().Get("size"))
+	if err != nil {
+		http.Error(writer, "size must be thumb, medium or original", http.StatusBadRequest)
+		return
+	}
+
+	content, variant, err := images.Open(req.Context(), image, size)
+	if errors.Is(err, service.ErrImageNotFound) {
+		http.Error(writer, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(writer, "Failed to read image", http.StatusInternalServerError)
+		return
+	}
+	defer content.Close()
+
+	writer.Header().Set("Content-Type", variant.MimeType)
+	if variant.Size > 0 {
+		writer.Header().Set("Content-Length", strconv.FormatInt(variant.Size, 10))
+	}
+	io.Copy(writer, content)
+}
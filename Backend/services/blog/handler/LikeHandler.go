@@ -2,6 +2,7 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 
 	"net/http"
 
@@ -14,6 +15,8 @@ type LikeHandler struct {
 	LikeService *service.LikeService
 }
 
+// Create likes the blog for the caller and returns its like count. Liking a
+// blog again is answered with 200 OK instead of 201 Created.
 func (handler *LikeHandler) Create(writer http.ResponseWriter, req *http.Request) {
 	var like model.Like
 	err := json.NewDecoder(req.Body).Decode(&like)
@@ -22,36 +25,59 @@ func (handler *LikeHandler) Create(writer http.ResponseWriter, req *http.Request
 		writer.WriteHeader(http.StatusBadRequest)
 		return
 	}
-	err = handler.LikeService.CreateLike(&like)
+	// the like is the authenticated user's, when known
+	if userId := callerId(req); userId != "" {
+		like.UserId = userId
+	}
+	if like.UserId == "" {
+		http.Error(writer, "Missing userId", http.StatusBadRequest)
+		return
+	}
+
+	summary, created, err := handler.LikeService.CreateLike(&like)
 	if err != nil {
-		writer.WriteHeader(http.StatusExpectationFailed)
+		writeLikeError(writer, err)
 		return
 	}
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
 	writer.Header().Set("Content-Type", "application/json")
-	writer.WriteHeader(http.StatusCreated)
-	json.NewEncoder(writer).Encode(like)
+	writer.WriteHeader(status)
+	json.NewEncoder(writer).Encode(summary)
 }
 
+// Delete takes back a like and returns the blog's like count. Taking back a
+// like that does not exist is not an error.
 func (handler *LikeHandler) Delete(writer http.ResponseWriter, req *http.Request) {
 	userId := mux.Vars(req)["userId"]
 	blogId := mux.Vars(req)["blogId"]
 
-	/*userIdd, err := uuid.Parse(userId)
-	blogIdd, err := uuid.Parse(blogId)
-
-	if err != nil {
-		http.Error(writer, "Invalid like user ID", http.StatusBadRequest)
-		print(err)
+	if id := callerId(req); id != "" && id != userId && !caller(req).IsAdmin() {
+		http.Error(writer, "Cannot remove another user's like", http.StatusForbidden)
 		return
-	}*/
-	err := handler.LikeService.Delete(userId, blogId)
+	}
+
+	summary, err := handler.LikeService.Delete(userId, blogId)
 	if err != nil {
-		println("Error while deleting a like")
-		println(err.Error())
-		writer.WriteHeader(http.StatusInternalServerError)
+		writeLikeError(writer, err)
 		return
 	}
-	writer.WriteHeader(http.StatusAccepted)
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	json.NewEncoder(writer).Encode(summary)
+}
+
+func writeLikeError(writer http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrBlogClosed):
+		http.Error(writer, err.Error(), http.StatusConflict)
+	case errors.Is(err, service.ErrBlogNotFound):
+		http.Error(writer, err.Error(), http.StatusNotFound)
+	default:
+		http.Error(writer, err.Error(), http.StatusInternalServerError)
+	}
 }
 
 func (handler *LikeHandler) GetByBlogId(writer http.ResponseWriter, req *http.Request) {
@@ -8,12 +8,17 @@ import (
 	"time"
 
 	"blog.xws.com/handler"
+	"blog.xws.com/migration"
 	"blog.xws.com/repository"
 	"blog.xws.com/service"
 	"github.com/gorilla/mux"
+	"github.com/zopuu/soa-team-20/Backend/pkg/blobstore"
+	"github.com/zopuu/soa-team-20/Backend/services/followers_service/proto/followerspb"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
 )
 
 type MongoCollections struct {
@@ -55,15 +60,73 @@ func initMongoDB() MongoCollections {
 		},
 		Options: options.Index().SetUnique(true),
 	}
+	// likes are listed and counted per blog
+	blogLikesIndex := mongo.IndexModel{
+		Keys: bson.D{{Key: "blogId", Value: 1}},
+	}
 
-	_, err = collections.Likes.Indexes().CreateOne(ctx, likeIndex)
+	_, err = collections.Likes.Indexes().CreateMany(ctx, []mongo.IndexModel{likeIndex, blogLikesIndex})
 	if err != nil {
 		log.Fatalf("Failed to create index on Likes: %v", err)
 	}
 
+	// the feed lists the followed authors' blogs, newest first
+	feedIndex := mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "userId", Value: 1},
+			{Key: "publishedAt", Value: -1},
+		},
+	}
+	_, err = collections.Blogs.Indexes().CreateOne(ctx, feedIndex)
+	if err != nil {
+		log.Fatalf("Failed to create index on Blogs: %v", err)
+	}
+
+	// top-level comments are paged per blog by date or likes, replies are
+	// loaded per thread
+	commentIndexes := []mongo.IndexModel{
+		{Keys: bson.D{
+			{Key: "blog_id", Value: 1},
+			{Key: "depth", Value: 1},
+			{Key: "date_of_creation", Value: -1},
+		}},
+		{Keys: bson.D{
+			{Key: "blog_id", Value: 1},
+			{Key: "depth", Value: 1},
+			{Key: "like_count", Value: -1},
+		}},
+		{Keys: bson.D{{Key: "root_id", Value: 1}}},
+	}
+	_, err = collections.Comments.Indexes().CreateMany(ctx, commentIndexes)
+	if err != nil {
+		log.Fatalf("Failed to create index on Comments: %v", err)
+	}
+
 	return collections
 }
 
+// initImageStore opens the blob store images are kept in, configured by the BLOB_* variables.
+func initImageStore() *service.ImageService {
+	store, err := blobstore.New(context.Background(), blobstore.ConfigFromEnv("./data/blobs", "blog-images"))
+	if err != nil {
+		log.Fatalf("Failed to open image store: %v", err)
+	}
+	return &service.ImageService{Store: store}
+}
+
+// initFollowService connects to the followers service at FOLLOWERS_GRPC_ADDR.
+func initFollowService() (*service.FollowService, *grpc.ClientConn) {
+	addr := os.Getenv("FOLLOWERS_GRPC_ADDR")
+	if addr == "" {
+		addr = "localhost:50051"
+	}
+	conn, err := grpc.Dial(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		log.Fatalf("Failed to connect to followers service: %v", err)
+	}
+	return &service.FollowService{Client: followerspb.NewFollowersServiceClient(conn)}, conn
+}
+
 func cors(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Adjust origin as needed; use "*" only if you don't use credentials
@@ -89,15 +152,22 @@ func startServer(handler *handler.BlogHandler, commentHandler *handler.CommentHa
 	router := mux.NewRouter().StrictSlash(true)
 
 	router.HandleFunc("/blogs", handler.GetAll).Methods("GET")
+	router.HandleFunc("/blogs/feed", handler.GetFeed).Methods("GET")
 	router.HandleFunc("/blogs/{id}", handler.GetById).Methods("GET")
+	router.HandleFunc("/blogs/{id}/images/{imageId}", handler.GetImage).Methods("GET")
 	router.HandleFunc("/blogs", handler.Create).Methods("POST")
 	router.HandleFunc("/blogs/{id}", handler.Delete).Methods("DELETE")
 	router.HandleFunc("/blogs/{id}", handler.Update).Methods("PUT")
+	router.HandleFunc("/blogs/{id}/publish", handler.Publish).Methods("POST")
+	router.HandleFunc("/blogs/{id}/close", handler.Close).Methods("POST")
+	router.HandleFunc("/blogs/{id}/reopen", handler.Reopen).Methods("POST")
 	router.HandleFunc("/blogs/users/{userId}", handler.GetAllByUser).Methods("GET")
 	router.HandleFunc("/blogs/{id}/comments", commentHandler.GetByBlogId).Methods("GET")
 	router.HandleFunc("/blogs/comments", commentHandler.Create).Methods("POST")
 	router.HandleFunc("/blogs/comments/{id}", commentHandler.Update).Methods("PUT")
 	router.HandleFunc("/blogs/comments/{id}", commentHandler.Delete).Methods("DELETE")
+	router.HandleFunc("/blogs/comments/{id}/likes", commentHandler.Like).Methods("POST")
+	router.HandleFunc("/blogs/comments/{id}/likes", commentHandler.Unlike).Methods("DELETE")
 	//router.HandleFunc("/blogs/comments/{id}", commentHandler.GetById).Methods("GET")
 	router.HandleFunc("/blogs/comments/{blogId}", commentHandler.GetByBlogId).Methods("GET")
 	router.HandleFunc("/blogs/likes/{blogId}", likeHandler.GetByBlogId).Methods("GET")
@@ -117,18 +187,56 @@ func startServer(handler *handler.BlogHandler, commentHandler *handler.CommentHa
 }
 func main() {
 	collections := initMongoDB()
+	images := initImageStore()
+
+	// "migrate-images" moves image bytes still stored in Mongo into the blob store and exits.
+	if len(os.Args) > 1 && os.Args[1] == "migrate-images" {
+		if err := migration.MigrateImages(context.Background(), collections.Blogs, images); err != nil {
+			log.Fatalf("Image migration failed: %v", err)
+		}
+		return
+	}
 
+	// Blogs stored before blogs had a status were all public.
 	blogRepository := &repository.BlogRepository{Collection: collections.Blogs}
-	blogService := &service.BlogService{BlogRepository: blogRepository}
-	blogHandler := &handler.BlogHandler{BlogService: blogService}
+	backfilled, err := blogRepository.BackfillStatus()
+	if err != nil {
+		log.Fatalf("Failed to backfill blog status: %v", err)
+	}
+	if backfilled > 0 {
+		log.Printf("Published %d blogs stored without a status", backfilled)
+	}
+	follows, followersConn := initFollowService()
+	defer followersConn.Close()
+
+	likeRepository := &repository.LikeRepository{Collection: collections.Likes}
+	blogService := &service.BlogService{BlogRepository: blogRepository, LikeRepository: likeRepository, Images: images, Follows: follows}
+	blogHandler := &handler.BlogHandler{BlogService: blogService, Images: images}
 
 	commentRepository := &repository.CommentRepository{Collection: collections.Comments}
-	commentService := &service.CommentService{CommentRepository: commentRepository}
+	commentService := &service.CommentService{CommentRepository: commentRepository, BlogRepository: blogRepository, Follows: follows}
 	commentHandler := &handler.CommentHandler{CommentService: commentService}
 
-	likeRepository := &repository.LikeRepository{Collection: collections.Likes}
-	likeService := &service.LikeService{LikeRepository: likeRepository}
+	// Comments stored before threads were flat and had no likes.
+	threaded, err := commentRepository.BackfillThreads()
+	if err != nil {
+		log.Fatalf("Failed to backfill comment threads: %v", err)
+	}
+	if threaded > 0 {
+		log.Printf("Prepared %d comments stored before threaded comments", threaded)
+	}
+
+	likeService := &service.LikeService{LikeRepository: likeRepository, BlogRepository: blogRepository}
 	likeHandler := &handler.LikeHandler{LikeService: likeService}
 
+	// Blogs stored before the like count kept their likes only in the likes collection.
+	counted, err := likeService.BackfillLikeCounts()
+	if err != nil {
+		log.Fatalf("Failed to backfill like counts: %v", err)
+	}
+	if counted > 0 {
+		log.Printf("Counted the likes of %d blogs stored without a like count", counted)
+	}
+
 	startServer(blogHandler, commentHandler, likeHandler)
 }
@@ -0,0 +1,68 @@
+// Package migration holds one-off data migrations run through the service binary.
+package migration
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+
+	"blog.xws.com/model"
+	"blog.xws.com/service"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// legacyImage is the shape images had while their bytes were stored in Mongo.
+type legacyImage struct {
+	Data     []byte `bson:"data"`
+	MimeType string `bson:"mimeType"`
+	Filename string `bson:"filename"`
+}
+
+// MigrateImages moves blog image bytes into the blob store, leaving only
+// references behind. Images go through the same processing as new uploads,
+// so they also get variants and lose their metadata. Blogs already migrated are skipped, so the migration
+// can be rerun after a failure.
+func MigrateImages(ctx context.Context, blogs *mongo.Collection, images *service.ImageService) error {
+	cursor, err := blogs.Find(ctx, bson.M{"images.data": bson.M{"$exists": true}})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	moved := 0
+	for cursor.Next(ctx) {
+		var doc struct {
+			Id     uuid.UUID     `bson:"_id"`
+			Images []legacyImage `bson:"images"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			return err
+		}
+
+		migrated := make([]model.Image, 0, len(doc.Images))
+		for _, legacy := range doc.Images {
+			if len(legacy.Data) == 0 {
+				continue
+			}
+			image, err := images.Save(ctx, "blogs", legacy.Filename, bytes.NewReader(legacy.Data))
+			if err != nil {
+				images.Delete(migrated...)
+				return fmt.Errorf("blog %s: %w", doc.Id, err)
+			}
+			migrated = append(migrated, image)
+		}
+		if _, err := blogs.UpdateOne(ctx, bson.M{"_id": doc.Id}, bson.M{"$set": bson.M{"images": migrated}}); err != nil {
+			images.Delete(migrated...)
+			return err
+		}
+		moved += len(migrated)
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+	log.Printf("Moved %d blog images to the blob store", moved)
+	return nil
+}
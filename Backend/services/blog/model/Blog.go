@@ -6,14 +6,46 @@ import (
 	"github.com/google/uuid"
 )
 
+// Blog is a post written in Markdown. Description holds the source as the
+// author wrote it and DescriptionHtml its sanitized rendering, with image
+// references pointing at the blog's own Images. Only the author sees a blog
+// while it is a draft; a closed blog stays readable but takes no new
+// comments or likes. LikeCount is kept in step with the likes collection and
+// LikedByMe is filled in for whoever asked for the blog.
 type Blog struct {
-	Id             uuid.UUID `json:"id" bson:"_id,omitempty"`
-	UserId         string    `json:"userId" bson:"userId"`
-	Title          string    `json:"title" bson:"title"`
-	Description    string    `json:"description" bson:"description"`
-	DateOfCreation time.Time `json:"date_of_creation" bson:"date_of_creation"`
-	Images         []Image   `json:"images" bson:"images"`
-	Likes          []Like    `json:"likes" bson:"likes"`
+	Id              uuid.UUID  `json:"id" bson:"_id,omitempty"`
+	UserId          string     `json:"userId" bson:"userId"`
+	Title           string     `json:"title" bson:"title"`
+	Description     string     `json:"description" bson:"description"`
+	DescriptionHtml string     `json:"descriptionHtml" bson:"descriptionHtml"`
+	DateOfCreation  time.Time  `json:"date_of_creation" bson:"date_of_creation"`
+	Images          []Image    `json:"images" bson:"images"`
+	LikeCount       int64      `json:"likeCount" bson:"likeCount"`
+	LikedByMe       bool       `json:"likedByMe" bson:"-"`
+	Status          BlogStatus `json:"status" bson:"status"`
+	PublishedAt     time.Time  `json:"publishedAt" bson:"publishedAt"`
+	ClosedAt        time.Time  `json:"closedAt" bson:"closedAt"`
+}
+
+// BlogPage is one page of a blog listing.
+type BlogPage struct {
+	Items    []Blog `json:"items"`
+	Page     int    `json:"page"`
+	PageSize int    `json:"pageSize"`
+	Total    int64  `json:"total"`
+}
+
+type BlogStatus int
+
+const (
+	Draft BlogStatus = iota
+	Published
+	Closed
+)
+
+// IsPublic reports whether readers other than the author can see the blog.
+func (blog Blog) IsPublic() bool {
+	return blog.Status == Published || blog.Status == Closed
 }
 
 func BeforeCreateTour(userId string, title, description string, images []Image) *Blog {
@@ -24,6 +56,6 @@ func BeforeCreateTour(userId string, title, description string, images []Image)
 		Description:    description,
 		DateOfCreation: time.Now(),
 		Images:         images,
-		Likes:          []Like{},
+		Status:         Draft,
 	}
 }
@@ -6,13 +6,37 @@ import (
 	"github.com/google/uuid"
 )
 
+// DeletedCommentText replaces the text of a deleted comment, which stays in
+// its thread so that the replies to it keep their place.
+const DeletedCommentText = "[deleted]"
+
+// Comment is a comment on a blog, or a reply to another comment of the same
+// blog when ParentId is set. RootId is the top-level comment of the thread and
+// Depth how many replies deep the comment is, 0 for top-level comments.
+// LikedByMe and Replies are filled in when comments are listed.
 type Comment struct {
 	ID             uuid.UUID `json:"id" bson:"_id,omitempty"`
 	UserId         string    `json:"userId" bson:"user_id"`
 	BlogId         string    `json:"blogId" bson:"blog_id"`
+	ParentId       string    `json:"parentId,omitempty" bson:"parent_id,omitempty"`
+	RootId         string    `json:"rootId,omitempty" bson:"root_id,omitempty"`
+	Depth          int       `json:"depth" bson:"depth"`
 	DateOfCreation time.Time `json:"dateOfCreation" bson:"date_of_creation"`
 	Text           string    `json:"text" bson:"text"`
 	LastEdit       time.Time `json:"lastEdit" bson:"last_edit"`
+	Deleted        bool      `json:"deleted" bson:"deleted"`
+	LikeCount      int64     `json:"likeCount" bson:"like_count"`
+	LikedBy        []string  `json:"-" bson:"liked_by"`
+	LikedByMe      bool      `json:"likedByMe" bson:"-"`
+	Replies        []Comment `json:"replies" bson:"-"`
+}
+
+// CommentPage is one page of a blog's top-level comments, each with all of its replies.
+type CommentPage struct {
+	Items    []Comment `json:"items"`
+	Page     int       `json:"page"`
+	PageSize int       `json:"pageSize"`
+	Total    int64     `json:"total"`
 }
 
 func CreateNewComment(userId string, blogId string, text string) *Comment {
@@ -23,5 +47,18 @@ func CreateNewComment(userId string, blogId string, text string) *Comment {
 		DateOfCreation: time.Now(),
 		Text:           text,
 		LastEdit:       time.Now(),
+		LikedBy:        []string{},
+	}
+}
+
+// CreateReply makes a comment answering parent, one level deeper in its thread.
+func CreateReply(userId string, parent Comment, text string) *Comment {
+	reply := CreateNewComment(userId, parent.BlogId, text)
+	reply.ParentId = parent.ID.String()
+	reply.RootId = parent.RootId
+	if parent.Depth == 0 {
+		reply.RootId = parent.ID.String()
 	}
+	reply.Depth = parent.Depth + 1
+	return reply
 }
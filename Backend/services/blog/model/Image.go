@@ -1,49 +1,37 @@
 package model
 
-import (
-	"encoding/base64"
-	"encoding/json"
-)
+import "github.com/google/uuid"
 
+// Image references a picture kept in the blob store; only the key and
+// metadata are stored with the blog.
 type Image struct {
-	Data     []byte `json:"data" bson:"data, omitempty"`
-	MimeType string `json:"mimeType" bson:"mimeType, omitempty"`
-	Filename string `json:"filename" bson:"filename, omitempty"`
+	Id       uuid.UUID `json:"id" bson:"id"`
+	Key      string    `json:"-" bson:"key,omitempty"`
+	MimeType string    `json:"mimeType" bson:"mimeType,omitempty"`
+	Filename string    `json:"filename" bson:"filename,omitempty"`
+	Size     int64     `json:"size" bson:"size,omitempty"`
+	Checksum string    `json:"checksum" bson:"checksum,omitempty"`
+	Url      string    `json:"url,omitempty" bson:"-"`
+	// Variants holds the smaller renditions by size name ("thumb", "medium").
+	Variants map[string]ImageVariant `json:"-" bson:"variants,omitempty"`
 }
 
-// Custom JSON marshaling to convert byte array to base64 string
-func (img Image) MarshalJSON() ([]byte, error) {
-	type Alias Image
-	return json.Marshal(&struct {
-		Data string `json:"data,omitempty"`
-		*Alias
-	}{
-		Data:  base64.StdEncoding.EncodeToString(img.Data),
-		Alias: (*Alias)(&img),
-	})
+// ImageVariant is one stored rendition of an image.
+type ImageVariant struct {
+	Key      string `bson:"key"`
+	MimeType string `bson:"mimeType"`
+	Size     int64  `bson:"size"`
 }
 
-// Custom JSON unmarshaling to convert base64 string to byte array
-func (img *Image) UnmarshalJSON(data []byte) error {
-	type Alias Image
-	aux := &struct {
-		Data string `json:"data,omitempty"`
-		*Alias
-	}{
-		Alias: (*Alias)(img),
-	}
-	
-	if err := json.Unmarshal(data, &aux); err != nil {
-		return err
-	}
-	
-	if aux.Data != "" {
-		decoded, err := base64.StdEncoding.DecodeString(aux.Data)
-		if err != nil {
-			return err
-		}
-		img.Data = decoded
+func (image Image) IsEmpty() bool {
+	return image.Key == ""
+}
+
+// Variant returns the rendition stored for size, falling back to the
+// original when the image is too small to have one or predates variants.
+func (image Image) Variant(size string) ImageVariant {
+	if variant, ok := image.Variants[size]; ok {
+		return variant
 	}
-	
-	return nil
+	return ImageVariant{Key: image.Key, MimeType: image.MimeType, Size: image.Size}
 }
@@ -10,6 +10,14 @@ type Like struct {
 	DateOfCreation time.Time `json:"dateOfCreation" bson:"date_of_creation"`
 }
 
+// LikeSummary is a blog's like count together with whether the user asking
+// likes it.
+type LikeSummary struct {
+	BlogId    string `json:"blogId"`
+	LikeCount int64  `json:"likeCount"`
+	LikedByMe bool   `json:"likedByMe"`
+}
+
 func CreateNewLike(userId string, blogId string) *Like {
 	return &Like{
 		UserId:         userId,
@@ -9,14 +9,26 @@ import (
 	"github.com/google/uuid"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type BlogRepository struct {
 	Collection *mongo.Collection
 }
 
-func (repo *BlogRepository) GetAll() ([]model.Blog, error) {
-	cursor, err := repo.Collection.Find(context.TODO(), bson.M{})
+// visibleTo matches the blogs viewerId may see: every published or closed
+// blog, and the viewer's own drafts.
+func visibleTo(viewerId string) bson.M {
+	public := bson.M{"status": bson.M{"$in": bson.A{model.Published, model.Closed}}}
+	if viewerId == "" {
+		return public
+	}
+	return bson.M{"$or": bson.A{public, bson.M{"userId": viewerId}}}
+}
+
+// GetAll returns every blog viewerId may see.
+func (repo *BlogRepository) GetAll(viewerId string) ([]model.Blog, error) {
+	cursor, err := repo.Collection.Find(context.TODO(), visibleTo(viewerId))
 	if err != nil {
 		return nil, err
 	}
@@ -74,6 +86,7 @@ func (repo *BlogRepository) Update(id uuid.UUID, updatedBlog model.Blog) error {
 		"$set": bson.M{
 			"title":            updatedBlog.Title,
 			"description":      updatedBlog.Description,
+			"descriptionHtml":  updatedBlog.DescriptionHtml,
 			"images":           updatedBlog.Images,
 			"date_of_creation": updatedBlog.DateOfCreation, // optional
 		},
@@ -89,8 +102,9 @@ func (repo *BlogRepository) Update(id uuid.UUID, updatedBlog model.Blog) error {
 	return nil
 }
 
-func (repo *BlogRepository) GetAllByUser(userID string) ([]model.Blog, error) {
-	filter := bson.M{"userId": userID}
+// GetAllByUser returns the blogs of userID that viewerId may see.
+func (repo *BlogRepository) GetAllByUser(userID string, viewerId string) ([]model.Blog, error) {
+	filter := bson.M{"$and": bson.A{bson.M{"userId": userID}, visibleTo(viewerId)}}
 	cursor, err := repo.Collection.Find(context.TODO(), filter)
 	if err != nil {
 		return nil, err
@@ -112,3 +126,120 @@ func (repo *BlogRepository) GetAllByUser(userID string) ([]model.Blog, error) {
 
 	return blogs, nil
 }
+
+// GetFeed returns one page of the published and closed blogs of authorIds,
+// newest first, together with the total number of such blogs. Page is 1-based.
+func (repo *BlogRepository) GetFeed(authorIds []string, page, pageSize int) ([]model.Blog, int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"userId": bson.M{"$in": authorIds},
+		"status": bson.M{"$in": bson.A{model.Published, model.Closed}},
+	}
+	total, err := repo.Collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "publishedAt", Value: -1}, {Key: "_id", Value: 1}}).
+		SetSkip(int64((page - 1) * pageSize)).
+		SetLimit(int64(pageSize))
+	cursor, err := repo.Collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	blogs := []model.Blog{}
+	if err := cursor.All(ctx, &blogs); err != nil {
+		return nil, 0, err
+	}
+	return blogs, total, nil
+}
+
+// AddLikes changes the blog's like count by delta and returns the new count.
+func (repo *BlogRepository) AddLikes(id uuid.UUID, delta int64) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var blog model.Blog
+	opts := options.FindOneAndUpdate().
+		SetReturnDocument(options.After).
+		SetProjection(bson.M{"likeCount": 1})
+	err := repo.Collection.FindOneAndUpdate(ctx, bson.M{"_id": id}, bson.M{"$inc": bson.M{"likeCount": delta}}, opts).Decode(&blog)
+	return blog.LikeCount, err
+}
+
+// UpdateStatus moves the blog to status, recording when it was published and closed.
+func (repo *BlogRepository) UpdateStatus(id uuid.UUID, status model.BlogStatus, publishedAt time.Time, closedAt time.Time) error {
+	update := bson.M{
+		"$set": bson.M{
+			"status":      status,
+			"publishedAt": publishedAt,
+			"closedAt":    closedAt,
+		},
+	}
+	res, err := repo.Collection.UpdateOne(context.TODO(), bson.M{"_id": id}, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// BackfillStatus publishes the blogs stored before blogs had a status, since
+// they were all public, using their creation date as the publish date.
+func (repo *BlogRepository) BackfillStatus() (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	res, err := repo.Collection.UpdateMany(ctx,
+		bson.M{"status": bson.M{"$exists": false}},
+		mongo.Pipeline{
+			{{Key: "$set", Value: bson.M{
+				"status":      model.Published,
+				"publishedAt": "$date_of_creation",
+				"closedAt":    time.Time{},
+			}}},
+		},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.ModifiedCount, nil
+}
+
+// BackfillLikeCounts sets the like count of the blogs stored before blogs had
+// one from counts, keyed by blog id, and drops their unused embedded likes.
+func (repo *BlogRepository) BackfillLikeCounts(counts map[string]int64) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	missing := bson.M{"likeCount": bson.M{"$exists": false}}
+	cursor, err := repo.Collection.Find(ctx, missing, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return 0, err
+	}
+	var blogs []model.Blog
+	if err := cursor.All(ctx, &blogs); err != nil {
+		return 0, err
+	}
+
+	var backfilled int64
+	for _, blog := range blogs {
+		update := bson.M{
+			"$set":   bson.M{"likeCount": counts[blog.Id.String()]},
+			"$unset": bson.M{"likes": ""},
+		}
+		res, err := repo.Collection.UpdateOne(ctx, bson.M{"_id": blog.Id, "likeCount": bson.M{"$exists": false}}, update)
+		if err != nil {
+			return backfilled, err
+		}
+		backfilled += res.ModifiedCount
+	}
+	return backfilled, nil
+}
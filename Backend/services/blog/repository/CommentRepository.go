@@ -9,6 +9,7 @@ import (
 	"github.com/google/uuid"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type CommentRepository struct {
@@ -29,13 +30,14 @@ func (repo *CommentRepository) GetById(id uuid.UUID) (model.Comment, error) {
 	defer cancel()
 
 	var comment model.Comment
-	err := repo.Collection.FindOne(ctx, bson.M{"blog_id": id}).Decode(&comment)
+	err := repo.Collection.FindOne(ctx, bson.M{"_id": id}).Decode(&comment)
 	if err != nil {
 		return comment, err
 	}
 	return comment, nil
 }
 
+// UpdateComment changes the text of a comment that has not been deleted.
 func (repo *CommentRepository) UpdateComment(id uuid.UUID, comment *model.Comment) error {
 	update := bson.M{
 		"$set": bson.M{
@@ -44,7 +46,7 @@ func (repo *CommentRepository) UpdateComment(id uuid.UUID, comment *model.Commen
 		},
 	}
 
-	res, err := repo.Collection.UpdateOne(context.TODO(), bson.M{"_id": id}, update)
+	res, err := repo.Collection.UpdateOne(context.TODO(), bson.M{"_id": id, "deleted": bson.M{"$ne": true}}, update)
 	if err != nil {
 		return err
 	}
@@ -54,35 +56,151 @@ func (repo *CommentRepository) UpdateComment(id uuid.UUID, comment *model.Commen
 	return nil
 }
 
+// DeleteComment soft-deletes a comment: its text, author and likes are
+// dropped, but it stays in place so the replies to it are not orphaned.
 func (repo *CommentRepository) DeleteComment(id uuid.UUID) error {
+	update := bson.M{
+		"$set": bson.M{
+			"deleted":    true,
+			"text":       model.DeletedCommentText,
+			"user_id":    "",
+			"like_count": 0,
+			"liked_by":   bson.A{},
+		},
+	}
 
-	res, err := repo.Collection.DeleteOne(context.TODO(), bson.M{"_id": id})
+	res, err := repo.Collection.UpdateOne(context.TODO(), bson.M{"_id": id}, update)
 	if err != nil {
 		return err
 	}
-	if res.DeletedCount == 0 {
+	if res.MatchedCount == 0 {
 		return errors.New("comment not found")
 	}
 	return nil
 }
 
-func (repo *CommentRepository) GetCommentsByBlogId(blogId uuid.UUID) (*[]model.Comment, error) {
+// CommentSort orders the top-level comments of a blog, and the replies within
+// each thread.
+type CommentSort string
+
+const (
+	SortNewest CommentSort = "newest"
+	SortOldest CommentSort = "oldest"
+	SortTop    CommentSort = "top"
+)
+
+func (order CommentSort) Valid() bool {
+	return order == SortNewest || order == SortOldest || order == SortTop
+}
+
+// sort orders by the requested field with _id as a tie-breaker, so pages stay stable.
+func (order CommentSort) sort() bson.D {
+	switch order {
+	case SortOldest:
+		return bson.D{{Key: "date_of_creation", Value: 1}, {Key: "_id", Value: 1}}
+	case SortTop:
+		return bson.D{{Key: "like_count", Value: -1}, {Key: "date_of_creation", Value: -1}, {Key: "_id", Value: 1}}
+	}
+	return bson.D{{Key: "date_of_creation", Value: -1}, {Key: "_id", Value: 1}}
+}
+
+// GetTopLevel returns one page of the blog's top-level comments together with
+// the total number of them. Page is 1-based.
+func (repo *CommentRepository) GetTopLevel(blogId string, order CommentSort, page, pageSize int) ([]model.Comment, int64, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	filter := bson.M{"blog_id": blogId.String()}
-	//filter := bson.M{}
-	cursor, err := repo.Collection.Find(ctx, filter)
+	filter := bson.M{"blog_id": blogId, "depth": 0}
+	total, err := repo.Collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSort(order.sort()).
+		SetSkip(int64((page - 1) * pageSize)).
+		SetLimit(int64(pageSize))
+	cursor, err := repo.Collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	comments := []model.Comment{}
+	if err := cursor.All(ctx, &comments); err != nil {
+		return nil, 0, err
+	}
+	return comments, total, nil
+}
+
+// GetReplies returns every reply in the threads started by rootIds.
+func (repo *CommentRepository) GetReplies(rootIds []string) ([]model.Comment, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cursor, err := repo.Collection.Find(ctx, bson.M{"root_id": bson.M{"$in": rootIds}})
 	if err != nil {
 		return nil, err
 	}
 	defer cursor.Close(ctx)
 
-	var comments []model.Comment
-	println("Repository comments count", comments)
-	if err = cursor.All(ctx, &comments); err != nil {
+	replies := []model.Comment{}
+	if err := cursor.All(ctx, &replies); err != nil {
 		return nil, err
 	}
+	return replies, nil
+}
+
+// Like adds the user's like to a comment that has not been deleted, and
+// reports whether it was added; liking a comment twice changes nothing.
+func (repo *CommentRepository) Like(id uuid.UUID, userId string) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	res, err := repo.Collection.UpdateOne(ctx,
+		bson.M{"_id": id, "deleted": bson.M{"$ne": true}, "liked_by": bson.M{"$ne": userId}},
+		bson.M{"$addToSet": bson.M{"liked_by": userId}, "$inc": bson.M{"like_count": 1}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount == 1, nil
+}
 
-	return &comments, nil
+// Unlike takes back the user's like of a comment, if any, and reports whether
+// there was one.
+func (repo *CommentRepository) Unlike(id uuid.UUID, userId string) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	res, err := repo.Collection.UpdateOne(ctx,
+		bson.M{"_id": id, "liked_by": userId},
+		bson.M{"$pull": bson.M{"liked_by": userId}, "$inc": bson.M{"like_count": -1}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount == 1, nil
+}
+
+// BackfillThreads gives the comments stored before comments could be replied
+// to and liked an empty like list, so they sort and take likes like new ones.
+// Such comments are all top-level.
+func (repo *CommentRepository) BackfillThreads() (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	res, err := repo.Collection.UpdateMany(ctx,
+		bson.M{"like_count": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{
+			"depth":      0,
+			"deleted":    false,
+			"like_count": 0,
+			"liked_by":   bson.A{},
+		}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.ModifiedCount, nil
 }
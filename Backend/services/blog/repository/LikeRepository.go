@@ -2,26 +2,38 @@ package repository
 
 import (
 	"context"
-	"errors"
 	"time"
 
 	"blog.xws.com/model"
 	"github.com/google/uuid"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type LikeRepository struct {
 	Collection *mongo.Collection
 }
 
-func (repo *LikeRepository) CreateLike(like *model.Like) error {
-
+// CreateLike stores the like unless the user already likes the blog, and
+// reports whether it was added.
+func (repo *LikeRepository) CreateLike(like *model.Like) (bool, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
-	println("Creating like for user ID:", like.UserId, "and blog ID:", like.BlogId)
-	_, err := repo.Collection.InsertOne(ctx, like)
-	return err
+
+	res, err := repo.Collection.UpdateOne(ctx,
+		bson.M{"userId": like.UserId, "blogId": like.BlogId},
+		bson.M{"$setOnInsert": like},
+		options.Update().SetUpsert(true),
+	)
+	if mongo.IsDuplicateKeyError(err) {
+		// a concurrent request added the same like first
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return res.UpsertedCount == 1, nil
 }
 
 func (repo *LikeRepository) GetById(id uuid.UUID) (model.Like, error) {
@@ -36,18 +48,75 @@ func (repo *LikeRepository) GetById(id uuid.UUID) (model.Like, error) {
 	return like, nil
 }
 
-func (repo *LikeRepository) DeleteLike(userId string, blogId string) error {
-
+// DeleteLike removes the user's like of the blog, if any, and reports whether
+// there was one.
+func (repo *LikeRepository) DeleteLike(userId string, blogId string) (bool, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
+
 	res, err := repo.Collection.DeleteOne(ctx, bson.M{"userId": userId, "blogId": blogId})
 	if err != nil {
-		return err
+		return false, err
 	}
-	if res.DeletedCount == 0 {
-		return errors.New("like not found")
+	return res.DeletedCount == 1, nil
+}
+
+// Exists reports whether the user likes the blog.
+func (repo *LikeRepository) Exists(userId string, blogId string) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	count, err := repo.Collection.CountDocuments(ctx, bson.M{"userId": userId, "blogId": blogId}, options.Count().SetLimit(1))
+	return count > 0, err
+}
+
+// LikedBlogIds returns which of blogIds the user likes.
+func (repo *LikeRepository) LikedBlogIds(userId string, blogIds []string) (map[string]bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cursor, err := repo.Collection.Find(ctx, bson.M{"userId": userId, "blogId": bson.M{"$in": blogIds}})
+	if err != nil {
+		return nil, err
 	}
-	return nil
+	defer cursor.Close(ctx)
+
+	var likes []model.Like
+	if err := cursor.All(ctx, &likes); err != nil {
+		return nil, err
+	}
+	liked := make(map[string]bool, len(likes))
+	for _, like := range likes {
+		liked[like.BlogId] = true
+	}
+	return liked, nil
+}
+
+// CountByBlog returns the number of likes of every liked blog, keyed by blog id.
+func (repo *LikeRepository) CountByBlog() (map[string]int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	cursor, err := repo.Collection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$group", Value: bson.M{"_id": "$blogId", "count": bson.M{"$sum": 1}}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var groups []struct {
+		BlogId string `bson:"_id"`
+		Count  int64  `bson:"count"`
+	}
+	if err := cursor.All(ctx, &groups); err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int64, len(groups))
+	for _, group := range groups {
+		counts[group.BlogId] = group.Count
+	}
+	return counts, nil
 }
 
 func (repo *LikeRepository) GetLikesByBlogId(blogId string) (*[]model.Like, error) {
@@ -62,10 +131,9 @@ func (repo *LikeRepository) GetLikesByBlogId(blogId string) (*[]model.Like, erro
 	}
 	defer cursor.Close(ctx)
 
-	var likes []model.Like
+	likes := []model.Like{}
 	if err = cursor.All(ctx, &likes); err != nil {
 		return nil, err
 	}
-	println("Number of likes found:", "user ID:", likes[0].UserId, "length:", len(likes), "blog Id:", likes[0].BlogId)
 	return &likes, nil
 }
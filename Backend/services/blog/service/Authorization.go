@@ -0,0 +1,41 @@
+package service
+
+import (
+	"errors"
+	"strings"
+
+	"blog.xws.com/model"
+)
+
+var ErrForbidden = errors.New("only the author of the blog can change it")
+
+// Caller is the authenticated user a request is made on behalf of, as
+// forwarded by the gateway.
+type Caller struct {
+	Id    string
+	Roles []string
+}
+
+func (c Caller) HasRole(role string) bool {
+	for _, r := range c.Roles {
+		if strings.EqualFold(strings.TrimSpace(r), role) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c Caller) IsAdmin() bool {
+	return c.HasRole("admin")
+}
+
+// authorizeAuthor lets only the blog's author (or an admin) change it.
+func authorizeAuthor(blog model.Blog, caller Caller) error {
+	if caller.IsAdmin() {
+		return nil
+	}
+	if caller.Id == "" || caller.Id != blog.UserId {
+		return ErrForbidden
+	}
+	return nil
+}
@@ -1,46 +1,291 @@
 package service
 
 import (
+	"errors"
 	"fmt"
+	"time"
 
 	"blog.xws.com/model"
 	"blog.xws.com/repository"
 	"github.com/google/uuid"
 )
 
+var (
+	ErrBlogNotFound            = errors.New("blog not found")
+	ErrInvalidStatusTransition = errors.New("invalid blog status transition")
+	ErrBlogClosed              = errors.New("blog does not take new comments or likes")
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
 type BlogService struct {
 	BlogRepository *repository.BlogRepository
+	Images         *ImageService
+	LikeRepository *repository.LikeRepository
+	Follows        *FollowService
 }
 
-func (service *BlogService) GetAllBlogs() ([]model.Blog, error) {
-	return service.BlogRepository.GetAll()
+// GetAllBlogs returns the published and closed blogs, and the viewer's own drafts.
+func (service *BlogService) GetAllBlogs(viewerId string) ([]model.Blog, error) {
+	blogs, err := service.BlogRepository.GetAll(viewerId)
+	if err != nil {
+		return nil, err
+	}
+	prepareAll(blogs)
+	return blogs, service.markLiked(viewerId, blogs)
 }
 
-func (service *BlogService) GetById(id uuid.UUID) (*model.Blog, error) {
-	blog, err := service.BlogRepository.GetById(id)
+// GetFeed returns one page of the published and closed blogs of the users
+// viewerId follows, newest first; paging values out of range fall back to defaults.
+func (service *BlogService) GetFeed(viewerId string, page, pageSize int) (model.BlogPage, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = defaultPageSize
+	}
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+
+	following, err := service.Follows.Following(viewerId)
 	if err != nil {
-		return nil, fmt.Errorf(fmt.Sprintf("Blog with id %s not found", id))
+		return model.BlogPage{}, err
+	}
+	feed := model.BlogPage{Items: []model.Blog{}, Page: page, PageSize: pageSize}
+	if len(following) == 0 {
+		return feed, nil
 	}
 
+	feed.Items, feed.Total, err = service.BlogRepository.GetFeed(following, page, pageSize)
+	if err != nil {
+		return model.BlogPage{}, err
+	}
+	prepareAll(feed.Items)
+	return feed, service.markLiked(viewerId, feed.Items)
+}
+
+// GetById returns the blog if viewer may see it; other people's drafts are
+// reported as not found.
+func (service *BlogService) GetById(id uuid.UUID, viewer Caller) (*model.Blog, error) {
+	blog, err := service.BlogRepository.GetById(id)
+	if err != nil || (!blog.IsPublic() && authorizeAuthor(blog, viewer) != nil) {
+		return nil, fmt.Errorf("Blog with id %s not found", id)
+	}
+
+	prepare(&blog)
+	if blog.LikedByMe, err = service.likedBy(viewer.Id, blog); err != nil {
+		return nil, err
+	}
 	return &blog, nil
 }
 
+// GetImage returns the stored reference of one of the blog's images.
+func (service *BlogService) GetImage(id, imageId uuid.UUID, viewer Caller) (model.Image, error) {
+	blog, err := service.GetById(id, viewer)
+	if err != nil {
+		return model.Image{}, err
+	}
+	for _, image := range blog.Images {
+		if image.Id == imageId {
+			return image, nil
+		}
+	}
+	return model.Image{}, ErrImageNotFound
+}
+
+// Create stores a new blog together with the HTML rendering of its Markdown.
 func (service *BlogService) Create(blog *model.Blog) error {
-	err := service.BlogRepository.Create(model.BeforeCreateTour(blog.UserId, blog.Title, blog.Description, blog.Images))
+	newBlog := model.BeforeCreateTour(blog.UserId, blog.Title, blog.Description, blog.Images)
+	html, err := renderMarkdown(newBlog.Description, newBlog.Id, newBlog.Images)
 	if err != nil {
 		return err
 	}
+	newBlog.DescriptionHtml = html
+
+	if err := service.BlogRepository.Create(newBlog); err != nil {
+		return err
+	}
+	*blog = *newBlog
+	prepare(blog)
 	return nil
 }
 
-func (service *BlogService) Delete(id uuid.UUID) error {
-	return service.BlogRepository.Delete(id)
+// Delete removes the author's blog together with its images.
+func (service *BlogService) Delete(id uuid.UUID, caller Caller) error {
+	blog, err := service.BlogRepository.GetById(id)
+	if err != nil {
+		return errors.New("blog not found")
+	}
+	if err := authorizeAuthor(blog, caller); err != nil {
+		return err
+	}
+	if err := service.BlogRepository.Delete(id); err != nil {
+		return err
+	}
+	service.Images.Delete(blog.Images...)
+	return nil
 }
 
-func (service *BlogService) Update(id uuid.UUID, updatedBlog model.Blog) error {
-	return service.BlogRepository.Update(id, updatedBlog)
+// Update changes the blog's text and keeps only the existing images listed in
+// updatedBlog; images left out are deleted. The Markdown is rendered again
+// against the images that are kept.
+func (service *BlogService) Update(id uuid.UUID, updatedBlog model.Blog, caller Caller) error {
+	blog, err := service.BlogRepository.GetById(id)
+	if err != nil {
+		return err
+	}
+	if err := authorizeAuthor(blog, caller); err != nil {
+		return err
+	}
+
+	keep := map[uuid.UUID]bool{}
+	for _, image := range updatedBlog.Images {
+		keep[image.Id] = true
+	}
+	var kept, removed []model.Image
+	for _, image := range blog.Images {
+		if keep[image.Id] {
+			kept = append(kept, image)
+		} else {
+			removed = append(removed, image)
+		}
+	}
+
+	updatedBlog.Images = kept
+	if updatedBlog.Images == nil {
+		updatedBlog.Images = []model.Image{}
+	}
+	if updatedBlog.DescriptionHtml, err = renderMarkdown(updatedBlog.Description, id, updatedBlog.Images); err != nil {
+		return err
+	}
+	if err := service.BlogRepository.Update(id, updatedBlog); err != nil {
+		return err
+	}
+	service.Images.Delete(removed...)
+	return nil
+}
+
+// GetAllByUser returns the blogs of userId that viewerId may see.
+func (service *BlogService) GetAllByUser(userId string, viewerId string) ([]model.Blog, error) {
+	blogs, err := service.BlogRepository.GetAllByUser(userId, viewerId)
+	if err != nil {
+		return nil, err
+	}
+	prepareAll(blogs)
+	return blogs, service.markLiked(viewerId, blogs)
+}
+
+// Publish makes a draft visible to everyone.
+func (service *BlogService) Publish(id uuid.UUID, caller Caller) (model.Blog, error) {
+	return service.transition(id, caller, model.Draft, model.Published)
+}
+
+// Close stops new comments and likes on a published blog, which stays readable.
+func (service *BlogService) Close(id uuid.UUID, caller Caller) (model.Blog, error) {
+	return service.transition(id, caller, model.Published, model.Closed)
+}
+
+// Reopen takes comments and likes on a closed blog again, keeping its
+// original publish date.
+func (service *BlogService) Reopen(id uuid.UUID, caller Caller) (model.Blog, error) {
+	return service.transition(id, caller, model.Closed, model.Published)
+}
+
+// transition moves the author's blog from one status to the next.
+func (service *BlogService) transition(id uuid.UUID, caller Caller, from, to model.BlogStatus) (model.Blog, error) {
+	blog, err := service.BlogRepository.GetById(id)
+	if err != nil {
+		return model.Blog{}, fmt.Errorf("Blog with id %s not found", id)
+	}
+	if err := authorizeAuthor(blog, caller); err != nil {
+		return model.Blog{}, err
+	}
+	if blog.Status != from {
+		return model.Blog{}, ErrInvalidStatusTransition
+	}
+
+	now := time.Now().UTC()
+	switch to {
+	case model.Published:
+		if from == model.Draft {
+			blog.PublishedAt = now
+		}
+		blog.ClosedAt = time.Time{}
+	case model.Closed:
+		blog.ClosedAt = now
+	}
+	blog.Status = to
+	if err := service.BlogRepository.UpdateStatus(id, blog.Status, blog.PublishedAt, blog.ClosedAt); err != nil {
+		return model.Blog{}, err
+	}
+	prepare(&blog)
+	if blog.LikedByMe, err = service.likedBy(caller.Id, blog); err != nil {
+		return model.Blog{}, err
+	}
+	return blog, nil
 }
 
-func (service *BlogService) GetAllByUser(userId string) ([]model.Blog, error) {
-	return service.BlogRepository.GetAllByUser(userId)
+// checkOpen lets new comments and likes through only on published blogs, and
+// returns the blog they are for.
+func checkOpen(blogs *repository.BlogRepository, blogId string) (model.Blog, error) {
+	id, err := uuid.Parse(blogId)
+	if err != nil {
+		return model.Blog{}, fmt.Errorf("%w: %s", ErrBlogNotFound, blogId)
+	}
+	blog, err := blogs.GetById(id)
+	if err != nil {
+		return model.Blog{}, fmt.Errorf("%w: %s", ErrBlogNotFound, blogId)
+	}
+	if blog.Status != model.Published {
+		return model.Blog{}, ErrBlogClosed
+	}
+	return blog, nil
+}
+
+// markLiked sets LikedByMe on the blogs viewerId likes.
+func (service *BlogService) markLiked(viewerId string, blogs []model.Blog) error {
+	if viewerId == "" || len(blogs) == 0 {
+		return nil
+	}
+	ids := make([]string, len(blogs))
+	for i, blog := range blogs {
+		ids[i] = blog.Id.String()
+	}
+	liked, err := service.LikeRepository.LikedBlogIds(viewerId, ids)
+	if err != nil {
+		return err
+	}
+	for i := range blogs {
+		blogs[i].LikedByMe = liked[ids[i]]
+	}
+	return nil
+}
+
+// likedBy reports whether viewerId likes the blog.
+func (service *BlogService) likedBy(viewerId string, blog model.Blog) (bool, error) {
+	if viewerId == "" {
+		return false, nil
+	}
+	return service.LikeRepository.Exists(viewerId, blog.Id.String())
+}
+
+func prepareAll(blogs []model.Blog) {
+	for i := range blogs {
+		prepare(&blogs[i])
+	}
+}
+
+// prepare fills in the image URLs, and renders blogs written before
+// descriptions were stored as Markdown.
+func prepare(blog *model.Blog) {
+	for i := range blog.Images {
+		blog.Images[i].Url = imageUrl(blog.Id, blog.Images[i])
+	}
+	if blog.DescriptionHtml == "" && blog.Description != "" {
+		blog.DescriptionHtml, _ = renderMarkdown(blog.Description, blog.Id, blog.Images)
+	}
 }
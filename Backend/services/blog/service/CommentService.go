@@ -1,35 +1,111 @@
 package service
 
 import (
+	"errors"
 	"fmt"
+	"sort"
 
 	"blog.xws.com/model"
 	"blog.xws.com/repository"
 	"github.com/google/uuid"
 )
 
+// maxCommentDepth is how many levels of replies a thread can have below its
+// top-level comment.
+const maxCommentDepth = 3
+
+var (
+	ErrCommentNotFound     = errors.New("comment not found")
+	ErrCommentDeleted      = errors.New("comment has been deleted")
+	ErrCommentTooDeep      = fmt.Errorf("replies can only be nested %d levels deep", maxCommentDepth)
+	ErrNotCommentAuthor    = errors.New("only the author of the comment can change it")
+	ErrInvalidCommentQuery = errors.New("invalid comment query")
+)
+
 type CommentService struct {
 	CommentRepository *repository.CommentRepository
+	BlogRepository    *repository.BlogRepository
+	Follows           *FollowService
 }
 
-func (service *CommentService) CreateComment(comment *model.Comment) error {
-	err := service.CommentRepository.CreateComment(model.CreateNewComment(comment.UserId, comment.BlogId, comment.Text))
+// CreateComment adds a comment to a published blog, or a reply to one of its
+// comments when ParentId is set. Only the author and the users following them
+// may comment. The comment is userId's whatever comment.UserId says; the
+// stored comment is copied back into comment.
+func (service *CommentService) CreateComment(comment *model.Comment, userId string) error {
+	newComment := model.CreateNewComment(userId, comment.BlogId, comment.Text)
+	if comment.ParentId != "" {
+		parent, err := service.getComment(comment.ParentId)
+		if err != nil {
+			return err
+		}
+		if comment.BlogId != "" && comment.BlogId != parent.BlogId {
+			return fmt.Errorf("%w: %s is not a comment of blog %s", ErrCommentNotFound, comment.ParentId, comment.BlogId)
+		}
+		if parent.Deleted {
+			return ErrCommentDeleted
+		}
+		if parent.Depth >= maxCommentDepth {
+			return ErrCommentTooDeep
+		}
+		newComment = model.CreateReply(userId, parent, comment.Text)
+	}
+
+	blog, err := checkOpen(service.BlogRepository, newComment.BlogId)
+	if err != nil {
+		return err
+	}
+	if newComment.UserId != blog.UserId {
+		follows, err := service.Follows.Follows(newComment.UserId, blog.UserId)
+		if err != nil {
+			return err
+		}
+		if !follows {
+			return ErrNotFollowing
+		}
+	}
+	err = service.CommentRepository.CreateComment(newComment)
 	if err != nil {
 		return err
 	}
+	*comment = *newComment
+	comment.Replies = []model.Comment{}
 	return nil
 }
 
-func (service *CommentService) UpdateComment(comment *model.Comment) error {
-	err := service.CommentRepository.UpdateComment(comment.ID, comment)
+// UpdateComment changes the text of the caller's own comment.
+func (service *CommentService) UpdateComment(comment *model.Comment, caller Caller) error {
+	existing, err := service.CommentRepository.GetById(comment.ID)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrCommentNotFound, comment.ID)
+	}
+	if err := authorizeCommenter(existing, caller); err != nil {
+		return err
+	}
+	if existing.Deleted {
+		return ErrCommentDeleted
+	}
+	err = service.CommentRepository.UpdateComment(comment.ID, comment)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func (service *CommentService) Delete(id uuid.UUID) error {
-	err := service.CommentRepository.DeleteComment(id)
+// Delete soft-deletes the caller's own comment, leaving "[deleted]" in its
+// place in the thread. Deleting a comment again changes nothing.
+func (service *CommentService) Delete(id uuid.UUID, caller Caller) error {
+	existing, err := service.CommentRepository.GetById(id)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrCommentNotFound, id)
+	}
+	if existing.Deleted {
+		return nil
+	}
+	if err := authorizeCommenter(existing, caller); err != nil {
+		return err
+	}
+	err = service.CommentRepository.DeleteComment(id)
 
 	if err != nil {
 		return err
@@ -37,16 +113,166 @@ func (service *CommentService) Delete(id uuid.UUID) error {
 	return nil
 }
 
-func (service *CommentService) GetByBlogId(id uuid.UUID) (*[]model.Comment, error) {
-	return  service.CommentRepository.GetCommentsByBlogId(id)
+// GetByBlogId returns one page of the blog's top-level comments, each with
+// its replies nested below it, sorted newest first by default. Paging values
+// out of range fall back to defaults.
+func (service *CommentService) GetByBlogId(id uuid.UUID, viewerId string, order repository.CommentSort, page, pageSize int) (model.CommentPage, error) {
+	if order == "" {
+		order = repository.SortNewest
+	}
+	if !order.Valid() {
+		return model.CommentPage{}, fmt.Errorf("%w: unknown sort %q", ErrInvalidCommentQuery, order)
+	}
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = defaultPageSize
+	}
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+
+	roots, total, err := service.CommentRepository.GetTopLevel(id.String(), order, page, pageSize)
+	if err != nil {
+		return model.CommentPage{}, err
+	}
+	rootIds := make([]string, len(roots))
+	for i, root := range roots {
+		rootIds[i] = root.ID.String()
+	}
+	var replies []model.Comment
+	if len(roots) > 0 {
+		if replies, err = service.CommentRepository.GetReplies(rootIds); err != nil {
+			return model.CommentPage{}, err
+		}
+	}
+
+	return model.CommentPage{
+		Items:    buildThreads(roots, replies, order, viewerId),
+		Page:     page,
+		PageSize: pageSize,
+		Total:    total,
+	}, nil
+}
+
+// LikeComment likes a comment of a published blog. Liking a comment twice is
+// not an error.
+func (service *CommentService) LikeComment(id uuid.UUID, userId string) (model.Comment, error) {
+	comment, err := service.CommentRepository.GetById(id)
+	if err != nil {
+		return model.Comment{}, fmt.Errorf("%w: %s", ErrCommentNotFound, id)
+	}
+	if comment.Deleted {
+		return model.Comment{}, ErrCommentDeleted
+	}
+	if _, err := checkOpen(service.BlogRepository, comment.BlogId); err != nil {
+		return model.Comment{}, err
+	}
+	if _, err := service.CommentRepository.Like(id, userId); err != nil {
+		return model.Comment{}, err
+	}
+	return service.likeState(id, userId)
+}
+
+// UnlikeComment takes back the user's like of a comment, if any.
+func (service *CommentService) UnlikeComment(id uuid.UUID, userId string) (model.Comment, error) {
+	if _, err := service.CommentRepository.Unlike(id, userId); err != nil {
+		return model.Comment{}, err
+	}
+	return service.likeState(id, userId)
+}
+
+func (service *CommentService) likeState(id uuid.UUID, userId string) (model.Comment, error) {
+	comment, err := service.CommentRepository.GetById(id)
+	if err != nil {
+		return model.Comment{}, fmt.Errorf("%w: %s", ErrCommentNotFound, id)
+	}
+	comment.LikedByMe = contains(comment.LikedBy, userId)
+	comment.Replies = []model.Comment{}
+	return comment, nil
 }
 
 func (service *CommentService) GetById(id uuid.UUID) (*model.Comment, error) {
-	println("Service: GetById:", id.String())
 	comment, err := service.CommentRepository.GetById(id)
 	if err != nil {
-		return nil, fmt.Errorf(fmt.Sprintf("Comment with id %s not found", id))
+		return nil, fmt.Errorf("Comment with id %s not found", id)
 	}
 
 	return &comment, nil
 }
+
+func (service *CommentService) getComment(rawId string) (model.Comment, error) {
+	id, err := uuid.Parse(rawId)
+	if err != nil {
+		return model.Comment{}, fmt.Errorf("%w: %s", ErrCommentNotFound, rawId)
+	}
+	comment, err := service.CommentRepository.GetById(id)
+	if err != nil {
+		return model.Comment{}, fmt.Errorf("%w: %s", ErrCommentNotFound, rawId)
+	}
+	return comment, nil
+}
+
+// authorizeCommenter lets only the comment's author (or an admin) change it.
+func authorizeCommenter(comment model.Comment, caller Caller) error {
+	if caller.IsAdmin() {
+		return nil
+	}
+	if caller.Id == "" || caller.Id != comment.UserId {
+		return ErrNotCommentAuthor
+	}
+	return nil
+}
+
+// buildThreads nests the replies below the comments they answer, sorting every
+// level of each thread in the given order.
+func buildThreads(roots, replies []model.Comment, order repository.CommentSort, viewerId string) []model.Comment {
+	children := map[string][]model.Comment{}
+	for _, reply := range replies {
+		children[reply.ParentId] = append(children[reply.ParentId], reply)
+	}
+
+	var attach func(comment *model.Comment)
+	attach = func(comment *model.Comment) {
+		comment.LikedByMe = viewerId != "" && contains(comment.LikedBy, viewerId)
+		comment.Replies = children[comment.ID.String()]
+		if comment.Replies == nil {
+			comment.Replies = []model.Comment{}
+		}
+		sortComments(comment.Replies, order)
+		for i := range comment.Replies {
+			attach(&comment.Replies[i])
+		}
+	}
+	for i := range roots {
+		attach(&roots[i])
+	}
+	return roots
+}
+
+// sortComments orders comments the way CommentRepository sorts top-level ones.
+func sortComments(comments []model.Comment, order repository.CommentSort) {
+	sort.SliceStable(comments, func(a, b int) bool {
+		x, y := comments[a], comments[b]
+		if order == repository.SortTop && x.LikeCount != y.LikeCount {
+			return x.LikeCount > y.LikeCount
+		}
+		if !x.DateOfCreation.Equal(y.DateOfCreation) {
+			if order == repository.SortOldest {
+				return x.DateOfCreation.Before(y.DateOfCreation)
+			}
+			return x.DateOfCreation.After(y.DateOfCreation)
+		}
+		return x.ID.String() < y.ID.String()
+	})
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
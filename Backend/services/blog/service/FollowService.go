@@ -0,0 +1,46 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/zopuu/soa-team-20/Backend/services/followers_service/proto/followerspb"
+)
+
+var (
+	ErrNotFollowing         = errors.New("only followers of the author can comment on the blog")
+	ErrFollowersUnavailable = errors.New("followers service is unavailable")
+)
+
+// FollowService asks the followers service who a user follows.
+type FollowService struct {
+	Client followerspb.FollowersServiceClient
+}
+
+// Following returns the ids of the users userId follows.
+func (service *FollowService) Following(userId string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	resp, err := service.Client.GetFollowing(ctx, &followerspb.UserRequest{UserId: userId})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrFollowersUnavailable, err)
+	}
+	return resp.UserIds, nil
+}
+
+// Follows reports whether followerId follows authorId.
+func (service *FollowService) Follows(followerId, authorId string) (bool, error) {
+	following, err := service.Following(followerId)
+	if err != nil {
+		return false, err
+	}
+	for _, id := range following {
+		if id == authorId {
+			return true, nil
+		}
+	}
+	return false, nil
+}
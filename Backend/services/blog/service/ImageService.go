@@ -0,0 +1,110 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log"
+	"path"
+
+	"blog.xws.com/model"
+	"github.com/google/uuid"
+	"github.com/zopuu/soa-team-20/Backend/pkg/blobstore"
+	"github.com/zopuu/soa-team-20/Backend/pkg/imaging"
+)
+
+var (
+	ErrImageNotFound        = errors.New("image not found")
+	ErrUnsupportedImageType = errors.New("Invalid image type. Only JPEG, PNG, GIF, and WebP are allowed")
+	ErrImageTooLarge        = errors.New("image dimensions are too large")
+)
+
+// ImageService keeps uploaded images in the blob store.
+type ImageService struct {
+	Store blobstore.Store
+}
+
+// Save stores the image read from r under a new key below prefix, together
+// with its thumbnail and medium variants. The type is detected from the
+// content, and metadata such as EXIF location tags is removed.
+func (service *ImageService) Save(ctx context.Context, prefix, filename string, r io.Reader) (model.Image, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return model.Image{}, err
+	}
+	processed, err := imaging.Process(data)
+	if errors.Is(err, imaging.ErrUnsupported) {
+		return model.Image{}, ErrUnsupportedImageType
+	}
+	if errors.Is(err, imaging.ErrTooLarge) {
+		return model.Image{}, ErrImageTooLarge
+	}
+	if err != nil {
+		return model.Image{}, err
+	}
+
+	key := path.Join(prefix, uuid.New().String())
+	object, err := service.put(ctx, key, processed.Original)
+	if err != nil {
+		return model.Image{}, err
+	}
+	image := model.Image{
+		Id:       uuid.New(),
+		Key:      object.Key,
+		MimeType: processed.Original.ContentType,
+		Filename: filename,
+		Size:     object.Size,
+		Checksum: object.Checksum,
+	}
+
+	for size, encoded := range processed.Variants {
+		variant, err := service.put(ctx, key+"-"+string(size), encoded)
+		if err != nil {
+			service.Delete(image)
+			return model.Image{}, err
+		}
+		if image.Variants == nil {
+			image.Variants = map[string]model.ImageVariant{}
+		}
+		image.Variants[string(size)] = model.ImageVariant{Key: variant.Key, MimeType: encoded.ContentType, Size: variant.Size}
+	}
+	return image, nil
+}
+
+func (service *ImageService) put(ctx context.Context, key string, encoded imaging.Encoded) (blobstore.Object, error) {
+	return service.Store.Put(ctx, key, bytes.NewReader(encoded.Data), int64(len(encoded.Data)), encoded.ContentType)
+}
+
+// Open returns the content of the requested rendition; the caller must close it.
+func (service *ImageService) Open(ctx context.Context, image model.Image, size imaging.Size) (io.ReadSeekCloser, model.ImageVariant, error) {
+	if image.IsEmpty() {
+		return nil, model.ImageVariant{}, ErrImageNotFound
+	}
+	variant := image.Variant(string(size))
+	content, _, err := service.Store.Get(ctx, variant.Key)
+	if errors.Is(err, blobstore.ErrNotFound) {
+		return nil, model.ImageVariant{}, ErrImageNotFound
+	}
+	return content, variant, err
+}
+
+// Delete removes images that are no longer referenced, variants included.
+// Failures are only logged: an orphaned blob is harmless, while failing the
+// request that already changed the document would not be.
+func (service *ImageService) Delete(images ...model.Image) {
+	for _, image := range images {
+		if image.IsEmpty() {
+			continue
+		}
+		keys := []string{image.Key}
+		for _, variant := range image.Variants {
+			keys = append(keys, variant.Key)
+		}
+		for _, key := range keys {
+			if err := service.Store.Delete(context.Background(), key); err != nil {
+				log.Printf("failed to delete image %s: %v", key, err)
+			}
+		}
+	}
+}
@@ -1,37 +1,80 @@
 package service
 
 import (
-	//"fmt"
-	"log"
-	"time"
+	"fmt"
 
 	"blog.xws.com/model"
 	"blog.xws.com/repository"
+	"github.com/google/uuid"
 )
 
 type LikeService struct {
 	LikeRepository *repository.LikeRepository
+	BlogRepository *repository.BlogRepository
 }
 
-func (service *LikeService) CreateLike(like *model.Like) error {
-	like.DateOfCreation = time.Now()
+// CreateLike likes a published blog. Liking a blog twice is not an error; the
+// second like is ignored and reported as not created.
+func (service *LikeService) CreateLike(like *model.Like) (model.LikeSummary, bool, error) {
+	blog, err := checkOpen(service.BlogRepository, like.BlogId)
+	if err != nil {
+		return model.LikeSummary{}, false, err
+	}
 
-	err := service.LikeRepository.CreateLike(model.CreateNewLike(like.UserId, like.BlogId))
-	log.Println("Creating like:", err)
-	log.Println("Creating like object:", like)
+	created, err := service.LikeRepository.CreateLike(model.CreateNewLike(like.UserId, like.BlogId))
 	if err != nil {
-		return err
+		return model.LikeSummary{}, false, err
 	}
-	return nil
+	count, err := service.changeLikeCount(blog.Id, created, 1)
+	if err != nil {
+		return model.LikeSummary{}, false, err
+	}
+	return model.LikeSummary{BlogId: like.BlogId, LikeCount: count, LikedByMe: true}, created, nil
 }
 
-func (service *LikeService) Delete(userId string, blogId string) error {
-	err := service.LikeRepository.DeleteLike(userId, blogId)
+// Delete takes back the user's like, if any. Likes can be taken back on closed
+// blogs too.
+func (service *LikeService) Delete(userId string, blogId string) (model.LikeSummary, error) {
+	id, err := uuid.Parse(blogId)
+	if err != nil {
+		return model.LikeSummary{}, fmt.Errorf("%w: %s", ErrBlogNotFound, blogId)
+	}
+	blog, err := service.BlogRepository.GetById(id)
+	if err != nil {
+		return model.LikeSummary{}, fmt.Errorf("%w: %s", ErrBlogNotFound, blogId)
+	}
+
+	deleted, err := service.LikeRepository.DeleteLike(userId, blogId)
+	if err != nil {
+		return model.LikeSummary{}, err
+	}
+	count, err := service.changeLikeCount(blog.Id, deleted, -1)
+	if err != nil {
+		return model.LikeSummary{}, err
+	}
+	return model.LikeSummary{BlogId: blogId, LikeCount: count, LikedByMe: false}, nil
+}
+
+// changeLikeCount adjusts the blog's stored like count by delta when the like
+// was actually added or removed, and otherwise returns the count as stored.
+// Only the request whose insert or delete succeeded moves the count, so
+// concurrent likes of the same blog each count once.
+func (service *LikeService) changeLikeCount(blogId uuid.UUID, changed bool, delta int64) (int64, error) {
+	if changed {
+		return service.BlogRepository.AddLikes(blogId, delta)
+	}
+	blog, err := service.BlogRepository.GetById(blogId)
+	return blog.LikeCount, err
+}
 
+// BackfillLikeCounts counts the stored likes of the blogs that have no like
+// count yet.
+func (service *LikeService) BackfillLikeCounts() (int64, error) {
+	counts, err := service.LikeRepository.CountByBlog()
 	if err != nil {
-		return err
+		return 0, err
 	}
-	return nil
+	return service.BlogRepository.BackfillLikeCounts(counts)
 }
 
 func (service *LikeService) GetByBlogId(id string) (*[]model.Like, error) {
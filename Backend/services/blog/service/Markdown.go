@@ -0,0 +1,104 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"blog.xws.com/model"
+	"github.com/google/uuid"
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// imageRefPrefix marks a Markdown image that points at one of the blog's own
+// uploads, by image id or file name: ![A view](image:view.jpg).
+const imageRefPrefix = "image:"
+
+var imagesKey = parser.NewContextKey()
+
+// markdown renders GitHub flavoured Markdown. Raw HTML in the source is not
+// rendered, and the output is sanitized again in renderMarkdown.
+var markdown = goldmark.New(
+	goldmark.WithExtensions(extension.GFM),
+	goldmark.WithParserOptions(parser.WithASTTransformers(util.Prioritized(imageResolver{}, 100))),
+)
+
+// sanitizer allows the usual user generated content. Links only keep http,
+// https and mailto URLs and get rel="nofollow noreferrer"; external ones open
+// in a new tab.
+var sanitizer = bluemonday.UGCPolicy().
+	RequireNoReferrerOnLinks(true).
+	AddTargetBlankToFullyQualifiedLinks(true)
+
+// blogImages is what imageResolver needs to know about the blog being rendered.
+type blogImages struct {
+	blogId uuid.UUID
+	images []model.Image
+}
+
+// url returns where the referenced image is served, or false when ref does
+// not name one of the blog's images.
+func (b blogImages) url(ref string) (string, bool) {
+	ref = strings.TrimPrefix(ref, imageRefPrefix)
+	for _, image := range b.images {
+		if image.Id.String() == ref || (image.Filename != "" && image.Filename == ref) {
+			return imageUrl(b.blogId, image), true
+		}
+	}
+	return "", false
+}
+
+// imageResolver points image references at the blog's own images. Any other
+// image, external ones included, is replaced by its alt text, so rendered
+// blogs never load pictures from elsewhere.
+type imageResolver struct{}
+
+func (imageResolver) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	images, _ := pc.Get(imagesKey).(blogImages)
+
+	var unresolved []*ast.Image
+	ast.Walk(doc, func(node ast.Node, entering bool) (ast.WalkStatus, error) {
+		image, ok := node.(*ast.Image)
+		if !ok || !entering {
+			return ast.WalkContinue, nil
+		}
+		if url, ok := images.url(string(image.Destination)); ok {
+			image.Destination = []byte(url)
+		} else {
+			unresolved = append(unresolved, image)
+		}
+		return ast.WalkSkipChildren, nil
+	})
+
+	for _, image := range unresolved {
+		parent := image.Parent()
+		for child := image.FirstChild(); child != nil; {
+			next := child.NextSibling()
+			parent.InsertBefore(parent, image, child)
+			child = next
+		}
+		parent.RemoveChild(parent, image)
+	}
+}
+
+// renderMarkdown turns a blog's Markdown source into sanitized HTML.
+func renderMarkdown(source string, blogId uuid.UUID, images []model.Image) (string, error) {
+	pc := parser.NewContext()
+	pc.Set(imagesKey, blogImages{blogId: blogId, images: images})
+
+	var out bytes.Buffer
+	if err := markdown.Convert([]byte(source), &out, parser.WithContext(pc)); err != nil {
+		return "", err
+	}
+	return sanitizer.SanitizeReader(&out).String(), nil
+}
+
+func imageUrl(blogId uuid.UUID, image model.Image) string {
+	return fmt.Sprintf("/blogs/%s/images/%s", blogId, image.Id)
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"blog.xws.com/model"
+	"github.com/google/uuid"
+)
+
+func render(t *testing.T, source string, blogId uuid.UUID, images ...model.Image) string {
+	t.Helper()
+	html, err := renderMarkdown(source, blogId, images)
+	if err != nil {
+		t.Fatalf("renderMarkdown(%q): %v", source, err)
+	}
+	return html
+}
+
+func TestRenderMarkdownDropsRawHTML(t *testing.T) {
+	html := render(t, "Hello <script>alert('x')</script> **world**\n\n<div onclick=\"steal()\">block</div>\n\n<img src=x onerror=alert(1)>", uuid.New())
+
+	// the text between inline tags stays, escaped, but no markup does
+	for _, unwanted := range []string{"<script", "</script", "onclick", "onerror", "<div", "<img"} {
+		if strings.Contains(html, unwanted) {
+			t.Errorf("output keeps %q: %s", unwanted, html)
+		}
+	}
+	if !strings.Contains(html, "<strong>world</strong>") {
+		t.Errorf("Markdown around the raw HTML is lost: %s", html)
+	}
+}
+
+func TestRenderMarkdownRemovesJavascriptLinks(t *testing.T) {
+	html := render(t, "[click](javascript:alert(1)) and [safe](https://example.com)", uuid.New())
+
+	if strings.Contains(strings.ToLower(html), "javascript:") {
+		t.Errorf("javascript: link kept: %s", html)
+	}
+	if !strings.Contains(html, "click") {
+		t.Errorf("link text is lost: %s", html)
+	}
+	if !strings.Contains(html, `href="https://example.com"`) {
+		t.Errorf("safe link is lost: %s", html)
+	}
+}
+
+func TestRenderMarkdownReplacesExternalImagesWithAltText(t *testing.T) {
+	html := render(t, "Look: ![a tracking pixel](http://example.com/pixel.png)", uuid.New())
+
+	if strings.Contains(html, "<img") || strings.Contains(html, "example.com") {
+		t.Errorf("external image kept: %s", html)
+	}
+	if !strings.Contains(html, "a tracking pixel") {
+		t.Errorf("alt text is lost: %s", html)
+	}
+}
+
+func TestRenderMarkdownResolvesBlogImages(t *testing.T) {
+	blogId := uuid.New()
+	view := model.Image{Id: uuid.New(), Filename: "view.jpg"}
+	other := model.Image{Id: uuid.New(), Filename: "other.png"}
+
+	tests := []struct {
+		name   string
+		source string
+		want   model.Image
+	}{
+		{"by file name", "![A view](image:view.jpg)", view},
+		{"by id", "![Other](image:" + other.Id.String() + ")", other},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			html := render(t, tt.source, blogId, view, other)
+			src := `src="/blogs/` + blogId.String() + `/images/` + tt.want.Id.String() + `"`
+			if !strings.Contains(html, "<img") || !strings.Contains(html, src) {
+				t.Errorf("want an image with %s, got %s", src, html)
+			}
+		})
+	}
+
+	html := render(t, "![Missing](image:missing.jpg)", blogId, view)
+	if strings.Contains(html, "<img") || !strings.Contains(html, "Missing") {
+		t.Errorf("unknown image reference should fall back to its alt text: %s", html)
+	}
+}
@@ -0,0 +1,26 @@
+package grpc
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc/metadata"
+	"tour.xws.com/service"
+)
+
+// callerFromContext reads the identity the gateway forwards as x-user-id /
+// x-roles metadata.
+func callerFromContext(ctx context.Context) service.Caller {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return service.Caller{}
+	}
+	var c service.Caller
+	if ids := md.Get("x-user-id"); len(ids) > 0 {
+		c.Id = ids[0]
+	}
+	if roles := md.Get("x-roles"); len(roles) > 0 && roles[0] != "" {
+		c.Roles = strings.Split(roles[0], ",")
+	}
+	return c
+}
@@ -0,0 +1,142 @@
+package grpc
+
+import (
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"tour.xws.com/model"
+	tourpb "tour.xws.com/proto"
+)
+
+// The proto enums reserve 0 for UNSPECIFIED, so every model value is shifted
+// by one on the wire.
+
+func toProtoStatus(s model.TourStatus) tourpb.TourStatus {
+	return tourpb.TourStatus(s + 1)
+}
+
+func toProtoDifficulty(d model.TourDifficulty) tourpb.TourDifficulty {
+	return tourpb.TourDifficulty(d + 1)
+}
+
+func toProtoTransportType(t model.TransportType) tourpb.TransportType {
+	return tourpb.TransportType(t + 1)
+}
+
+// fromProtoStatus returns nil for UNSPECIFIED so it can be used as an optional filter.
+func fromProtoStatus(s tourpb.TourStatus) *model.TourStatus {
+	if s == tourpb.TourStatus_TOUR_STATUS_UNSPECIFIED {
+		return nil
+	}
+	status := model.TourStatus(s - 1)
+	return &status
+}
+
+// fromProtoDifficulty returns nil for UNSPECIFIED so it can be used as an optional filter.
+func fromProtoDifficulty(d tourpb.TourDifficulty) *model.TourDifficulty {
+	if d == tourpb.TourDifficulty_TOUR_DIFFICULTY_UNSPECIFIED {
+		return nil
+	}
+	difficulty := model.TourDifficulty(d - 1)
+	return &difficulty
+}
+
+// fromProtoTransportType returns nil for UNSPECIFIED so it can be used as an optional filter.
+func fromProtoTransportType(t tourpb.TransportType) *model.TransportType {
+	if t == tourpb.TransportType_TRANSPORT_TYPE_UNSPECIFIED {
+		return nil
+	}
+	transportType := model.TransportType(t - 1)
+	return &transportType
+}
+
+func difficultyOrDefault(d tourpb.TourDifficulty) model.TourDifficulty {
+	if difficulty := fromProtoDifficulty(d); difficulty != nil {
+		return *difficulty
+	}
+	return model.Beginner
+}
+
+func toProtoDurations(durations []model.TransportDuration) []*tourpb.TransportDuration {
+	out := make([]*tourpb.TransportDuration, 0, len(durations))
+	for _, duration := range durations {
+		out = append(out, &tourpb.TransportDuration{
+			TransportType: toProtoTransportType(duration.TransportType),
+			Minutes:       duration.Minutes,
+		})
+	}
+	return out
+}
+
+// fromProtoDurations keeps UNSPECIFIED transport types as an invalid value,
+// so the service rejects them instead of silently picking one.
+func fromProtoDurations(durations []*tourpb.TransportDuration) []model.TransportDuration {
+	out := make([]model.TransportDuration, 0, len(durations))
+	for _, duration := range durations {
+		out = append(out, model.TransportDuration{
+			TransportType: model.TransportType(duration.GetTransportType() - 1),
+			Minutes:       duration.GetMinutes(),
+		})
+	}
+	return out
+}
+
+func toProtoTour(tour model.Tour) *tourpb.Tour {
+	pbTour := &tourpb.Tour{
+		Id:          tour.Id.String(),
+		AuthorId:    tour.AuthorId,
+		Title:       tour.Title,
+		Description: tour.Description,
+		Difficulty:  toProtoDifficulty(tour.Difficulty),
+		Tags:        tour.Tags,
+		Status:      toProtoStatus(tour.Status),
+		Price:       tour.Price,
+		Distance:    tour.Distance,
+		Durations:   toProtoDurations(tour.Durations),
+		RatingStats: toProtoRatingStats(tour.RatingStats),
+		Revision:    int32(tour.Revision),
+	}
+	if tour.RevisionOf != nil {
+		pbTour.RevisionOf = tour.RevisionOf.String()
+	}
+
+	// Set timestamps if they're not zero
+	if !tour.PublishedAt.IsZero() {
+		pbTour.PublishedAt = timestamppb.New(tour.PublishedAt)
+	}
+	if !tour.ArchivedAt.IsZero() {
+		pbTour.ArchivedAt = timestamppb.New(tour.ArchivedAt)
+	}
+	return pbTour
+}
+
+func toProtoRatingStats(stats model.RatingStats) *tourpb.RatingStats {
+	histogram := make([]int32, 0, len(stats.Histogram))
+	for _, count := range stats.Histogram {
+		histogram = append(histogram, int32(count))
+	}
+	return &tourpb.RatingStats{Average: stats.Average, Count: int32(stats.Count), Histogram: histogram}
+}
+
+func toProtoTours(tours []model.Tour) []*tourpb.Tour {
+	out := make([]*tourpb.Tour, 0, len(tours))
+	for _, tour := range tours {
+		out = append(out, toProtoTour(tour))
+	}
+	return out
+}
+
+func toProtoKeyPoint(keyPoint model.KeyPoint) *tourpb.KeyPoint {
+	pbKeyPoint := &tourpb.KeyPoint{
+		Id:          keyPoint.Id.String(),
+		TourId:      keyPoint.TourId.String(),
+		Latitude:    keyPoint.Coordinates.Latitude,
+		Longitude:   keyPoint.Coordinates.Longitude,
+		Title:       keyPoint.Title,
+		Description: keyPoint.Description,
+		Order:       int32(keyPoint.Order),
+	}
+	if !keyPoint.CreatedAt.IsZero() {
+		pbKeyPoint.CreatedAt = timestamppb.New(keyPoint.CreatedAt)
+	}
+	pbKeyPoint.ImageUrl = keyPoint.ImageUrl()
+	return pbKeyPoint
+}
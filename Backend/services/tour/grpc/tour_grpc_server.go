@@ -1,94 +1,92 @@
 package grpc
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"log"
 
 	"github.com/google/uuid"
-	"google.golang.org/protobuf/types/known/timestamppb"
+	"go.mongodb.org/mongo-driver/mongo"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"tour.xws.com/model"
 	tourpb "tour.xws.com/proto"
+	"tour.xws.com/repository"
 	"tour.xws.com/service"
 )
 
 type TourGRPCServer struct {
 	tourpb.UnimplementedTourServiceServer
-	tourService *service.TourService
+	tourService     *service.TourService
+	keyPointService *service.KeyPointService
+	images          *service.ImageService
 }
 
-func NewTourGRPCServer(tourService *service.TourService) *TourGRPCServer {
+func NewTourGRPCServer(tourService *service.TourService, keyPointService *service.KeyPointService, images *service.ImageService) *TourGRPCServer {
 	return &TourGRPCServer{
-		tourService: tourService,
+		tourService:     tourService,
+		keyPointService: keyPointService,
+		images:          images,
 	}
 }
 
+// saveImage stores an uploaded key point image; a nil image stores nothing.
+func (s *TourGRPCServer) saveImage(ctx context.Context, image *tourpb.Image) (model.Image, error) {
+	if image == nil || len(image.Data) == 0 {
+		return model.Image{}, nil
+	}
+	stored, err := s.images.Save(ctx, "keypoints", image.Filename, bytes.NewReader(image.Data))
+	if errors.Is(err, service.ErrUnsupportedImageType) || errors.Is(err, service.ErrImageTooLarge) {
+		return model.Image{}, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err != nil {
+		log.Printf("gRPC saveImage ERROR err=%v", err)
+		return model.Image{}, status.Error(codes.Internal, "failed to store image")
+	}
+	return stored, nil
+}
+
+// CreateTour stores a draft tour written by the caller; req.AuthorId is ignored.
 func (s *TourGRPCServer) CreateTour(ctx context.Context, req *tourpb.CreateTourRequest) (*tourpb.CreateTourResponse, error) {
+	caller := callerFromContext(ctx)
+	if caller.Id == "" {
+		return nil, status.Error(codes.Unauthenticated, "a signed-in author is required")
+	}
+
 	// Convert protobuf message to domain model
 	tour := model.BeforeCreateTour(
-		req.AuthorId,
+		caller.Id,
 		req.Title,
 		req.Description,
 		req.Tags,
-		model.TourDifficulty(req.Difficulty),
+		difficultyOrDefault(req.Difficulty),
 	)
-	
-	// Set transport type from request
-	tour.TransportType = model.TransportType(req.TransportType)
+	tour.Durations = fromProtoDurations(req.Durations)
 
 	// Call the service
 	if err := s.tourService.Create(tour); err != nil {
 		log.Printf("gRPC CreateTour ERROR err=%v", err)
-		return nil, err
-	}
-
-	// Convert domain model back to protobuf
-	pbTour := &tourpb.Tour{
-		Id:          tour.Id.String(),
-		AuthorId:    tour.AuthorId,
-		Title:       tour.Title,
-		Description: tour.Description,
-		Difficulty:  tourpb.TourDifficulty(tour.Difficulty),
-		Tags:        tour.Tags,
-		Status:      tourpb.TourStatus(tour.Status),
-		Price:       tour.Price,
-		Distance:    tour.Distance,
-		Duration:    tour.Duration,
-		TransportType: tourpb.TransportType(tour.TransportType),
-	}
-
-	// Set timestamps if they're not zero
-	if !tour.PublishedAt.IsZero() {
-		pbTour.PublishedAt = timestamppb.New(tour.PublishedAt)
-	}
-	if !tour.ArchivedAt.IsZero() {
-		pbTour.ArchivedAt = timestamppb.New(tour.ArchivedAt)
+		return nil, toStatusError(err)
 	}
 
 	log.Printf("gRPC CreateTour OK id=%s", tour.Id)
 	return &tourpb.CreateTourResponse{
-		Tour:    pbTour,
+		Tour:    toProtoTour(*tour),
 		Message: "Tour created successfully",
 	}, nil
 }
 
 func (s *TourGRPCServer) DeleteTour(ctx context.Context, req *tourpb.DeleteTourRequest) (*tourpb.DeleteTourResponse, error) {
-	
-	// Parse UUID
 	id, err := uuid.Parse(req.Id)
 	if err != nil {
 		log.Printf("gRPC DeleteTour BAD_ID raw=%s", req.Id)
-		return &tourpb.DeleteTourResponse{
-			Message: "Invalid tour ID",
-			Success: false,
-		}, nil
+		return nil, status.Error(codes.InvalidArgument, "invalid tour id")
 	}
-	// Call the service
-	if err = s.tourService.Delete(id); err != nil {
+	if err = s.tourService.Delete(id, callerFromContext(ctx)); err != nil {
 		log.Printf("gRPC DeleteTour ERROR id=%s err=%v", id, err)
-		return &tourpb.DeleteTourResponse{
-			Message: err.Error(),
-			Success: false,
-		}, nil
+		return nil, toStatusError(err)
 	}
 
 	log.Printf("gRPC DeleteTour OK id=%s", id)
@@ -96,4 +94,226 @@ func (s *TourGRPCServer) DeleteTour(ctx context.Context, req *tourpb.DeleteTourR
 		Message: "Tour deleted successfully",
 		Success: true,
 	}, nil
-}
\ No newline at end of file
+}
+
+func (s *TourGRPCServer) GetTour(ctx context.Context, req *tourpb.GetTourRequest) (*tourpb.GetTourResponse, error) {
+	id, err := uuid.Parse(req.Id)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid tour id")
+	}
+
+	tour, err := s.tourService.GetById(id, callerFromContext(ctx))
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return &tourpb.GetTourResponse{Tour: toProtoTour(tour)}, nil
+}
+
+func (s *TourGRPCServer) ListTours(ctx context.Context, req *tourpb.ListToursRequest) (*tourpb.ListToursResponse, error) {
+	page, err := s.tourService.Find(repository.TourQuery{
+		Status:        fromProtoStatus(req.Status),
+		Difficulty:    fromProtoDifficulty(req.Difficulty),
+		TransportType: fromProtoTransportType(req.TransportType),
+		Tag:           req.Tag,
+		AuthorId:      req.AuthorId,
+		MinPrice:      req.MinPrice,
+		MaxPrice:      req.MaxPrice,
+		MaxDuration:   req.MaxDuration,
+		SortBy:        repository.TourSortField(req.SortBy),
+		Descending:    req.Descending,
+		Page:          int(req.Page),
+		PageSize:      int(req.PageSize),
+	}, callerFromContext(ctx))
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return toListToursResponse(page), nil
+}
+
+func (s *TourGRPCServer) ListToursByAuthor(ctx context.Context, req *tourpb.ListToursByAuthorRequest) (*tourpb.ListToursResponse, error) {
+	if req.AuthorId == "" {
+		return nil, status.Error(codes.InvalidArgument, "author id is required")
+	}
+
+	page, err := s.tourService.Find(repository.TourQuery{
+		AuthorId: req.AuthorId,
+		Page:     int(req.Page),
+		PageSize: int(req.PageSize),
+	}, callerFromContext(ctx))
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return toListToursResponse(page), nil
+}
+
+func (s *TourGRPCServer) UpdateTour(ctx context.Context, req *tourpb.UpdateTourRequest) (*tourpb.UpdateTourResponse, error) {
+	id, err := uuid.Parse(req.Id)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid tour id")
+	}
+
+	updatedTour := model.Tour{
+		Title:       req.Title,
+		Description: req.Description,
+		Difficulty:  difficultyOrDefault(req.Difficulty),
+		Tags:        req.Tags,
+		Price:       req.Price,
+		Durations:   fromProtoDurations(req.Durations),
+	}
+	// edits to a published tour come back as its draft revision
+	tour, err := s.tourService.Update(id, updatedTour, callerFromContext(ctx))
+	if err != nil {
+		log.Printf("gRPC UpdateTour ERROR id=%s err=%v", id, err)
+		return nil, toStatusError(err)
+	}
+	log.Printf("gRPC UpdateTour OK id=%s", id)
+	return &tourpb.UpdateTourResponse{Tour: toProtoTour(tour), Message: "Tour updated successfully"}, nil
+}
+
+func (s *TourGRPCServer) ListKeyPoints(ctx context.Context, req *tourpb.ListKeyPointsRequest) (*tourpb.ListKeyPointsResponse, error) {
+	tourId, err := uuid.Parse(req.TourId)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid tour id")
+	}
+
+	keyPoints, err := s.keyPointService.GetAllByTour(tourId, callerFromContext(ctx).Id)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	out := make([]*tourpb.KeyPoint, 0, len(keyPoints))
+	for _, keyPoint := range keyPoints {
+		out = append(out, toProtoKeyPoint(keyPoint))
+	}
+	return &tourpb.ListKeyPointsResponse{KeyPoints: out}, nil
+}
+
+func (s *TourGRPCServer) CreateKeyPoint(ctx context.Context, req *tourpb.CreateKeyPointRequest) (*tourpb.KeyPointResponse, error) {
+	tourId, err := uuid.Parse(req.TourId)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid tour id")
+	}
+	image, err := s.saveImage(ctx, req.Image)
+	if err != nil {
+		return nil, err
+	}
+
+	keyPoint := model.BeforeCreateKeyPoint(
+		tourId,
+		model.Coordinates{Latitude: req.Latitude, Longitude: req.Longitude},
+		req.Title,
+		req.Description,
+		image,
+	)
+	if err := s.keyPointService.Create(keyPoint, callerFromContext(ctx)); err != nil {
+		s.images.Delete(image)
+		log.Printf("gRPC CreateKeyPoint ERROR tour=%s err=%v", tourId, err)
+		return nil, toStatusError(err)
+	}
+
+	log.Printf("gRPC CreateKeyPoint OK id=%s", keyPoint.Id)
+	return &tourpb.KeyPointResponse{KeyPoint: toProtoKeyPoint(*keyPoint), Message: "KeyPoint created successfully"}, nil
+}
+
+func (s *TourGRPCServer) UpdateKeyPoint(ctx context.Context, req *tourpb.UpdateKeyPointRequest) (*tourpb.KeyPointResponse, error) {
+	id, err := uuid.Parse(req.Id)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid key point id")
+	}
+
+	// without a new image the stored one is kept
+	image, err := s.saveImage(ctx, req.Image)
+	if err != nil {
+		return nil, err
+	}
+
+	updatedKeyPoint := model.KeyPoint{
+		Coordinates: model.Coordinates{Latitude: req.Latitude, Longitude: req.Longitude},
+		Title:       req.Title,
+		Description: req.Description,
+		Image:       image,
+	}
+	if err := s.keyPointService.Update(id, updatedKeyPoint, callerFromContext(ctx)); err != nil {
+		s.images.Delete(image)
+		log.Printf("gRPC UpdateKeyPoint ERROR id=%s err=%v", id, err)
+		return nil, toStatusError(err)
+	}
+
+	keyPoint, err := s.keyPointService.GetById(id)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	log.Printf("gRPC UpdateKeyPoint OK id=%s", id)
+	return &tourpb.KeyPointResponse{KeyPoint: toProtoKeyPoint(*keyPoint), Message: "KeyPoint updated successfully"}, nil
+}
+
+func (s *TourGRPCServer) DeleteKeyPoint(ctx context.Context, req *tourpb.DeleteKeyPointRequest) (*tourpb.DeleteKeyPointResponse, error) {
+	id, err := uuid.Parse(req.Id)
+	if err != nil {
+		log.Printf("gRPC DeleteKeyPoint BAD_ID raw=%s", req.Id)
+		return nil, status.Error(codes.InvalidArgument, "invalid key point id")
+	}
+
+	if err := s.keyPointService.Delete(id, callerFromContext(ctx)); err != nil {
+		log.Printf("gRPC DeleteKeyPoint ERROR id=%s err=%v", id, err)
+		return nil, toStatusError(err)
+	}
+
+	log.Printf("gRPC DeleteKeyPoint OK id=%s", id)
+	return &tourpb.DeleteKeyPointResponse{
+		Message: "KeyPoint deleted successfully",
+		Success: true,
+	}, nil
+}
+
+func toListToursResponse(page model.TourPage) *tourpb.ListToursResponse {
+	return &tourpb.ListToursResponse{
+		Tours:    toProtoTours(page.Items),
+		Page:     int32(page.Page),
+		PageSize: int32(page.PageSize),
+		Total:    page.Total,
+	}
+}
+
+// toStatusError maps service errors onto gRPC status codes.
+func toStatusError(err error) error {
+	var invalid *service.ValidationError
+	switch {
+	case errors.As(err, &invalid):
+		return invalidArgument(invalid)
+	case errors.Is(err, service.ErrInvalidTourQuery):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, service.ErrForbidden):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, service.ErrTourNotPurchased),
+		errors.Is(err, service.ErrUnpublishedListing):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, service.ErrInvalidStatusTransition),
+		errors.Is(err, service.ErrTourNotPublishable),
+		errors.Is(err, service.ErrTourNotEditable),
+		errors.Is(err, service.ErrTourInUse):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, repository.ErrTourNotFound),
+		errors.Is(err, repository.ErrKeyPointNotFound),
+		errors.Is(err, repository.ErrTourRevisionNotFound),
+		errors.Is(err, mongo.ErrNoDocuments):
+		return status.Error(codes.NotFound, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
+// invalidArgument carries the rejected fields as BadRequest details, so the
+// gateway can report them the same way the REST endpoints do.
+func invalidArgument(invalid *service.ValidationError) error {
+	st := status.New(codes.InvalidArgument, service.ErrValidation.Error())
+	violations := make([]*errdetails.BadRequest_FieldViolation, 0, len(invalid.Fields))
+	for _, field := range invalid.Fields {
+		violations = append(violations, &errdetails.BadRequest_FieldViolation{Field: field.Field, Description: field.Message})
+	}
+	detailed, err := st.WithDetails(&errdetails.BadRequest{FieldViolations: violations})
+	if err != nil {
+		return status.Error(codes.InvalidArgument, invalid.Error())
+	}
+	return detailed.Err()
+}
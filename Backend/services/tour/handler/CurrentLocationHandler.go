@@ -2,6 +2,7 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 
 	"github.com/gorilla/mux"
@@ -10,37 +11,70 @@ import (
 )
 
 type CurrentLocationHandler struct {
-	Svc *service.CurrentLocationService
+	Svc          *service.CurrentLocationService
+	ExecutionSvc *service.TourExecutionService
 }
 
+// Get returns a user's simulated position to that user or an admin.
 func (h *CurrentLocationHandler) Get(w http.ResponseWriter, r *http.Request) {
 	userId := mux.Vars(r)["userId"]
+	viewer := caller(r)
+	if viewer.Id == "" {
+		writeError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if viewer.Id != userId && !viewer.IsAdmin() {
+		writeError(w, "Forbidden", http.StatusForbidden)
+		return
+	}
 	loc, err := h.Svc.Get(userId)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError); return
+		writeError(w, err.Error(), http.StatusInternalServerError)
+		return
 	}
 	if loc == nil {
-		w.WriteHeader(http.StatusNoContent); return
+		w.WriteHeader(http.StatusNoContent)
+		return
 	}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(loc)
 }
 
 type setReq struct {
-	UserId     string           `json:"userId"`
 	Coordinates model.Coordinates `json:"coordinates"`
 }
 
+// Set moves the caller's simulated position; a userId in the body is ignored.
 func (h *CurrentLocationHandler) Set(w http.ResponseWriter, r *http.Request) {
+	userId := callerId(r)
+	if userId == "" {
+		writeError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
 	var req setReq
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "bad request", http.StatusBadRequest); return
+		writeError(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	err := h.Svc.Set(userId, req.Coordinates)
+	if errors.Is(err, service.ErrValidation) {
+		writeValidationError(w, err)
+		return
 	}
-	if req.UserId == "" {
-		http.Error(w, "userId required", http.StatusBadRequest); return
+	if err != nil {
+		writeError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	// Moving the tourist may complete the next key point of their active tour
+	execution, err := h.ExecutionSvc.CheckProximity(userId, req.Coordinates)
+	if err != nil {
+		writeError(w, err.Error(), http.StatusInternalServerError)
+		return
 	}
-	if err := h.Svc.Set(req.UserId, req.Coordinates); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError); return
+	if execution == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
 	}
-	w.WriteHeader(http.StatusNoContent)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(execution)
 }
@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"tour.xws.com/repository"
+	"tour.xws.com/service"
+)
+
+// errorBody is the JSON body of every error response. Fields is only set for
+// rejected input and lists each invalid field with the reason.
+type errorBody struct {
+	Status int                  `json:"status"`
+	Error  string               `json:"error"`
+	Fields []service.FieldError `json:"fields,omitempty"`
+}
+
+// writeError is the JSON counterpart of http.Error.
+func writeError(writer http.ResponseWriter, message string, status int) {
+	writeErrorBody(writer, errorBody{Status: status, Error: message})
+}
+
+// writeValidationError reports err as 400 Bad Request, listing the invalid
+// fields when err is a *service.ValidationError.
+func writeValidationError(writer http.ResponseWriter, err error) {
+	body := errorBody{Status: http.StatusBadRequest, Error: err.Error()}
+	var invalid *service.ValidationError
+	if errors.As(err, &invalid) {
+		body.Error = service.ErrValidation.Error()
+		body.Fields = invalid.Fields
+	}
+	writeErrorBody(writer, body)
+}
+
+func writeErrorBody(writer http.ResponseWriter, body errorBody) {
+	writer.Header().Del("Content-Length")
+	writer.Header().Set("Content-Type", "application/json")
+	writer.Header().Set("X-Content-Type-Options", "nosniff")
+	writer.WriteHeader(body.Status)
+	json.NewEncoder(writer).Encode(body)
+}
+
+// isNotFound reports whether err means that the tour, revision or execution
+// asked for doesn't exist; anything else is a server error.
+func isNotFound(err error) bool {
+	return errors.Is(err, repository.ErrTourNotFound) ||
+		errors.Is(err, repository.ErrTourRevisionNotFound) ||
+		errors.Is(err, repository.ErrTourExecutionNotFound) ||
+		errors.Is(err, mongo.ErrNoDocuments)
+}
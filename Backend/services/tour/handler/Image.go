@@ -0,0 +1,168 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/zopuu/soa-team-20/Backend/pkg/imaging"
+	"tour.xws.com/model"
+	"tour.xws.com/service"
+)
+
+// uploadForm is a multipart form whose files were streamed straight into the
+// blob store. Uploads are deleted again on Close unless Keep was called.
+type uploadForm struct {
+	Values url.Values
+	Files  map[string][]model.Image
+	images *service.ImageService
+	kept   bool
+}
+
+// readUploadForm streams the request's multipart body, storing the parts named
+// in fileFields as images below prefix and collecting the other fields.
+func readUploadForm(writer http.ResponseWriter, req *http.Request, images *service.ImageService, prefix string, maxBytes int64, fileFields ...string) (*uploadForm, error) {
+	req.Body = http.MaxBytesReader(writer, req.Body, maxBytes)
+	reader, err := req.MultipartReader()
+	if err != nil {
+		return nil, err
+	}
+
+	form := &uploadForm{Values: url.Values{}, Files: map[string][]model.Image{}, images: images}
+	isFile := map[string]bool{}
+	for _, field := range fileFields {
+		isFile[field] = true
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return form, nil
+		}
+		if err != nil {
+			form.Close()
+			return nil, err
+		}
+
+		name := part.FormName()
+		if !isFile[name] || part.FileName() == "" {
+			value, err := io.ReadAll(io.LimitReader(part, 1<<20))
+			part.Close()
+			if err != nil {
+				form.Close()
+				return nil, err
+			}
+			form.Values.Add(name, string(value))
+			continue
+		}
+
+		// the declared Content-Type is ignored; Save detects the type from the content
+		image, err := images.Save(req.Context(), prefix, part.FileName(), part)
+		part.Close()
+		if err != nil {
+			form.Close()
+			return nil, err
+		}
+		form.Files[name] = append(form.Files[name], image)
+	}
+}
+
+// Image returns the first image uploaded under field.
+func (form *uploadForm) Image(field string) (model.Image, bool) {
+	if files := form.Files[field]; len(files) > 0 {
+		return files[0], true
+	}
+	return model.Image{}, false
+}
+
+// Keep marks the uploads as referenced by a stored document.
+func (form *uploadForm) Keep() {
+	form.kept = true
+}
+
+func (form *uploadForm) Close() {
+	if form.kept {
+		return
+	}
+	for _, files := range form.Files {
+		form.images.Delete(files...)
+	}
+}
+
+// writeUploadError reports a multipart body readUploadForm rejected.
+func writeUploadError(writer http.ResponseWriter, err error) {
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.Is(err, service.ErrUnsupportedImageType), errors.Is(err, service.ErrImageTooLarge):
+		writeError(writer, err.Error(), http.StatusBadRequest)
+	case errors.As(err, &tooLarge):
+		writeError(writer, "Upload too large", http.StatusRequestEntityTooLarge)
+	case errors.Is(err, http.ErrNotMultipart), errors.Is(err, http.ErrMissingBoundary):
+		writeError(writer, "Failed to parse multipart form", http.StatusBadRequest)
+	default:
+		log.Printf("upload failed: %v", err)
+		writeError(writer, "Failed to store uploaded image", http.StatusInternalServerError)
+	}
+}
+
+// writeImage serves the stored image in the rendition asked for by the size
+// query parameter (thumb, medium or original). Responses carry a content-hash
+// ETag, answer If-None-Match with 304 without touching the blob store and
+// support range requests. When the request names the current image version
+// (the v parameter of the image URL) the response may be cached for good.
+func writeImage(writer http.ResponseWriter, req *http.Request, images *service.ImageService, image model.Image) {
+	size, err := imaging.ParseSize(req.URL.Query().Get("size"))
+	if err != nil {
+		writeError(writer, "size must be thumb, medium or original", http.StatusBadRequest)
+		return
+	}
+
+	variant := image.Variant(string(size))
+	header := writer.Header()
+	header.Set("ETag", variant.ETag())
+	if version := req.URL.Query().Get("v"); version != "" && version == image.Version() {
+		header.Set("Cache-Control", "private, max-age=31536000, immutable")
+	} else {
+		header.Set("Cache-Control", "private, no-cache")
+	}
+	if etagMatches(req.Header.Get("If-None-Match"), variant.ETag()) {
+		writer.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	content, variant, err := images.Open(req.Context(), image, size)
+	if errors.Is(err, service.ErrImageNotFound) {
+		writeError(writer, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		writeError(writer, "Failed to read image", http.StatusInternalServerError)
+		return
+	}
+	defer content.Close()
+
+	header.Set("Content-Type", variant.MimeType)
+	// ServeContent handles Range and If-Range and sets Content-Length
+	http.ServeContent(writer, req, "", time.Time{}, content)
+}
+
+// etagMatches reports whether an If-None-Match header matches etag, using the
+// weak comparison RFC 9110 prescribes for it.
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	if strings.TrimSpace(ifNoneMatch) == "*" {
+		return true
+	}
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
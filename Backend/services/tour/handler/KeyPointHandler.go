@@ -2,9 +2,10 @@ package handler
 
 import (
 	"encoding/json"
-	"io"
+	"errors"
 	"log"
 	"net/http"
+	"net/url"
 	"strconv"
 
 	"github.com/google/uuid"
@@ -15,13 +16,16 @@ import (
 
 type KeyPointHandler struct {
 	KeyPointService *service.KeyPointService
+	Images          *service.ImageService
 }
 
+const maxKeyPointUpload = 10 << 20
+
 func (handler *KeyPointHandler) GetAll(writer http.ResponseWriter, req *http.Request) {
 	keyPoints, err := handler.KeyPointService.GetAllKeyPoints()
 	writer.Header().Set("Content-Type", "application/json")
 	if err != nil {
-		http.Error(writer, err.Error(), http.StatusInternalServerError)
+		writeError(writer, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
@@ -34,15 +38,15 @@ func (handler *KeyPointHandler) GetAllByTour(writer http.ResponseWriter, req *ht
 
 	tourId, err := uuid.Parse(tourIdStr)
 	if err != nil {
-		http.Error(writer, "Invalid UUID", http.StatusBadRequest)
+		writeError(writer, "Invalid UUID", http.StatusBadRequest)
 		return
 	}
 
 	writer.Header().Set("Content-Type", "application/json")
 
-	keyPoints, err := handler.KeyPointService.GetAllByTour(tourId)
+	keyPoints, err := handler.KeyPointService.GetAllByTour(tourId, callerId(req))
 	if err != nil {
-		http.Error(writer, err.Error(), http.StatusInternalServerError)
+		writeError(writer, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
@@ -55,15 +59,15 @@ func (handler *KeyPointHandler) GetAllByTourSortedByCreatedAt(writer http.Respon
 
 	tourId, err := uuid.Parse(tourIdStr)
 	if err != nil {
-		http.Error(writer, "Invalid UUID", http.StatusBadRequest)
+		writeError(writer, "Invalid UUID", http.StatusBadRequest)
 		return
 	}
 
 	writer.Header().Set("Content-Type", "application/json")
 
-	keyPoints, err := handler.KeyPointService.GetAllByTourSortedByCreatedAt(tourId)
+	keyPoints, err := handler.KeyPointService.GetAllByTourSortedByCreatedAt(tourId, callerId(req))
 	if err != nil {
-		http.Error(writer, err.Error(), http.StatusInternalServerError)
+		writeError(writer, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
@@ -71,81 +75,115 @@ func (handler *KeyPointHandler) GetAllByTourSortedByCreatedAt(writer http.Respon
 	json.NewEncoder(writer).Encode(keyPoints)
 }
 
-func (handler *KeyPointHandler) Create(writer http.ResponseWriter, req *http.Request) {
-	// Parse multipart form data (10MB limit)
-	err := req.ParseMultipartForm(10 << 20)
+func (handler *KeyPointHandler) GetById(writer http.ResponseWriter, req *http.Request) {
+	id, err := uuid.Parse(mux.Vars(req)["id"])
 	if err != nil {
-		http.Error(writer, "Failed to parse multipart form", http.StatusBadRequest)
+		writeError(writer, "Invalid UUID", http.StatusBadRequest)
 		return
 	}
 
-	// Extract form fields
-	tourIdStr := req.FormValue("tourId")
-	title := req.FormValue("title")
-	description := req.FormValue("description")
-	latitudeStr := req.FormValue("latitude")
-	longitudeStr := req.FormValue("longitude")
-
-	// Parse required fields
-	tourId, err := uuid.Parse(tourIdStr)
-	if err != nil {
-		http.Error(writer, "Invalid tour ID", http.StatusBadRequest)
+	keyPoint, err := handler.KeyPointService.GetDetail(id, callerId(req))
+	if errors.Is(err, service.ErrTourNotPurchased) {
+		writeError(writer, err.Error(), http.StatusForbidden)
 		return
 	}
-
-	latitude, err := strconv.ParseFloat(latitudeStr, 64)
 	if err != nil {
-		http.Error(writer, "Invalid latitude", http.StatusBadRequest)
+		writeError(writer, err.Error(), http.StatusNotFound)
 		return
 	}
 
-	longitude, err := strconv.ParseFloat(longitudeStr, 64)
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	json.NewEncoder(writer).Encode(keyPoint)
+}
+
+func (handler *KeyPointHandler) Reorder(writer http.ResponseWriter, req *http.Request) {
+	tourIdStr := mux.Vars(req)["tourId"]
+
+	tourId, err := uuid.Parse(tourIdStr)
 	if err != nil {
-		http.Error(writer, "Invalid longitude", http.StatusBadRequest)
+		writeError(writer, "Invalid UUID", http.StatusBadRequest)
 		return
 	}
 
-	coordinates := model.Coordinates{
-		Latitude:  latitude,
-		Longitude: longitude,
+	var input struct {
+		KeyPointIds []uuid.UUID `json:"keyPointIds"`
+	}
+	if err := json.NewDecoder(req.Body).Decode(&input); err != nil {
+		writeError(writer, err.Error(), http.StatusBadRequest)
+		return
 	}
 
-	// Handle image upload
-	var image model.Image
-	file, header, err := req.FormFile("image")
-	if err == nil {
-		defer file.Close()
-		
-		// Read image data
-		imageData, err := io.ReadAll(file)
-		if err != nil {
-			http.Error(writer, "Failed to read image file", http.StatusInternalServerError)
+	keyPoints, err := handler.KeyPointService.Reorder(tourId, input.KeyPointIds, caller(req))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidKeyPointOrder) {
+			writeError(writer, err.Error(), http.StatusBadRequest)
 			return
 		}
-
-		// Validate file type
-		contentType := header.Header.Get("Content-Type")
-		if !isValidImageType(contentType) {
-			http.Error(writer, "Invalid image type. Only JPEG, PNG, GIF, and WebP are allowed", http.StatusBadRequest)
+		if errors.Is(err, service.ErrForbidden) {
+			writeError(writer, err.Error(), http.StatusForbidden)
 			return
 		}
-
-		image = model.Image{
-			Data:     imageData,
-			MimeType: contentType,
-			Filename: header.Filename,
+		if errors.Is(err, service.ErrTourNotEditable) {
+			writeError(writer, err.Error(), http.StatusConflict)
+			return
 		}
+		writeError(writer, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	json.NewEncoder(writer).Encode(keyPoints)
+}
+
+func (handler *KeyPointHandler) Create(writer http.ResponseWriter, req *http.Request) {
+	// Stream the multipart form, storing the image as it arrives (10MB limit)
+	form, err := readUploadForm(writer, req, handler.Images, "keypoints", maxKeyPointUpload, "image")
+	if err != nil {
+		writeUploadError(writer, err)
+		return
+	}
+	defer form.Close()
+
+	// Parse form fields, reporting every malformed one at once
+	var invalid []service.FieldError
+	tourId, err := uuid.Parse(form.Values.Get("tourId"))
+	if err != nil {
+		invalid = append(invalid, service.FieldError{Field: "tourId", Message: "must be a valid UUID"})
+	}
+	coordinates := parseFormCoordinates(form.Values, &invalid)
+	if len(invalid) > 0 {
+		writeValidationError(writer, &service.ValidationError{Fields: invalid})
+		return
 	}
+	title := form.Values.Get("title")
+	description := form.Values.Get("description")
+
 	// If no image uploaded, image will be empty struct
+	image, _ := form.Image("image")
 
 	// Create keypoint
 	keyPoint := model.BeforeCreateKeyPoint(tourId, coordinates, title, description, image)
 	
-	err = handler.KeyPointService.Create(keyPoint)
+	err = handler.KeyPointService.Create(keyPoint, caller(req))
+	if errors.Is(err, service.ErrValidation) {
+		writeValidationError(writer, err)
+		return
+	}
+	if errors.Is(err, service.ErrForbidden) {
+		writeError(writer, err.Error(), http.StatusForbidden)
+		return
+	}
+	if errors.Is(err, service.ErrTourNotEditable) {
+		writeError(writer, err.Error(), http.StatusConflict)
+		return
+	}
 	if err != nil {
-		http.Error(writer, "Error while creating keypoint", http.StatusInternalServerError)
+		writeError(writer, "Error while creating keypoint", http.StatusInternalServerError)
 		return
 	}
+	form.Keep()
 
 	writer.Header().Set("Content-Type", "application/json")
 	writer.WriteHeader(http.StatusCreated)
@@ -153,37 +191,27 @@ func (handler *KeyPointHandler) Create(writer http.ResponseWriter, req *http.Req
 	log.Println("KeyPoint successfully created")
 }
 
-// Helper function to validate image types
-func isValidImageType(contentType string) bool {
-	validTypes := []string{
-		"image/jpeg",
-		"image/jpg",
-		"image/png", 
-		"image/gif",
-		"image/webp",
-	}
-	
-	for _, validType := range validTypes {
-		if contentType == validType {
-			return true
-		}
-	}
-	return false
-}
-
 func (handler *KeyPointHandler) Delete(writer http.ResponseWriter, req *http.Request) {
 	idStr := mux.Vars(req)["id"]
 	log.Printf("Deleting keyPoint with ID: %s", idStr)
 
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		http.Error(writer, "Invalid UUID", http.StatusBadRequest)
+		writeError(writer, "Invalid UUID", http.StatusBadRequest)
 		return
 	}
 
-	err = handler.KeyPointService.Delete(id)
+	err = handler.KeyPointService.Delete(id, caller(req))
+	if errors.Is(err, service.ErrForbidden) {
+		writeError(writer, err.Error(), http.StatusForbidden)
+		return
+	}
+	if errors.Is(err, service.ErrTourNotEditable) {
+		writeError(writer, err.Error(), http.StatusConflict)
+		return
+	}
 	if err != nil {
-		http.Error(writer, err.Error(), http.StatusNotFound)
+		writeError(writer, err.Error(), http.StatusNotFound)
 		return
 	}
 
@@ -197,7 +225,7 @@ func (handler *KeyPointHandler) Update(writer http.ResponseWriter, req *http.Req
 
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		http.Error(writer, "Invalid UUID", http.StatusBadRequest)
+		writeError(writer, "Invalid UUID", http.StatusBadRequest)
 		return
 	}
 
@@ -205,6 +233,7 @@ func (handler *KeyPointHandler) Update(writer http.ResponseWriter, req *http.Req
 	contentType := req.Header.Get("Content-Type")
 	
 	var updatedKeyPoint model.KeyPoint
+	var uploads *uploadForm
 	
 	if contentType == "application/json" {
 		// Handle JSON request (existing behavior)
@@ -212,11 +241,10 @@ func (handler *KeyPointHandler) Update(writer http.ResponseWriter, req *http.Req
 			Title       string            `json:"title"`
 			Description string            `json:"description"`
 			Coordinates model.Coordinates `json:"coordinates"`
-			Image       model.Image       `json:"image"`
 		}
 
 		if err := json.NewDecoder(req.Body).Decode(&input); err != nil {
-			http.Error(writer, err.Error(), http.StatusBadRequest)
+			writeError(writer, err.Error(), http.StatusBadRequest)
 			return
 		}
 
@@ -224,73 +252,28 @@ func (handler *KeyPointHandler) Update(writer http.ResponseWriter, req *http.Req
 			Coordinates: input.Coordinates,
 			Title:       input.Title,
 			Description: input.Description,
-			Image:       input.Image,
 		}
 	} else {
-		// Handle multipart form data (new behavior for file uploads)
-		err := req.ParseMultipartForm(10 << 20)
-		if err != nil {
-			http.Error(writer, "Failed to parse multipart form", http.StatusBadRequest)
-			return
-		}
-
-		// Extract form fields
-		title := req.FormValue("title")
-		description := req.FormValue("description")
-		latitudeStr := req.FormValue("latitude")
-		longitudeStr := req.FormValue("longitude")
-
-		// Parse coordinates
-		latitude, err := strconv.ParseFloat(latitudeStr, 64)
+		// Handle multipart form data, streaming a new image into the store
+		form, err := readUploadForm(writer, req, handler.Images, "keypoints", maxKeyPointUpload, "image")
 		if err != nil {
-			http.Error(writer, "Invalid latitude", http.StatusBadRequest)
+			writeUploadError(writer, err)
 			return
 		}
+		defer form.Close()
 
-		longitude, err := strconv.ParseFloat(longitudeStr, 64)
-		if err != nil {
-			http.Error(writer, "Invalid longitude", http.StatusBadRequest)
+		var invalid []service.FieldError
+		coordinates := parseFormCoordinates(form.Values, &invalid)
+		if len(invalid) > 0 {
+			writeValidationError(writer, &service.ValidationError{Fields: invalid})
 			return
 		}
+		title := form.Values.Get("title")
+		description := form.Values.Get("description")
 
-		coordinates := model.Coordinates{
-			Latitude:  latitude,
-			Longitude: longitude,
-		}
-
-		// Get existing keypoint to preserve image if no new image uploaded
-		existingKeyPoint, err := handler.KeyPointService.GetById(id)
-		if err != nil {
-			http.Error(writer, "KeyPoint not found", http.StatusNotFound)
-			return
-		}
-
-		// Handle image upload
-		var image model.Image = existingKeyPoint.Image // Default to existing image
-		file, header, err := req.FormFile("image")
-		if err == nil {
-			defer file.Close()
-			
-			// Read image data
-			imageData, err := io.ReadAll(file)
-			if err != nil {
-				http.Error(writer, "Failed to read image file", http.StatusInternalServerError)
-				return
-			}
-
-			// Validate image type
-			mimeType := header.Header.Get("Content-Type")
-			if mimeType != "image/jpeg" && mimeType != "image/png" && mimeType != "image/gif" && mimeType != "image/webp" {
-				http.Error(writer, "Invalid image format. Only JPEG, PNG, GIF, and WebP are supported", http.StatusBadRequest)
-				return
-			}
-
-			image = model.Image{
-				Data:     imageData,
-				MimeType: mimeType,
-				Filename: header.Filename,
-			}
-		}
+		// Without a new upload the existing image is kept
+		image, _ := form.Image("image")
+		uploads = form
 
 		updatedKeyPoint = model.KeyPoint{
 			Coordinates: coordinates,
@@ -300,42 +283,69 @@ func (handler *KeyPointHandler) Update(writer http.ResponseWriter, req *http.Req
 		}
 	}
 
-	err = handler.KeyPointService.Update(id, updatedKeyPoint)
+	err = handler.KeyPointService.Update(id, updatedKeyPoint, caller(req))
+	if errors.Is(err, service.ErrValidation) {
+		writeValidationError(writer, err)
+		return
+	}
+	if errors.Is(err, service.ErrForbidden) {
+		writeError(writer, err.Error(), http.StatusForbidden)
+		return
+	}
+	if errors.Is(err, service.ErrTourNotEditable) {
+		writeError(writer, err.Error(), http.StatusConflict)
+		return
+	}
 	if err != nil {
-		http.Error(writer, "KeyPoint not found", http.StatusNotFound)
+		writeError(writer, "KeyPoint not found", http.StatusNotFound)
 		return
 	}
+	if uploads != nil {
+		uploads.Keep()
+	}
 
 	writer.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(writer).Encode(map[string]string{"message": "KeyPoint updated successfully"})
 }
 
+// parseFormCoordinates reads the latitude and longitude form fields, adding
+// the ones that are not numbers to invalid. Their range is left to the service.
+func parseFormCoordinates(values url.Values, invalid *[]service.FieldError) model.Coordinates {
+	parse := func(name string) float64 {
+		v, err := strconv.ParseFloat(values.Get(name), 64)
+		if err != nil {
+			*invalid = append(*invalid, service.FieldError{Field: name, Message: "must be a number"})
+		}
+		return v
+	}
+	return model.Coordinates{Latitude: parse("latitude"), Longitude: parse("longitude")}
+}
+
 // GetImage serves the image data for a keypoint
 func (handler *KeyPointHandler) GetImage(writer http.ResponseWriter, req *http.Request) {
 	idStr := mux.Vars(req)["id"]
 	
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		http.Error(writer, "Invalid UUID", http.StatusBadRequest)
+		writeError(writer, "Invalid UUID", http.StatusBadRequest)
 		return
 	}
 
-	keyPoint, err := handler.KeyPointService.GetById(id)
+	image, err := handler.KeyPointService.GetImage(id, callerId(req))
+	if errors.Is(err, service.ErrTourNotPurchased) {
+		writeError(writer, err.Error(), http.StatusForbidden)
+		return
+	}
 	if err != nil {
-		http.Error(writer, "KeyPoint not found", http.StatusNotFound)
+		writeError(writer, "KeyPoint not found", http.StatusNotFound)
 		return
 	}
 
 	// Check if image exists
-	if len(keyPoint.Image.Data) == 0 {
-		http.Error(writer, "No image found for this keypoint", http.StatusNotFound)
+	if image.IsEmpty() {
+		writeError(writer, "No image found for this keypoint", http.StatusNotFound)
 		return
 	}
 
-	// Set appropriate headers
-	writer.Header().Set("Content-Type", keyPoint.Image.MimeType)
-	writer.Header().Set("Content-Length", strconv.Itoa(len(keyPoint.Image.Data)))
-	
-	// Write image data
-	writer.Write(keyPoint.Image.Data)
+	writeImage(writer, req, handler.Images, image)
 }
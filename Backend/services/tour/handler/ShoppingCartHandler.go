@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"tour.xws.com/service"
+)
+
+type ShoppingCartHandler struct {
+	CartService *service.ShoppingCartService
+}
+
+func (h *ShoppingCartHandler) Get(w http.ResponseWriter, r *http.Request) {
+	touristId := callerId(r)
+	if touristId == "" {
+		writeError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	cart, err := h.CartService.GetCart(touristId)
+	if err != nil {
+		writeError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(cart)
+}
+
+type addCartItemReq struct {
+	TourId string `json:"tourId"`
+}
+
+func (h *ShoppingCartHandler) AddItem(w http.ResponseWriter, r *http.Request) {
+	touristId := callerId(r)
+	if touristId == "" {
+		writeError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var body addCartItemReq
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, "Bad JSON", http.StatusBadRequest)
+		return
+	}
+	tourId, err := uuid.Parse(body.TourId)
+	if err != nil {
+		writeError(w, "Invalid tour id", http.StatusBadRequest)
+		return
+	}
+
+	cart, err := h.CartService.AddItem(touristId, tourId)
+	if err != nil {
+		writeCartError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(cart)
+}
+
+func (h *ShoppingCartHandler) RemoveItem(w http.ResponseWriter, r *http.Request) {
+	touristId := callerId(r)
+	if touristId == "" {
+		writeError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	tourId, err := uuid.Parse(mux.Vars(r)["tourId"])
+	if err != nil {
+		writeError(w, "Invalid tour id", http.StatusBadRequest)
+		return
+	}
+
+	cart, err := h.CartService.RemoveItem(touristId, tourId)
+	if err != nil {
+		writeCartError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(cart)
+}
+
+func (h *ShoppingCartHandler) Checkout(w http.ResponseWriter, r *http.Request) {
+	touristId := callerId(r)
+	if touristId == "" {
+		writeError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	tokens, err := h.CartService.Checkout(touristId)
+	if err != nil {
+		writeCartError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(tokens)
+}
+
+func (h *ShoppingCartHandler) GetPurchases(w http.ResponseWriter, r *http.Request) {
+	touristId := callerId(r)
+	if touristId == "" {
+		writeError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	tokens, err := h.CartService.GetPurchases(touristId)
+	if err != nil {
+		writeError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(tokens)
+}
+
+func writeCartError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrTourNotForSale),
+		errors.Is(err, service.ErrTourAlreadyInCart),
+		errors.Is(err, service.ErrTourAlreadyPurchased),
+		errors.Is(err, service.ErrCartEmpty):
+		writeError(w, err.Error(), http.StatusConflict)
+	default:
+		writeError(w, err.Error(), http.StatusNotFound)
+	}
+}
@@ -0,0 +1,163 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"tour.xws.com/model"
+	"tour.xws.com/service"
+)
+
+type TourExecutionHandler struct {
+	ExecutionService *service.TourExecutionService
+}
+
+type startExecutionReq struct {
+	TourId string `json:"tourId"`
+}
+
+// touristId returns the authenticated tourist, answering 401 when there is none.
+// Executions are always the caller's own.
+func touristId(w http.ResponseWriter, r *http.Request) (string, bool) {
+	id := callerId(r)
+	if id == "" {
+		writeError(w, "Unauthorized", http.StatusUnauthorized)
+		return "", false
+	}
+	return id, true
+}
+
+func (h *TourExecutionHandler) Start(w http.ResponseWriter, r *http.Request) {
+	tourist, ok := touristId(w, r)
+	if !ok {
+		return
+	}
+	var body startExecutionReq
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, "Bad JSON", http.StatusBadRequest)
+		return
+	}
+	tourId, err := uuid.Parse(body.TourId)
+	if err != nil {
+		writeError(w, "Invalid tour id", http.StatusBadRequest)
+		return
+	}
+
+	execution, err := h.ExecutionService.Start(tourist, tourId)
+	if err != nil {
+		writeExecutionError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(execution)
+}
+
+func (h *TourExecutionHandler) GetById(w http.ResponseWriter, r *http.Request) {
+	tourist, ok := touristId(w, r)
+	if !ok {
+		return
+	}
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, "Invalid execution id", http.StatusBadRequest)
+		return
+	}
+
+	execution, err := h.ExecutionService.GetById(id, tourist)
+	if err != nil {
+		writeExecutionError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(execution)
+}
+
+// KeyPoints returns the route of the execution, as it was when the tour was started.
+func (h *TourExecutionHandler) KeyPoints(w http.ResponseWriter, r *http.Request) {
+	tourist, ok := touristId(w, r)
+	if !ok {
+		return
+	}
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, "Invalid execution id", http.StatusBadRequest)
+		return
+	}
+
+	keyPoints, err := h.ExecutionService.KeyPoints(id, tourist)
+	if err != nil {
+		writeExecutionError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(keyPoints)
+}
+
+// GetActive returns the caller's active execution, if any.
+func (h *TourExecutionHandler) GetActive(w http.ResponseWriter, r *http.Request) {
+	tourist, ok := touristId(w, r)
+	if !ok {
+		return
+	}
+
+	execution, err := h.ExecutionService.GetActive(tourist)
+	if err != nil {
+		writeError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if execution == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(execution)
+}
+
+func (h *TourExecutionHandler) Abandon(w http.ResponseWriter, r *http.Request) {
+	h.finish(w, r, h.ExecutionService.Abandon)
+}
+
+func (h *TourExecutionHandler) Complete(w http.ResponseWriter, r *http.Request) {
+	h.finish(w, r, h.ExecutionService.Complete)
+}
+
+func (h *TourExecutionHandler) finish(w http.ResponseWriter, r *http.Request, end func(uuid.UUID, string) (*model.TourExecution, error)) {
+	tourist, ok := touristId(w, r)
+	if !ok {
+		return
+	}
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, "Invalid execution id", http.StatusBadRequest)
+		return
+	}
+
+	execution, err := end(id, tourist)
+	if err != nil {
+		writeExecutionError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(execution)
+}
+
+func writeExecutionError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrTourNotStartable),
+		errors.Is(err, service.ErrExecutionAlreadyActive),
+		errors.Is(err, service.ErrExecutionNotActive),
+		errors.Is(err, service.ErrExecutionIncomplete):
+		writeError(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, service.ErrTourNotPurchased),
+		errors.Is(err, service.ErrNotExecutionOwner):
+		writeError(w, err.Error(), http.StatusForbidden)
+	case isNotFound(err):
+		writeError(w, err.Error(), http.StatusNotFound)
+	default:
+		writeError(w, err.Error(), http.StatusInternalServerError)
+	}
+}
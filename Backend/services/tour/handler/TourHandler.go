@@ -2,13 +2,19 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"log"
+	"mime"
 	"net/http"
-	"time"
+	"net/url"
+	"strconv"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 	"tour.xws.com/model"
+	"tour.xws.com/repository"
 	"tour.xws.com/service"
 )
 
@@ -16,35 +22,132 @@ type TourHandler struct {
 	TourService *service.TourService
 }
 
+// maxRouteUpload bounds an imported GPX or GeoJSON file.
+const maxRouteUpload = 5 << 20
+
+// GetAll returns one page of tours. Supported query parameters are page,
+// pageSize, status, difficulty, transportType, maxDuration (minutes, with
+// transportType if given), tag, authorId, minPrice, maxPrice, sort
+// (publishedAt, price, distance or rating) and order (asc or desc). Without a
+// status only published tours are listed, unless the caller is an admin or the
+// author given by authorId.
 func (handler *TourHandler) GetAll(writer http.ResponseWriter, req *http.Request) {
-	tours, err := handler.TourService.GetAllTours()
-	writer.Header().Set("Content-Type", "application/json")
+	query, err := parseTourQuery(req.URL.Query())
+	if err != nil {
+		writeError(writer, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	page, err := handler.TourService.Find(query, caller(req))
+	if errors.Is(err, service.ErrInvalidTourQuery) {
+		writeError(writer, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, service.ErrUnpublishedListing) {
+		writeError(writer, err.Error(), http.StatusForbidden)
+		return
+	}
 	if err != nil {
-		http.Error(writer, err.Error(), http.StatusInternalServerError)
+		writeError(writer, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	writer.Header().Set("Content-Type", "application/json")
 	writer.WriteHeader(http.StatusOK)
-	json.NewEncoder(writer).Encode(tours)
+	json.NewEncoder(writer).Encode(page)
 }
 
+func parseTourQuery(values url.Values) (repository.TourQuery, error) {
+	query := repository.TourQuery{
+		Tag:      values.Get("tag"),
+		AuthorId: values.Get("authorId"),
+		SortBy:   repository.TourSortField(values.Get("sort")),
+	}
+
+	ints := map[string]*int{"page": &query.Page, "pageSize": &query.PageSize}
+	for name, target := range ints {
+		if raw := values.Get(name); raw != "" {
+			v, err := strconv.Atoi(raw)
+			if err != nil {
+				return query, fmt.Errorf("invalid %s", name)
+			}
+			*target = v
+		}
+	}
+
+	if raw := values.Get("status"); raw != "" {
+		v, err := strconv.Atoi(raw)
+		if err != nil || v < int(model.Draft) || v > int(model.Archived) {
+			return query, errors.New("invalid status")
+		}
+		status := model.TourStatus(v)
+		query.Status = &status
+	}
+	if raw := values.Get("difficulty"); raw != "" {
+		v, err := strconv.Atoi(raw)
+		if err != nil || v < int(model.Beginner) || v > int(model.Pro) {
+			return query, errors.New("invalid difficulty")
+		}
+		difficulty := model.TourDifficulty(v)
+		query.Difficulty = &difficulty
+	}
+	if raw := values.Get("transportType"); raw != "" {
+		v, err := strconv.Atoi(raw)
+		if err != nil || v < int(model.Walking) || v > int(model.Bus) {
+			return query, errors.New("invalid transportType")
+		}
+		transportType := model.TransportType(v)
+		query.TransportType = &transportType
+	}
+
+	bounds := map[string]**float64{"minPrice": &query.MinPrice, "maxPrice": &query.MaxPrice, "maxDuration": &query.MaxDuration}
+	for name, target := range bounds {
+		if raw := values.Get(name); raw != "" {
+			v, err := strconv.ParseFloat(raw, 64)
+			if err != nil {
+				return query, fmt.Errorf("invalid %s", name)
+			}
+			*target = &v
+		}
+	}
+
+	switch values.Get("order") {
+	case "", "asc":
+	case "desc":
+		query.Descending = true
+	default:
+		return query, errors.New("invalid order, expected asc or desc")
+	}
+	return query, nil
+}
+
+// Create stores a new draft tour of the caller; an authorId in the body is ignored.
 func (handler *TourHandler) Create(writer http.ResponseWriter, req *http.Request) {
+	authorId := callerId(req)
+	if authorId == "" {
+		writeError(writer, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
 	var tour model.Tour
-	err := json.NewDecoder(req.Body).Decode(&tour)
-	if err != nil {
-		println("Error while parsing json")
-		writer.WriteHeader(http.StatusBadRequest)
+	if err := json.NewDecoder(req.Body).Decode(&tour); err != nil {
+		writeError(writer, "Invalid JSON body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	tour.AuthorId = authorId
+	err := handler.TourService.Create(&tour)
+	if errors.Is(err, service.ErrValidation) {
+		writeValidationError(writer, err)
 		return
 	}
-	err = handler.TourService.Create(&tour)
 	if err != nil {
-		println("Error while creating a new tour")
-		writer.WriteHeader(http.StatusExpectationFailed)
+		log.Printf("Error while creating a new tour: %v", err)
+		writeError(writer, "Error while creating tour", http.StatusInternalServerError)
 		return
 	}
-	writer.WriteHeader(http.StatusCreated)
+
 	writer.Header().Set("Content-Type", "application/json")
-	println("Tour successfully created")
+	writer.WriteHeader(http.StatusCreated)
+	json.NewEncoder(writer).Encode(tour)
 }
 
 func (handler *TourHandler) Delete(writer http.ResponseWriter, req *http.Request) {
@@ -53,13 +156,25 @@ func (handler *TourHandler) Delete(writer http.ResponseWriter, req *http.Request
 
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		http.Error(writer, "Invalid UUID", http.StatusBadRequest)
+		writeError(writer, "Invalid UUID", http.StatusBadRequest)
 		return
 	}
 
-	err = handler.TourService.Delete(id)
+	err = handler.TourService.Delete(id, caller(req))
+	if errors.Is(err, service.ErrForbidden) {
+		writeError(writer, err.Error(), http.StatusForbidden)
+		return
+	}
+	if errors.Is(err, service.ErrTourInUse) {
+		writeError(writer, err.Error(), http.StatusConflict)
+		return
+	}
+	if errors.Is(err, repository.ErrTourNotFound) {
+		writeError(writer, err.Error(), http.StatusNotFound)
+		return
+	}
 	if err != nil {
-		http.Error(writer, err.Error(), http.StatusNotFound)
+		writeError(writer, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
@@ -73,68 +188,250 @@ func (handler *TourHandler) Update(writer http.ResponseWriter, req *http.Request
 
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		http.Error(writer, "Invalid UUID", http.StatusBadRequest)
+		writeError(writer, "Invalid UUID", http.StatusBadRequest)
 		return
 	}
 
 	var input struct {
-		AuthorId      string               `json:"authorId"`
-		Title         string               `json:"title"`
-		Description   string               `json:"description"`
-		Difficulty    model.TourDifficulty `json:"difficulty"`
-		Tags          []string             `json:"tags"`
-		Status        model.TourStatus     `json:"status"`
-		Price         float64              `json:"price"`
-		Distance      float64              `json:"distance"`
-		PublishedAt   time.Time            `json:"publishedAt"`
-		ArchivedAt    time.Time            `json:"archivedAt"`
-		Duration      float64              `json:"duration"`
-		TransportType model.TransportType  `json:"transportType"`
+		Title       string                    `json:"title"`
+		Description string                    `json:"description"`
+		Difficulty  model.TourDifficulty      `json:"difficulty"`
+		Tags        []string                  `json:"tags"`
+		Price       float64                   `json:"price"`
+		Durations   []model.TransportDuration `json:"durations"`
 	}
 
 	if err := json.NewDecoder(req.Body).Decode(&input); err != nil {
-		http.Error(writer, err.Error(), http.StatusBadRequest)
+		writeError(writer, err.Error(), http.StatusBadRequest)
 		return
 	}
 
 	updatedTour := model.Tour{
-		AuthorId:     input.AuthorId,
 		Title:       input.Title,
 		Description: input.Description,
 		Difficulty:  input.Difficulty,
 		Tags:       input.Tags,
-		Status:     input.Status,
 		Price:     input.Price,
-		Distance:  input.Distance,
-		PublishedAt: input.PublishedAt,
-		ArchivedAt:  input.ArchivedAt,
-		Duration:    input.Duration,
-		TransportType: input.TransportType,
+		Durations:   input.Durations,
+	}
+
+	// edits to a published tour land in its draft revision, which is returned
+	tour, err := handler.TourService.Update(id, updatedTour, caller(req))
+	if errors.Is(err, service.ErrValidation) {
+		writeValidationError(writer, err)
+		return
+	}
+	if errors.Is(err, service.ErrForbidden) {
+		writeError(writer, err.Error(), http.StatusForbidden)
+		return
+	}
+	if isNotFound(err) {
+		writeError(writer, "Tour not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		writeError(writer, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(writer).Encode(tour)
+}
+
+// Export handles GET /tours/{id}/export?format=gpx|geojson; format defaults to gpx.
+func (handler *TourHandler) Export(writer http.ResponseWriter, req *http.Request) {
+	id, err := uuid.Parse(mux.Vars(req)["id"])
+	if err != nil {
+		writeError(writer, "Invalid UUID", http.StatusBadRequest)
+		return
+	}
+	format := service.GPX
+	if raw := req.URL.Query().Get("format"); raw != "" {
+		if format, err = service.ParseRouteFormat(raw); err != nil {
+			writeError(writer, err.Error(), http.StatusBadRequest)
+			return
+		}
 	}
 
-	err = handler.TourService.Update(id, updatedTour)
+	tour, data, err := handler.TourService.Export(id, format, caller(req))
+	if errors.Is(err, service.ErrTourNotPurchased) {
+		writeError(writer, err.Error(), http.StatusForbidden)
+		return
+	}
+	if isNotFound(err) {
+		writeError(writer, err.Error(), http.StatusNotFound)
+		return
+	}
 	if err != nil {
-		http.Error(writer, "Tour not found", http.StatusNotFound)
+		writeError(writer, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writer.Header().Set("Content-Type", format.ContentType())
+	writer.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": tour.Title + format.Extension()}))
+	writer.WriteHeader(http.StatusOK)
+	writer.Write(data)
+}
+
+// Import handles POST /tours/import, a multipart form with the GPX or GeoJSON
+// file in "file" and an optional "title" overriding the one in the file.
+func (handler *TourHandler) Import(writer http.ResponseWriter, req *http.Request) {
+	authorId := callerId(req)
+	if authorId == "" {
+		writeError(writer, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	req.Body = http.MaxBytesReader(writer, req.Body, maxRouteUpload)
+	if err := req.ParseMultipartForm(maxRouteUpload); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(writer, "Upload too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		writeError(writer, "Failed to parse multipart form", http.StatusBadRequest)
+		return
+	}
+	file, _, err := req.FormFile("file")
+	if err != nil {
+		writeError(writer, "file is required", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		writeError(writer, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	tour, err := handler.TourService.Import(authorId, req.FormValue("title"), data)
+	if errors.Is(err, service.ErrValidation) {
+		writeValidationError(writer, err)
+		return
+	}
+	if errors.Is(err, service.ErrInvalidRouteFile) || errors.Is(err, service.ErrUnsupportedRouteFormat) {
+		writeError(writer, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		writeError(writer, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	writer.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(writer).Encode(map[string]string{"message": "Tour updated successfully"})
+	writer.WriteHeader(http.StatusCreated)
+	json.NewEncoder(writer).Encode(tour)
 }
 
-func (handler *TourHandler) GetAllByAuthor(writer http.ResponseWriter, req *http.Request) {
-	userID := mux.Vars(req)["userId"]
+// Clone copies the tour and its key points into a new draft.
+func (handler *TourHandler) Clone(writer http.ResponseWriter, req *http.Request) {
+	id, err := uuid.Parse(mux.Vars(req)["id"])
+	if err != nil {
+		writeError(writer, "Invalid UUID", http.StatusBadRequest)
+		return
+	}
+
+	tour, err := handler.TourService.Clone(id, caller(req))
+	if errors.Is(err, service.ErrForbidden) {
+		writeError(writer, err.Error(), http.StatusForbidden)
+		return
+	}
+	if isNotFound(err) {
+		writeError(writer, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		writeError(writer, err.Error(), http.StatusInternalServerError)
+		return
+	}
 
 	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusCreated)
+	json.NewEncoder(writer).Encode(tour)
+}
+
+// StartRevision returns the draft revision of a published or archived tour,
+// starting one if needed.
+func (handler *TourHandler) StartRevision(writer http.ResponseWriter, req *http.Request) {
+	id, err := uuid.Parse(mux.Vars(req)["id"])
+	if err != nil {
+		writeError(writer, "Invalid UUID", http.StatusBadRequest)
+		return
+	}
 
-	tours, err := handler.TourService.GetAllByAuthor(userID)
+	tour, err := handler.TourService.StartRevision(id, caller(req))
+	if errors.Is(err, service.ErrForbidden) {
+		writeError(writer, err.Error(), http.StatusForbidden)
+		return
+	}
+	if isNotFound(err) {
+		writeError(writer, err.Error(), http.StatusNotFound)
+		return
+	}
 	if err != nil {
-		http.Error(writer, err.Error(), http.StatusInternalServerError)
+		writeError(writer, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	writer.Header().Set("Content-Type", "application/json")
 	writer.WriteHeader(http.StatusOK)
-	json.NewEncoder(writer).Encode(tours)
+	json.NewEncoder(writer).Encode(tour)
+}
+
+// Revisions lists the published versions of the tour, newest first.
+func (handler *TourHandler) Revisions(writer http.ResponseWriter, req *http.Request) {
+	id, err := uuid.Parse(mux.Vars(req)["id"])
+	if err != nil {
+		writeError(writer, "Invalid UUID", http.StatusBadRequest)
+		return
+	}
+
+	revisions, err := handler.TourService.Revisions(id, caller(req))
+	if errors.Is(err, service.ErrForbidden) {
+		writeError(writer, err.Error(), http.StatusForbidden)
+		return
+	}
+	if isNotFound(err) {
+		writeError(writer, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		writeError(writer, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	json.NewEncoder(writer).Encode(revisions)
+}
+
+// GetAllByAuthor lists one author's tours with the same visibility rules and
+// filters as GetAll.
+func (handler *TourHandler) GetAllByAuthor(writer http.ResponseWriter, req *http.Request) {
+	query, err := parseTourQuery(req.URL.Query())
+	if err != nil {
+		writeError(writer, err.Error(), http.StatusBadRequest)
+		return
+	}
+	query.AuthorId = mux.Vars(req)["userId"]
+
+	page, err := handler.TourService.Find(query, caller(req))
+	if errors.Is(err, service.ErrInvalidTourQuery) {
+		writeError(writer, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, service.ErrUnpublishedListing) {
+		writeError(writer, err.Error(), http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		writeError(writer, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	json.NewEncoder(writer).Encode(page)
 }
 
 func (handler *TourHandler) GetById(writer http.ResponseWriter, req *http.Request) {
@@ -143,14 +440,104 @@ func (handler *TourHandler) GetById(writer http.ResponseWriter, req *http.Reques
 
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		http.Error(writer, "Invalid UUID", http.StatusBadRequest)
+		writeError(writer, "Invalid UUID", http.StatusBadRequest)
+		return
+	}
+
+	tour, err := handler.TourService.GetById(id, caller(req))
+	if isNotFound(err) {
+		writeError(writer, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		writeError(writer, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	json.NewEncoder(writer).Encode(tour)
+}
+
+// Nearby handles GET /tours/nearby?lat=&lng=&radiusKm=&match=first|any.
+// radiusKm defaults to 10 and match to any key point of the tour.
+func (handler *TourHandler) Nearby(writer http.ResponseWriter, req *http.Request) {
+	values := req.URL.Query()
+	lat, latErr := strconv.ParseFloat(values.Get("lat"), 64)
+	lng, lngErr := strconv.ParseFloat(values.Get("lng"), 64)
+	if latErr != nil || lngErr != nil {
+		writeError(writer, "lat and lng are required", http.StatusBadRequest)
+		return
+	}
+	radiusKm := 10.0
+	if raw := values.Get("radiusKm"); raw != "" {
+		var err error
+		if radiusKm, err = strconv.ParseFloat(raw, 64); err != nil {
+			writeError(writer, "invalid radiusKm", http.StatusBadRequest)
+			return
+		}
+	}
+	var firstOnly bool
+	switch values.Get("match") {
+	case "", "any":
+	case "first":
+		firstOnly = true
+	default:
+		writeError(writer, "invalid match, expected first or any", http.StatusBadRequest)
+		return
+	}
+
+	tours, err := handler.TourService.Nearby(model.Coordinates{Latitude: lat, Longitude: lng}, radiusKm, firstOnly)
+	if errors.Is(err, service.ErrInvalidNearbyQuery) {
+		writeError(writer, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		writeError(writer, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	json.NewEncoder(writer).Encode(tours)
+}
+
+func (handler *TourHandler) Publish(writer http.ResponseWriter, req *http.Request) {
+	handler.changeStatus(writer, req, handler.TourService.Publish)
+}
+
+func (handler *TourHandler) Archive(writer http.ResponseWriter, req *http.Request) {
+	handler.changeStatus(writer, req, handler.TourService.Archive)
+}
+
+func (handler *TourHandler) Reactivate(writer http.ResponseWriter, req *http.Request) {
+	handler.changeStatus(writer, req, handler.TourService.Reactivate)
+}
+
+func (handler *TourHandler) changeStatus(writer http.ResponseWriter, req *http.Request, transition func(uuid.UUID, service.Caller) (model.Tour, error)) {
+	idStr := mux.Vars(req)["id"]
+	log.Printf("Changing status of tour with ID: %s", idStr)
+
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		writeError(writer, "Invalid UUID", http.StatusBadRequest)
 		return
 	}
 
-	tour, err := handler.TourService.GetById(id)
+	tour, err := transition(id, caller(req))
 	if err != nil {
-		// if repository returned not found, respond 404
-		http.Error(writer, err.Error(), http.StatusNotFound)
+		switch {
+		case errors.Is(err, service.ErrForbidden):
+			writeError(writer, err.Error(), http.StatusForbidden)
+		case errors.Is(err, service.ErrInvalidStatusTransition):
+			writeError(writer, err.Error(), http.StatusConflict)
+		case errors.Is(err, service.ErrTourNotPublishable):
+			writeError(writer, err.Error(), http.StatusUnprocessableEntity)
+		case isNotFound(err):
+			writeError(writer, err.Error(), http.StatusNotFound)
+		default:
+			writeError(writer, err.Error(), http.StatusInternalServerError)
+		}
 		return
 	}
 
@@ -2,26 +2,32 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 	"tour.xws.com/model"
+	"tour.xws.com/repository"
 	"tour.xws.com/service"
 )
 
 type TourRatingHandler struct {
 	RatingService *service.TourRatingService
+	Images        *service.ImageService
 }
 
+// maxReviewUpload bounds one photo upload request to a review.
+const maxReviewUpload = 10 << 20
+
+// createRatingReq carries only the review itself; who wrote it comes from the
+// identity the gateway forwards.
 type createRatingReq struct {
-	Rating       int    `json:"rating"`
-	Comment      string `json:"comment"`
-	TouristName  string `json:"touristName"`
-	TouristEmail string `json:"touristEmail"`
-	VisitedAt    string `json:"visitedAt"`   // yyyy-mm-dd
-	CommentedAt  string `json:"commentedAt"` // yyyy-mm-dd
+	Rating      int    `json:"rating"`
+	Comment     string `json:"comment"`
+	VisitedAt   string `json:"visitedAt"`   // yyyy-mm-dd
+	CommentedAt string `json:"commentedAt"` // yyyy-mm-dd
 }
 
 func parseDate(d string) (time.Time, error) {
@@ -31,44 +37,186 @@ func parseDate(d string) (time.Time, error) {
 
 func (h *TourRatingHandler) Create(w http.ResponseWriter, r *http.Request) {
 	tourId, err := uuid.Parse(mux.Vars(r)["id"])
-	if err != nil { http.Error(w, "Invalid tour id", http.StatusBadRequest); return }
+	if err != nil { writeError(w, "Invalid tour id", http.StatusBadRequest); return }
 
 	var body createRatingReq
 	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-		http.Error(w, "Bad JSON", http.StatusBadRequest); return
+		writeError(w, "Bad JSON", http.StatusBadRequest); return
 	}
 
 	visitedAt, err := parseDate(body.VisitedAt)
-	if err != nil { http.Error(w, "Invalid visitedAt", http.StatusBadRequest); return }
+	if err != nil { writeError(w, "Invalid visitedAt", http.StatusBadRequest); return }
 	commentedAt, err := parseDate(body.CommentedAt)
-	if err != nil { http.Error(w, "Invalid commentedAt", http.StatusBadRequest); return }
+	if err != nil { writeError(w, "Invalid commentedAt", http.StatusBadRequest); return }
+
+	touristId := callerId(r)
+	if touristId == "" { writeError(w, "Missing user identity", http.StatusUnauthorized); return }
 
 	item := &model.TourRating{
 		Id:           uuid.New(),
 		TourId:       tourId,
+		TouristId:    touristId,
 		Rating:       body.Rating,
 		Comment:      body.Comment,
-		TouristName:  body.TouristName,
-		TouristEmail: body.TouristEmail,
+		TouristName:  r.Header.Get("X-User-Name"),
+		TouristEmail: r.Header.Get("X-User-Email"),
 		VisitedAt:    visitedAt,
 		CommentedAt:  commentedAt,
 		CreatedAt:    time.Now().UTC(),
 	}
 
 	if err := h.RatingService.Create(item); err != nil {
-		http.Error(w, "Failed to save review", http.StatusInternalServerError); return
+		writeRatingError(w, err); return
 	}
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	_ = json.NewEncoder(w).Encode(map[string]any{"id": item.Id})
 }
 
+// Update lets a tourist edit their own review of the tour.
+func (h *TourRatingHandler) Update(w http.ResponseWriter, r *http.Request) {
+	tourId, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil { writeError(w, "Invalid tour id", http.StatusBadRequest); return }
+	reviewId, err := uuid.Parse(mux.Vars(r)["reviewId"])
+	if err != nil { writeError(w, "Invalid review id", http.StatusBadRequest); return }
+
+	var body createRatingReq
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, "Bad JSON", http.StatusBadRequest); return
+	}
+
+	visitedAt, err := parseDate(body.VisitedAt)
+	if err != nil { writeError(w, "Invalid visitedAt", http.StatusBadRequest); return }
+	commentedAt, err := parseDate(body.CommentedAt)
+	if err != nil { writeError(w, "Invalid commentedAt", http.StatusBadRequest); return }
+
+	item := &model.TourRating{
+		Id:          reviewId,
+		TourId:      tourId,
+		TouristId:   callerId(r),
+		Rating:      body.Rating,
+		Comment:     body.Comment,
+		VisitedAt:   visitedAt,
+		CommentedAt: commentedAt,
+		UpdatedAt:   time.Now().UTC(),
+	}
+
+	if err := h.RatingService.Update(item); err != nil {
+		writeRatingError(w, err); return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{"id": item.Id})
+}
+
+// AddImages attaches the photos uploaded as multipart "images" to the caller's review.
+func (h *TourRatingHandler) AddImages(w http.ResponseWriter, r *http.Request) {
+	tourId, reviewId, ok := reviewIds(w, r)
+	if !ok { return }
+
+	form, err := readUploadForm(w, r, h.Images, "reviews/"+reviewId.String(), maxReviewUpload, "images")
+	if err != nil { writeUploadError(w, err); return }
+	defer form.Close()
+	images := form.Files["images"]
+	if len(images) == 0 { writeError(w, "No images uploaded", http.StatusBadRequest); return }
+
+	added, err := h.RatingService.AddImages(tourId, reviewId, callerId(r), images)
+	if err != nil { writeRatingError(w, err); return }
+	form.Keep()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(added)
+}
+
+func (h *TourRatingHandler) DeleteImage(w http.ResponseWriter, r *http.Request) {
+	tourId, reviewId, ok := reviewIds(w, r)
+	if !ok { return }
+	imageId, err := uuid.Parse(mux.Vars(r)["imageId"])
+	if err != nil { writeError(w, "Invalid image id", http.StatusBadRequest); return }
+
+	if err := h.RatingService.RemoveImage(tourId, reviewId, imageId, callerId(r)); err != nil {
+		writeRatingError(w, err); return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *TourRatingHandler) GetImage(w http.ResponseWriter, r *http.Request) {
+	tourId, reviewId, ok := reviewIds(w, r)
+	if !ok { return }
+	imageId, err := uuid.Parse(mux.Vars(r)["imageId"])
+	if err != nil { writeError(w, "Invalid image id", http.StatusBadRequest); return }
+
+	image, err := h.RatingService.GetImage(tourId, reviewId, imageId)
+	if err != nil { writeRatingError(w, err); return }
+	writeImage(w, r, h.Images, image)
+}
+
+// SetReply posts or edits the tour author's reply to a review.
+func (h *TourRatingHandler) SetReply(w http.ResponseWriter, r *http.Request) {
+	tourId, reviewId, ok := reviewIds(w, r)
+	if !ok { return }
+
+	var body struct {
+		Text string `json:"text"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, "Bad JSON", http.StatusBadRequest); return
+	}
+
+	reply, err := h.RatingService.SetReply(tourId, reviewId, body.Text, caller(r))
+	if err != nil { writeRatingError(w, err); return }
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(reply)
+}
+
+func (h *TourRatingHandler) DeleteReply(w http.ResponseWriter, r *http.Request) {
+	tourId, reviewId, ok := reviewIds(w, r)
+	if !ok { return }
+
+	if err := h.RatingService.DeleteReply(tourId, reviewId, caller(r)); err != nil {
+		writeRatingError(w, err); return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func reviewIds(w http.ResponseWriter, r *http.Request) (tourId, reviewId uuid.UUID, ok bool) {
+	tourId, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil { writeError(w, "Invalid tour id", http.StatusBadRequest); return }
+	reviewId, err = uuid.Parse(mux.Vars(r)["reviewId"])
+	if err != nil { writeError(w, "Invalid review id", http.StatusBadRequest); return }
+	return tourId, reviewId, true
+}
+
+func writeRatingError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidRating),
+		errors.Is(err, service.ErrTooManyReviewImages),
+		errors.Is(err, service.ErrEmptyReply):
+		writeError(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, service.ErrAlreadyReviewed):
+		writeError(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, service.ErrNotReviewAuthor),
+		errors.Is(err, service.ErrNotEligibleToReview),
+		errors.Is(err, service.ErrForbidden):
+		writeError(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, service.ErrTourNotReviewable):
+		writeError(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, repository.ErrReviewNotFound),
+		errors.Is(err, repository.ErrReviewImageNotFound),
+		errors.Is(err, repository.ErrTourNotFound):
+		writeError(w, err.Error(), http.StatusNotFound)
+	default:
+		writeError(w, "Failed to save review", http.StatusInternalServerError)
+	}
+}
+
 func (h *TourRatingHandler) GetByTour(w http.ResponseWriter, r *http.Request) {
 	tourId, err := uuid.Parse(mux.Vars(r)["id"])
-	if err != nil { http.Error(w, "Invalid tour id", http.StatusBadRequest); return }
+	if err != nil { writeError(w, "Invalid tour id", http.StatusBadRequest); return }
 
 	items, err := h.RatingService.GetByTour(tourId)
-	if err != nil { http.Error(w, "Failed to load reviews", http.StatusInternalServerError); return }
+	if err != nil { writeError(w, "Failed to load reviews", http.StatusInternalServerError); return }
 
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(items)
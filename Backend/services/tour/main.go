@@ -6,15 +6,20 @@ import (
 	"net"
 	"net/http"
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/zopuu/soa-team-20/Backend/pkg/blobstore"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/reflection"
 	tourgrpc "tour.xws.com/grpc"
 	"tour.xws.com/handler"
+	"tour.xws.com/migration"
+	"tour.xws.com/model"
 	tourpb "tour.xws.com/proto"
 	"tour.xws.com/repository"
 	"tour.xws.com/service"
@@ -25,6 +30,10 @@ type MongoCollections struct {
 	KeyPoints        *mongo.Collection
 	CurrentLocations *mongo.Collection
 	Ratings          *mongo.Collection
+	Executions       *mongo.Collection
+	ShoppingCarts    *mongo.Collection
+	PurchaseTokens   *mongo.Collection
+	Revisions        *mongo.Collection
 }
 
 func initMongoDB() MongoCollections {
@@ -52,11 +61,125 @@ func initMongoDB() MongoCollections {
 		KeyPoints:        db.Collection("keyPoints"),
 		CurrentLocations: db.Collection("currentLocations"),
 		Ratings:          db.Collection("tour_ratings"),
+		Executions:       db.Collection("tour_executions"),
+		ShoppingCarts:    db.Collection("shopping_carts"),
+		PurchaseTokens:   db.Collection("tour_purchase_tokens"),
+		Revisions:        db.Collection("tour_revisions"),
+	}
+
+	tokenIndex := mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "touristId", Value: 1},
+			{Key: "tourId", Value: 1},
+		},
+		Options: options.Index().SetUnique(true),
+	}
+
+	_, err = collections.PurchaseTokens.Indexes().CreateOne(ctx, tokenIndex)
+	if err != nil {
+		log.Fatalf("Failed to create index on PurchaseTokens: %v", err)
+	}
+
+	// Tour listings filter mostly on status and sort by one of these fields.
+	tourIndexes := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "publishedAt", Value: -1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "price", Value: 1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "distance", Value: 1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "ratingStats.average", Value: -1}}},
+		{Keys: bson.D{{Key: "authorId", Value: 1}}},
+		{Keys: bson.D{{Key: "tags", Value: 1}}},
+		{Keys: bson.D{{Key: "difficulty", Value: 1}}},
+		{Keys: bson.D{{Key: "durations.transportType", Value: 1}, {Key: "durations.minutes", Value: 1}}},
+		// at most one pending draft revision per published tour
+		{Keys: bson.D{{Key: "revisionOf", Value: 1}}, Options: options.Index().SetUnique(true).SetSparse(true)},
+	}
+	// Tours stored with a single duration get it moved into their durations.
+	tourRepository := &repository.TourRepository{Collection: collections.Tours}
+	migrated, err := tourRepository.BackfillDurations()
+	if err != nil {
+		log.Fatalf("Failed to backfill tour durations: %v", err)
+	}
+	if migrated > 0 {
+		log.Printf("Backfilled durations on %d tours", migrated)
+	}
+	_, err = collections.Tours.Indexes().CreateMany(ctx, tourIndexes)
+	if err != nil {
+		log.Fatalf("Failed to create indexes on Tours: %v", err)
+	}
+
+	// Key points stored before the GeoJSON location existed get it derived here,
+	// so every key point is covered by the 2dsphere index.
+	keyPointRepository := &repository.KeyPointRepository{Collection: collections.KeyPoints}
+	backfilled, err := keyPointRepository.BackfillLocations()
+	if err != nil {
+		log.Fatalf("Failed to backfill key point locations: %v", err)
+	}
+	if backfilled > 0 {
+		log.Printf("Backfilled location on %d key points", backfilled)
+	}
+	_, err = collections.KeyPoints.Indexes().CreateOne(ctx, mongo.IndexModel{Keys: bson.D{{Key: "location", Value: "2dsphere"}}})
+	if err != nil {
+		log.Fatalf("Failed to create index on KeyPoints: %v", err)
+	}
+
+	// One review per tourist and tour. Reviews written before reviewers were
+	// identified have no touristId and are left out of the constraint.
+	ratingIndexes := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "tourId", Value: 1}}},
+		{
+			Keys: bson.D{{Key: "tourId", Value: 1}, {Key: "touristId", Value: 1}},
+			Options: options.Index().
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"touristId": bson.M{"$type": "string"}}),
+		},
+	}
+	_, err = collections.Ratings.Indexes().CreateMany(ctx, ratingIndexes)
+	if err != nil {
+		log.Fatalf("Failed to create indexes on Ratings: %v", err)
+	}
+
+	_, err = collections.Revisions.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "tourId", Value: 1}, {Key: "revision", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		log.Fatalf("Failed to create index on Revisions: %v", err)
+	}
+
+	// A tourist is on at most one tour at a time.
+	_, err = collections.Executions.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "touristId", Value: 1}},
+		Options: options.Index().
+			SetUnique(true).
+			SetPartialFilterExpression(bson.M{"status": model.Active}),
+	})
+	if err != nil {
+		log.Fatalf("Failed to create index on Executions: %v", err)
 	}
 
 	return collections
 }
 
+// proximityRadius reads how close (in metres) a tourist must get to a key point
+// for it to count as reached during a tour execution.
+func proximityRadius() float64 {
+	radius, err := strconv.ParseFloat(os.Getenv("KEYPOINT_PROXIMITY_RADIUS_METERS"), 64)
+	if err != nil || radius <= 0 {
+		return 50
+	}
+	return radius
+}
+
+// reviewActivityWindow reads for how many days after going on a tour a tourist
+// who did not buy it may still review it.
+func reviewActivityWindow() time.Duration {
+	days, err := strconv.Atoi(os.Getenv("REVIEW_ACTIVITY_WINDOW_DAYS"))
+	if err != nil || days <= 0 {
+		days = 7
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
 func cors(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Adjust origin as needed; use "*" only if you don't use credentials
@@ -78,14 +201,23 @@ func cors(next http.Handler) http.Handler {
 	})
 }
 
-func startGRPCServer(tourService *service.TourService) {
+// initImageStore opens the blob store images are kept in, configured by the BLOB_* variables.
+func initImageStore() *service.ImageService {
+	store, err := blobstore.New(context.Background(), blobstore.ConfigFromEnv("./data/blobs", "tour-images"))
+	if err != nil {
+		log.Fatalf("Failed to open image store: %v", err)
+	}
+	return &service.ImageService{Store: store}
+}
+
+func startGRPCServer(tourService *service.TourService, keyPointService *service.KeyPointService, images *service.ImageService) {
 	lis, err := net.Listen("tcp", ":50052")
 	if err != nil {
 		log.Fatalf("Failed listen %s: %v", ":50052", err)
 	}
 
 	grpcServer := grpc.NewServer()
-	tourGRPCServer := tourgrpc.NewTourGRPCServer(tourService)
+	tourGRPCServer := tourgrpc.NewTourGRPCServer(tourService, keyPointService, images)
 	tourpb.RegisterTourServiceServer(grpcServer, tourGRPCServer)
 	reflection.Register(grpcServer)
 
@@ -94,32 +226,64 @@ func startGRPCServer(tourService *service.TourService) {
 	}
 }
 
-func startServer(tourHandler *handler.TourHandler, keyPointHandler *handler.KeyPointHandler, locationHandler *handler.CurrentLocationHandler, ratingHandler *handler.TourRatingHandler) {
+func startServer(tourHandler *handler.TourHandler, keyPointHandler *handler.KeyPointHandler, locationHandler *handler.CurrentLocationHandler, ratingHandler *handler.TourRatingHandler, executionHandler *handler.TourExecutionHandler, cartHandler *handler.ShoppingCartHandler) {
 	router := mux.NewRouter().StrictSlash(true)
 
 	//TOUR ENDPOINTS
 	router.HandleFunc("/tours", tourHandler.GetAll).Methods("GET")
 	router.HandleFunc("/tours/users/{userId}", tourHandler.GetAllByAuthor).Methods("GET")
+	router.HandleFunc("/tours/nearby", tourHandler.Nearby).Methods("GET")
 	router.HandleFunc("/tours/{id}", tourHandler.GetById).Methods("GET")
 	router.HandleFunc("/tours", tourHandler.Create).Methods("POST")
+	router.HandleFunc("/tours/import", tourHandler.Import).Methods("POST")
 	router.HandleFunc("/tours/{id}", tourHandler.Delete).Methods("DELETE")
 	router.HandleFunc("/tours/{id}", tourHandler.Update).Methods("PUT")
+	router.HandleFunc("/tours/{id}/publish", tourHandler.Publish).Methods("POST")
+	router.HandleFunc("/tours/{id}/archive", tourHandler.Archive).Methods("POST")
+	router.HandleFunc("/tours/{id}/reactivate", tourHandler.Reactivate).Methods("POST")
+	router.HandleFunc("/tours/{id}/clone", tourHandler.Clone).Methods("POST")
+	router.HandleFunc("/tours/{id}/export", tourHandler.Export).Methods("GET")
+	router.HandleFunc("/tours/{id}/revisions", tourHandler.StartRevision).Methods("POST")
+	router.HandleFunc("/tours/{id}/revisions", tourHandler.Revisions).Methods("GET")
 
 	router.HandleFunc("/tours/{id}/reviews", ratingHandler.Create).Methods("POST")
 	router.HandleFunc("/tours/{id}/reviews", ratingHandler.GetByTour).Methods("GET")
+	router.HandleFunc("/tours/{id}/reviews/{reviewId}", ratingHandler.Update).Methods("PUT")
+	router.HandleFunc("/tours/{id}/reviews/{reviewId}/images", ratingHandler.AddImages).Methods("POST")
+	router.HandleFunc("/tours/{id}/reviews/{reviewId}/images/{imageId}", ratingHandler.GetImage).Methods("GET")
+	router.HandleFunc("/tours/{id}/reviews/{reviewId}/images/{imageId}", ratingHandler.DeleteImage).Methods("DELETE")
+	router.HandleFunc("/tours/{id}/reviews/{reviewId}/reply", ratingHandler.SetReply).Methods("PUT")
+	router.HandleFunc("/tours/{id}/reviews/{reviewId}/reply", ratingHandler.DeleteReply).Methods("DELETE")
 	//KEYPOINT ENDPOINTS
 	router.HandleFunc("/keyPoints", keyPointHandler.GetAll).Methods("GET")
 	router.HandleFunc("/keyPoints/tours/{tourId}", keyPointHandler.GetAllByTour).Methods("GET")
 	router.HandleFunc("/keyPoints/tours/{tourId}/sortedByCreatedAt", keyPointHandler.GetAllByTourSortedByCreatedAt).Methods("GET")
+	router.HandleFunc("/keyPoints/tours/{tourId}/order", keyPointHandler.Reorder).Methods("PUT")
 	router.HandleFunc("/keyPoints", keyPointHandler.Create).Methods("POST")
 	router.HandleFunc("/keyPoints/{id}", keyPointHandler.Delete).Methods("DELETE")
 	router.HandleFunc("/keyPoints/{id}", keyPointHandler.Update).Methods("PUT")
+	router.HandleFunc("/keyPoints/{id}", keyPointHandler.GetById).Methods("GET")
 	router.HandleFunc("/keyPoints/{id}/image", keyPointHandler.GetImage).Methods("GET")
 
 	//CURRENT LOCATION ENDPOINTS
 	router.HandleFunc("/simulator/location/{userId}", locationHandler.Get).Methods("GET")
 	router.HandleFunc("/simulator/location", locationHandler.Set).Methods("PUT")
 
+	//TOUR EXECUTION ENDPOINTS
+	router.HandleFunc("/executions", executionHandler.Start).Methods("POST")
+	router.HandleFunc("/executions/active", executionHandler.GetActive).Methods("GET")
+	router.HandleFunc("/executions/{id}", executionHandler.GetById).Methods("GET")
+	router.HandleFunc("/executions/{id}/abandon", executionHandler.Abandon).Methods("POST")
+	router.HandleFunc("/executions/{id}/complete", executionHandler.Complete).Methods("POST")
+	router.HandleFunc("/executions/{id}/keyPoints", executionHandler.KeyPoints).Methods("GET")
+
+	//SHOPPING CART ENDPOINTS
+	router.HandleFunc("/shopping-cart", cartHandler.Get).Methods("GET")
+	router.HandleFunc("/shopping-cart/items", cartHandler.AddItem).Methods("POST")
+	router.HandleFunc("/shopping-cart/items/{tourId}", cartHandler.RemoveItem).Methods("DELETE")
+	router.HandleFunc("/shopping-cart/checkout", cartHandler.Checkout).Methods("POST")
+	router.HandleFunc("/purchases", cartHandler.GetPurchases).Methods("GET")
+
 	router.Methods(http.MethodOptions).HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusNoContent)
 	})
@@ -132,29 +296,65 @@ func startServer(tourHandler *handler.TourHandler, keyPointHandler *handler.KeyP
 
 func main() {
 	collections := initMongoDB()
+	images := initImageStore()
 
+	// "migrate-images" moves image bytes still stored in Mongo into the blob store and exits.
+	if len(os.Args) > 1 && os.Args[1] == "migrate-images" {
+		if err := migration.MigrateImages(context.Background(), collections.KeyPoints, collections.Ratings, images); err != nil {
+			log.Fatalf("Image migration failed: %v", err)
+		}
+		return
+	}
+
+	//PURCHASE TOKENS
+	tokenRepo := &repository.TourPurchaseTokenRepository{Collection: collections.PurchaseTokens}
+	executionRepo := &repository.TourExecutionRepository{Collection: collections.Executions}
+	//KEYPOINT
+	keyPointRepository := &repository.KeyPointRepository{Collection: collections.KeyPoints}
 	//TOUR
 	tourRepository := &repository.TourRepository{Collection: collections.Tours}
-	tourService := &service.TourService{TourRepository: tourRepository}
+	revisionRepository := &repository.TourRevisionRepository{Collection: collections.Revisions}
+	tourService := &service.TourService{TourRepository: tourRepository, KeyPointRepository: keyPointRepository, RevisionRepository: revisionRepository, TokenRepository: tokenRepo, ExecutionRepository: executionRepo, Images: images}
 	tourHandler := &handler.TourHandler{TourService: tourService}
-	//KEYPOINT
-	keyPointRepository := &repository.KeyPointRepository{Collection: collections.KeyPoints}
-	keyPointService := &service.KeyPointService{KeyPointRepository: keyPointRepository}
-	keyPointHandler := &handler.KeyPointHandler{KeyPointService: keyPointService}
+	keyPointService := &service.KeyPointService{KeyPointRepository: keyPointRepository, TourRepository: tourRepository, TokenRepository: tokenRepo, Images: images}
+	keyPointHandler := &handler.KeyPointHandler{KeyPointService: keyPointService, Images: images}
 	//CURRENT LOCATION
 	locationRepo := &repository.CurrentLocationRepository{Collection: collections.CurrentLocations}
 	locationService := &service.CurrentLocationService{Repo: locationRepo}
-	locationHandler := &handler.CurrentLocationHandler{Svc: locationService}
+	//TOUR EXECUTION
+	executionService := &service.TourExecutionService{
+		Repo:               executionRepo,
+		TourRepository:     tourRepository,
+		KeyPointRepository: keyPointRepository,
+		LocationRepository: locationRepo,
+		TokenRepository:    tokenRepo,
+		RevisionRepository: revisionRepository,
+		ProximityRadius:    proximityRadius(),
+	}
+	executionHandler := &handler.TourExecutionHandler{ExecutionService: executionService}
+	locationHandler := &handler.CurrentLocationHandler{Svc: locationService, ExecutionSvc: executionService}
+	//SHOPPING CART
+	cartRepo := &repository.ShoppingCartRepository{Collection: collections.ShoppingCarts}
+	cartService := &service.ShoppingCartService{CartRepository: cartRepo, TokenRepository: tokenRepo, TourRepository: tourRepository}
+	cartHandler := &handler.ShoppingCartHandler{CartService: cartService}
 	//RATINGS
 	ratingRepo := &repository.TourRatingRepository{Collection: collections.Ratings}
-	ratingService := &service.TourRatingService{Repo: ratingRepo}
-	ratingHandler := &handler.TourRatingHandler{RatingService: ratingService}
+	ratingService := &service.TourRatingService{
+		Repo:                ratingRepo,
+		TourRepository:      tourRepository,
+		TokenRepository:     tokenRepo,
+		ExecutionRepository: executionRepo,
+		ActivityWindow:      reviewActivityWindow(),
+		Images:              images,
+	}
+	if err := ratingService.RefreshAllStats(); err != nil {
+		log.Fatalf("Failed to compute tour rating stats: %v", err)
+	}
+	ratingHandler := &handler.TourRatingHandler{RatingService: ratingService, Images: images}
 
 	go func() {
-        log.Println("Starting gRPC server on port 50052...")
-        startGRPCServer(tourService)
-    }()
-	startServer(tourHandler, keyPointHandler, locationHandler, ratingHandler)
-	
-	
+		log.Println("Starting gRPC server on port 50052...")
+		startGRPCServer(tourService, keyPointService, images)
+	}()
+	startServer(tourHandler, keyPointHandler, locationHandler, ratingHandler, executionHandler, cartHandler)
 }
@@ -0,0 +1,121 @@
+// Package migration holds one-off data migrations run through the service binary.
+package migration
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"tour.xws.com/model"
+	"tour.xws.com/service"
+)
+
+// legacyImage is the shape images had while their bytes were stored in Mongo.
+type legacyImage struct {
+	Id       uuid.UUID `bson:"id,omitempty"`
+	Data     []byte    `bson:"data"`
+	MimeType string    `bson:"mimeType"`
+	Filename string    `bson:"filename"`
+}
+
+// MigrateImages moves key point and review image bytes into the blob store,
+// leaving only references behind. Images go through the same processing as
+// new uploads, so they also get variants and lose their metadata. Documents already migrated are skipped, so
+// the migration can be rerun after a failure.
+func MigrateImages(ctx context.Context, keyPoints, ratings *mongo.Collection, images *service.ImageService) error {
+	keyPointCount, err := migrateKeyPointImages(ctx, keyPoints, images)
+	if err != nil {
+		return err
+	}
+	reviewCount, err := migrateReviewImages(ctx, ratings, images)
+	if err != nil {
+		return err
+	}
+	log.Printf("Moved %d key point images and %d review images to the blob store", keyPointCount, reviewCount)
+	return nil
+}
+
+func migrateKeyPointImages(ctx context.Context, collection *mongo.Collection, images *service.ImageService) (int, error) {
+	cursor, err := collection.Find(ctx, bson.M{"image.data": bson.M{"$exists": true}})
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	moved := 0
+	for cursor.Next(ctx) {
+		var doc struct {
+			Id    bson.RawValue `bson:"_id"`
+			Image legacyImage   `bson:"image"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			return moved, err
+		}
+
+		image, err := store(ctx, images, "keypoints", doc.Image)
+		if err != nil {
+			return moved, fmt.Errorf("key point %v: %w", doc.Id, err)
+		}
+		update := bson.M{"$set": bson.M{"image": image}}
+		if image.IsEmpty() {
+			update = bson.M{"$unset": bson.M{"image": ""}}
+		}
+		if _, err := collection.UpdateOne(ctx, bson.M{"_id": doc.Id}, update); err != nil {
+			images.Delete(image)
+			return moved, err
+		}
+		moved++
+	}
+	return moved, cursor.Err()
+}
+
+func migrateReviewImages(ctx context.Context, collection *mongo.Collection, images *service.ImageService) (int, error) {
+	cursor, err := collection.Find(ctx, bson.M{"images.data": bson.M{"$exists": true}})
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	moved := 0
+	for cursor.Next(ctx) {
+		var doc struct {
+			Id     uuid.UUID     `bson:"_id"`
+			Images []legacyImage `bson:"images"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			return moved, err
+		}
+
+		prefix := "reviews/" + doc.Id.String()
+		migrated := make([]model.ReviewImage, 0, len(doc.Images))
+		for _, legacy := range doc.Images {
+			image, err := store(ctx, images, prefix, legacy)
+			if err != nil {
+				return moved, fmt.Errorf("review %v: %w", doc.Id, err)
+			}
+			if !image.IsEmpty() {
+				migrated = append(migrated, model.ReviewImage{Id: legacy.Id, Image: image})
+			}
+		}
+		if _, err := collection.UpdateOne(ctx, bson.M{"_id": doc.Id}, bson.M{"$set": bson.M{"images": migrated}}); err != nil {
+			for _, image := range migrated {
+				images.Delete(image.Image)
+			}
+			return moved, err
+		}
+		moved += len(migrated)
+	}
+	return moved, cursor.Err()
+}
+
+// store writes one legacy image to the blob store; images without bytes are dropped.
+func store(ctx context.Context, images *service.ImageService, prefix string, legacy legacyImage) (model.Image, error) {
+	if len(legacy.Data) == 0 {
+		return model.Image{}, nil
+	}
+	return images.Save(ctx, prefix, legacy.Filename, bytes.NewReader(legacy.Data))
+}
@@ -1,7 +1,62 @@
 package model
 
+// Image references an uploaded picture kept in the blob store; only the key
+// and metadata live in the database.
 type Image struct {
-	Data     []byte `json:"data" bson:"data, omitempty"`
-	MimeType string `json:"mimeType" bson:"mimeType, omitempty"`
-	Filename string `json:"filename" bson:"filename, omitempty"`
+	Key      string `json:"-" bson:"key,omitempty"`
+	MimeType string `json:"mimeType" bson:"mimeType,omitempty"`
+	Filename string `json:"filename" bson:"filename,omitempty"`
+	Size     int64  `json:"size" bson:"size,omitempty"`
+	Checksum string `json:"checksum" bson:"checksum,omitempty"`
+	Url      string `json:"url,omitempty" bson:"-"`
+	// Variants holds the smaller renditions by size name ("thumb", "medium").
+	Variants map[string]ImageVariant `json:"-" bson:"variants,omitempty"`
+}
+
+// ImageVariant is one stored rendition of an image.
+type ImageVariant struct {
+	Key      string `bson:"key"`
+	MimeType string `bson:"mimeType"`
+	Size     int64  `bson:"size"`
+	Checksum string `bson:"checksum,omitempty"`
+}
+
+func (image Image) IsEmpty() bool {
+	return image.Key == ""
+}
+
+// Variant returns the rendition stored for size, falling back to the
+// original when the image is too small to have one or predates variants.
+func (image Image) Variant(size string) ImageVariant {
+	if variant, ok := image.Variants[size]; ok {
+		return variant
+	}
+	return ImageVariant{Key: image.Key, MimeType: image.MimeType, Size: image.Size, Checksum: image.Checksum}
+}
+
+// Version identifies the image content. Stored images never change in place,
+// so a URL carrying the version can be cached for good.
+func (image Image) Version() string {
+	if len(image.Checksum) < 16 {
+		return ""
+	}
+	return image.Checksum[:16]
+}
+
+// VersionedUrl adds the image version to the path the image is served from.
+func (image Image) VersionedUrl(path string) string {
+	if version := image.Version(); version != "" {
+		return path + "?v=" + version
+	}
+	return path
+}
+
+// ETag is the entity tag of the rendition, derived from its content hash.
+// Renditions stored without a checksum fall back to a weak tag on the key,
+// which is unique per upload.
+func (variant ImageVariant) ETag() string {
+	if variant.Checksum != "" {
+		return `"` + variant.Checksum + `"`
+	}
+	return `W/"` + variant.Key + `"`
 }
@@ -1,6 +1,9 @@
 package model
 
 import (
+	"encoding/json"
+	"fmt"
+	"math"
 	"time"
 
 	"github.com/google/uuid"
@@ -13,7 +16,31 @@ type KeyPoint struct {
 	Title       string      `json:"title" bson:"title"`
 	Description string      `json:"description" bson:"description"`
 	Image       Image       `json:"image" bson:"image"`
+	Order       int         `json:"order" bson:"order"`
 	CreatedAt   time.Time   `json:"createdAt" bson:"createdAt"`
+	// Location mirrors Coordinates as GeoJSON for the 2dsphere index.
+	Location GeoPoint `json:"-" bson:"location"`
+}
+
+// ImageUrl is where the key point's image is served, or empty without one.
+func (keyPoint KeyPoint) ImageUrl() string {
+	if keyPoint.Image.IsEmpty() {
+		return ""
+	}
+	return keyPoint.Image.VersionedUrl(fmt.Sprintf("/keyPoints/%s/image", keyPoint.Id))
+}
+
+// MarshalJSON fills in the image URL, since the image itself is not part of the document.
+func (keyPoint KeyPoint) MarshalJSON() ([]byte, error) {
+	type plain KeyPoint
+	keyPoint.Image.Url = keyPoint.ImageUrl()
+	return json.Marshal(plain(keyPoint))
+}
+
+// GeoPoint is a GeoJSON point; Coordinates holds longitude first, then latitude.
+type GeoPoint struct {
+	Type        string    `json:"type" bson:"type"`
+	Coordinates []float64 `json:"coordinates" bson:"coordinates"`
 }
 
 type Coordinates struct {
@@ -21,6 +48,38 @@ type Coordinates struct {
 	Longitude float64 `json:"longitude" bson:"longitude"`
 }
 
+const earthRadiusKm = 6371.0
+
+// GeoPoint converts the coordinates to GeoJSON.
+func (c Coordinates) GeoPoint() GeoPoint {
+	return GeoPoint{Type: "Point", Coordinates: []float64{c.Longitude, c.Latitude}}
+}
+
+// Valid reports whether the coordinates are within the WGS84 range.
+func (c Coordinates) Valid() bool {
+	return c.Latitude >= -90 && c.Latitude <= 90 && c.Longitude >= -180 && c.Longitude <= 180
+}
+
+// DistanceTo returns the great-circle (haversine) distance to other in kilometres.
+func (c Coordinates) DistanceTo(other Coordinates) float64 {
+	lat1 := c.Latitude * math.Pi / 180
+	lat2 := other.Latitude * math.Pi / 180
+	dLat := lat2 - lat1
+	dLng := (other.Longitude - c.Longitude) * math.Pi / 180
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// RouteDistance is the length in kilometres of the route visiting keyPoints in the given order.
+func RouteDistance(keyPoints []KeyPoint) float64 {
+	total := 0.0
+	for i := 1; i < len(keyPoints); i++ {
+		total += keyPoints[i-1].Coordinates.DistanceTo(keyPoints[i].Coordinates)
+	}
+	return total
+}
+
 func BeforeCreateKeyPoint(tourId uuid.UUID, coordinates Coordinates, title string, description string, image Image) *KeyPoint {
 	return &KeyPoint{
 		Id:          uuid.New(),
@@ -30,5 +89,6 @@ func BeforeCreateKeyPoint(tourId uuid.UUID, coordinates Coordinates, title strin
 		Description: description,
 		Image:       image,
 		CreatedAt:   time.Now(),
+		Location:    coordinates.GeoPoint(),
 	}
 }
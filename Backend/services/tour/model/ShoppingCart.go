@@ -0,0 +1,49 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ShoppingCart struct {
+	TouristId string      `json:"touristId" bson:"_id"`
+	Items     []OrderItem `json:"items" bson:"items"`
+	Total     float64     `json:"total" bson:"total"`
+	UpdatedAt time.Time   `json:"updatedAt" bson:"updatedAt"`
+}
+
+type OrderItem struct {
+	TourId   uuid.UUID `json:"tourId" bson:"tourId"`
+	TourName string    `json:"tourName" bson:"tourName"`
+	Price    float64   `json:"price" bson:"price"`
+}
+
+func NewShoppingCart(touristId string) *ShoppingCart {
+	return &ShoppingCart{
+		TouristId: touristId,
+		Items:     []OrderItem{},
+		Total:     0,
+		UpdatedAt: time.Now().UTC(),
+	}
+}
+
+// Contains reports whether tourId is already in the cart.
+func (c *ShoppingCart) Contains(tourId uuid.UUID) bool {
+	for _, item := range c.Items {
+		if item.TourId == tourId {
+			return true
+		}
+	}
+	return false
+}
+
+// RecalculateTotal keeps Total in sync with Items after every change.
+func (c *ShoppingCart) RecalculateTotal() {
+	total := 0.0
+	for _, item := range c.Items {
+		total += item.Price
+	}
+	c.Total = total
+	c.UpdatedAt = time.Now().UTC()
+}
@@ -7,19 +7,57 @@ import (
 )
 
 type Tour struct {
-	Id            uuid.UUID      `json:"id" bson:"_id,omitempty"`
-	AuthorId      string         `json:"authorId" bson:"authorId"`
-	Title         string         `json:"title" bson:"title"`
-	Description   string         `json:"description" bson:"description"`
-	Difficulty    TourDifficulty `json:"difficulty" bson:"difficulty"`
-	Tags          []string       `json:"tags" bson:"tags"`
-	Status        TourStatus     `json:"status" bson:"status"`
-	Price         float64        `json:"price" bson:"price"`
-	Distance      float64        `json:"distance" bson:"distance"`
-	PublishedAt   time.Time      `json:"publishedAt" bson:"publishedAt"`
-	ArchivedAt    time.Time      `json:"archivedAt" bson:"archivedAt"`
-	Duration      float64        `json:"duration" bson:"duration"`
-	TransportType TransportType  `json:"transportType" bson:"transportType"`
+	Id          uuid.UUID      `json:"id" bson:"_id,omitempty"`
+	AuthorId    string         `json:"authorId" bson:"authorId"`
+	Title       string         `json:"title" bson:"title"`
+	Description string         `json:"description" bson:"description"`
+	Difficulty  TourDifficulty `json:"difficulty" bson:"difficulty"`
+	Tags        []string       `json:"tags" bson:"tags"`
+	Status      TourStatus     `json:"status" bson:"status"`
+	Price       float64        `json:"price" bson:"price"`
+	Distance    float64        `json:"distance" bson:"distance"`
+	PublishedAt time.Time      `json:"publishedAt" bson:"publishedAt"`
+	ArchivedAt  time.Time      `json:"archivedAt" bson:"archivedAt"`
+	// Durations lists how long the tour takes with each supported means of transport.
+	Durations   []TransportDuration `json:"durations" bson:"durations"`
+	RatingStats RatingStats         `json:"ratingStats" bson:"ratingStats"`
+	// Revision counts the published versions of the tour; 0 until first published.
+	Revision int `json:"revision" bson:"revision"`
+	// RevisionOf is set on a draft revision of a published tour and holds the
+	// id of the live tour it will replace once published.
+	RevisionOf *uuid.UUID `json:"revisionOf,omitempty" bson:"revisionOf,omitempty"`
+}
+
+// IsDraftRevision reports whether the tour is a pending edit of a published tour.
+func (tour Tour) IsDraftRevision() bool {
+	return tour.RevisionOf != nil
+}
+
+// TourRevision is a snapshot of a published version of a tour. Executions
+// started on a revision keep following its key points after the tour is
+// republished.
+type TourRevision struct {
+	Id          uuid.UUID  `json:"id" bson:"_id"`
+	TourId      uuid.UUID  `json:"tourId" bson:"tourId"`
+	Revision    int        `json:"revision" bson:"revision"`
+	Tour        Tour       `json:"tour" bson:"tour"`
+	KeyPoints   []KeyPoint `json:"keyPoints" bson:"keyPoints"`
+	PublishedAt time.Time  `json:"publishedAt" bson:"publishedAt"`
+}
+
+// TourPage is one page of a tour listing.
+type TourPage struct {
+	Items    []Tour `json:"items"`
+	Page     int    `json:"page"`
+	PageSize int    `json:"pageSize"`
+	Total    int64  `json:"total"`
+}
+
+// NearbyTour is a tour found by a location search, with the distance from the
+// search point to its matching key point.
+type NearbyTour struct {
+	Tour
+	DistanceKm float64 `json:"distanceKm"`
 }
 
 type TourStatus int
@@ -47,20 +85,30 @@ const (
 	Bus
 )
 
+// Valid reports whether t is one of the known transport types.
+func (t TransportType) Valid() bool {
+	return t >= Walking && t <= Bus
+}
+
+// TransportDuration is how many minutes the tour takes with one means of transport.
+type TransportDuration struct {
+	TransportType TransportType `json:"transportType" bson:"transportType"`
+	Minutes       float64       `json:"minutes" bson:"minutes"`
+}
+
 func BeforeCreateTour(authorId string, title string, description string, tags []string, difficulty TourDifficulty) *Tour {
 	return &Tour{
-		Id:            uuid.New(),
-		AuthorId:      authorId,
-		Title:         title,
-		Description:   description,
-		Difficulty:    difficulty,
-		Tags:          tags,
-		Status:        Draft,
-		Price:         0,
-		Distance:      0,
-		PublishedAt:   time.Time{},
-		ArchivedAt:    time.Time{},
-		Duration:      0,
-		TransportType: 0,
+		Id:          uuid.New(),
+		AuthorId:    authorId,
+		Title:       title,
+		Description: description,
+		Difficulty:  difficulty,
+		Tags:        tags,
+		Status:      Draft,
+		Price:       0,
+		Distance:    0,
+		PublishedAt: time.Time{},
+		ArchivedAt:  time.Time{},
+		Durations:   []TransportDuration{},
 	}
 }
@@ -0,0 +1,59 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type TourExecution struct {
+	Id                 uuid.UUID           `json:"id" bson:"_id"`
+	TourId             uuid.UUID           `json:"tourId" bson:"tourId"`
+	Revision           int                 `json:"revision" bson:"revision"` // tour revision the execution started on
+	TouristId          string              `json:"touristId" bson:"touristId"`
+	Status             TourExecutionStatus `json:"status" bson:"status"`
+	StartLocation      Coordinates         `json:"startLocation" bson:"startLocation"`
+	CompletedKeyPoints []CompletedKeyPoint `json:"completedKeyPoints" bson:"completedKeyPoints"`
+	StartedAt          time.Time           `json:"startedAt" bson:"startedAt"`
+	LastActivity       time.Time           `json:"lastActivity" bson:"lastActivity"`
+	EndedAt            time.Time           `json:"endedAt" bson:"endedAt"`
+}
+
+type CompletedKeyPoint struct {
+	KeyPointId  uuid.UUID `json:"keyPointId" bson:"keyPointId"`
+	CompletedAt time.Time `json:"completedAt" bson:"completedAt"`
+}
+
+type TourExecutionStatus int
+
+const (
+	Active TourExecutionStatus = iota
+	Completed
+	Abandoned
+)
+
+func BeforeStartTourExecution(tourId uuid.UUID, revision int, touristId string, startLocation Coordinates) *TourExecution {
+	now := time.Now().UTC()
+	return &TourExecution{
+		Id:                 uuid.New(),
+		TourId:             tourId,
+		Revision:           revision,
+		TouristId:          touristId,
+		Status:             Active,
+		StartLocation:      startLocation,
+		CompletedKeyPoints: []CompletedKeyPoint{},
+		StartedAt:          now,
+		LastActivity:       now,
+		EndedAt:            time.Time{},
+	}
+}
+
+// IsKeyPointCompleted reports whether the tourist has already reached keyPointId.
+func (e *TourExecution) IsKeyPointCompleted(keyPointId uuid.UUID) bool {
+	for _, completed := range e.CompletedKeyPoints {
+		if completed.KeyPointId == keyPointId {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,27 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TourPurchaseToken proves a tourist bought a tour; it unlocks the full key
+// point data and lets them start executing the tour.
+type TourPurchaseToken struct {
+	Id          uuid.UUID `json:"id" bson:"_id"`
+	TouristId   string    `json:"touristId" bson:"touristId"`
+	TourId      uuid.UUID `json:"tourId" bson:"tourId"`
+	Price       float64   `json:"price" bson:"price"`
+	PurchasedAt time.Time `json:"purchasedAt" bson:"purchasedAt"`
+}
+
+func BeforeCreateTourPurchaseToken(touristId string, item OrderItem, purchasedAt time.Time) *TourPurchaseToken {
+	return &TourPurchaseToken{
+		Id:          uuid.New(),
+		TouristId:   touristId,
+		TourId:      item.TourId,
+		Price:       item.Price,
+		PurchasedAt: purchasedAt,
+	}
+}
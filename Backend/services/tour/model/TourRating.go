@@ -7,13 +7,62 @@ import (
 )
 
 type TourRating struct {
-	Id           uuid.UUID `json:"id" bson:"_id,omitempty"`
-	TourId       uuid.UUID `json:"tourId" bson:"tourId"`
-	Rating       int       `json:"rating" bson:"rating"`
-	Comment      string    `json:"comment,omitempty" bson:"comment,omitempty"`
-	TouristName  string    `json:"touristName,omitempty" bson:"touristName,omitempty"`
-	TouristEmail string    `json:"touristEmail,omitempty" bson:"touristEmail,omitempty"`
-	VisitedAt    time.Time `json:"visitedAt,omitempty" bson:"visitedAt,omitempty"`
-	CommentedAt  time.Time `json:"commentedAt,omitempty" bson:"commentedAt,omitempty"`
-	CreatedAt    time.Time `json:"createdAt" bson:"createdAt"`
+	Id           uuid.UUID     `json:"id" bson:"_id,omitempty"`
+	TourId       uuid.UUID     `json:"tourId" bson:"tourId"`
+	TouristId    string        `json:"touristId,omitempty" bson:"touristId,omitempty"`
+	Rating       int           `json:"rating" bson:"rating"`
+	Comment      string        `json:"comment,omitempty" bson:"comment,omitempty"`
+	TouristName  string        `json:"touristName,omitempty" bson:"touristName,omitempty"`
+	TouristEmail string        `json:"touristEmail,omitempty" bson:"touristEmail,omitempty"`
+	VisitedAt    time.Time     `json:"visitedAt,omitempty" bson:"visitedAt,omitempty"`
+	CommentedAt  time.Time     `json:"commentedAt,omitempty" bson:"commentedAt,omitempty"`
+	CreatedAt    time.Time     `json:"createdAt" bson:"createdAt"`
+	UpdatedAt    time.Time     `json:"updatedAt,omitempty" bson:"updatedAt,omitempty"`
+	Images       []ReviewImage `json:"images" bson:"images,omitempty"`
+	Reply        *ReviewReply  `json:"reply,omitempty" bson:"reply,omitempty"`
+}
+
+// ReviewImage is a photo attached to a review. Listings leave Image empty and
+// point to it through Url instead.
+type ReviewImage struct {
+	Id    uuid.UUID `json:"id" bson:"id"`
+	Url   string    `json:"url" bson:"-"`
+	Image Image     `json:"-" bson:",inline"`
+}
+
+// ReviewReply is the tour author's public answer to a review.
+type ReviewReply struct {
+	AuthorId  string    `json:"authorId" bson:"authorId"`
+	Text      string    `json:"text" bson:"text"`
+	CreatedAt time.Time `json:"createdAt" bson:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt,omitempty" bson:"updatedAt,omitempty"`
+}
+
+const MaxReviewImages = 5
+
+const (
+	MinRating = 1
+	MaxRating = 5
+)
+
+// RatingStats summarises a tour's reviews. Histogram[i] counts the reviews
+// rated i+1.
+type RatingStats struct {
+	Average   float64        `json:"average" bson:"average"`
+	Count     int            `json:"count" bson:"count"`
+	Histogram [MaxRating]int `json:"histogram" bson:"histogram"`
+}
+
+// NewRatingStats builds the summary from a histogram.
+func NewRatingStats(histogram [MaxRating]int) RatingStats {
+	stats := RatingStats{Histogram: histogram}
+	sum := 0
+	for i, n := range histogram {
+		stats.Count += n
+		sum += n * (i + 1)
+	}
+	if stats.Count > 0 {
+		stats.Average = float64(sum) / float64(stats.Count)
+	}
+	return stats
 }
@@ -184,20 +184,24 @@ func (TransportType) EnumDescriptor() ([]byte, []int) {
 
 // Tour message based on the Go model
 type Tour struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
-	AuthorId      string                 `protobuf:"bytes,2,opt,name=author_id,json=authorId,proto3" json:"author_id,omitempty"`
-	Title         string                 `protobuf:"bytes,3,opt,name=title,proto3" json:"title,omitempty"`
-	Description   string                 `protobuf:"bytes,4,opt,name=description,proto3" json:"description,omitempty"`
-	Difficulty    TourDifficulty         `protobuf:"varint,5,opt,name=difficulty,proto3,enum=tour.TourDifficulty" json:"difficulty,omitempty"`
-	Tags          []string               `protobuf:"bytes,6,rep,name=tags,proto3" json:"tags,omitempty"`
-	Status        TourStatus             `protobuf:"varint,7,opt,name=status,proto3,enum=tour.TourStatus" json:"status,omitempty"`
-	Price         float64                `protobuf:"fixed64,8,opt,name=price,proto3" json:"price,omitempty"`
-	Distance      float64                `protobuf:"fixed64,9,opt,name=distance,proto3" json:"distance,omitempty"`
-	PublishedAt   *timestamppb.Timestamp `protobuf:"bytes,10,opt,name=published_at,json=publishedAt,proto3" json:"published_at,omitempty"`
-	ArchivedAt    *timestamppb.Timestamp `protobuf:"bytes,11,opt,name=archived_at,json=archivedAt,proto3" json:"archived_at,omitempty"`
-	Duration      float64                `protobuf:"fixed64,12,opt,name=duration,proto3" json:"duration,omitempty"`
-	TransportType TransportType          `protobuf:"varint,13,opt,name=transport_type,json=transportType,proto3,enum=tour.TransportType" json:"transport_type,omitempty"`
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Id          string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	AuthorId    string                 `protobuf:"bytes,2,opt,name=author_id,json=authorId,proto3" json:"author_id,omitempty"`
+	Title       string                 `protobuf:"bytes,3,opt,name=title,proto3" json:"title,omitempty"`
+	Description string                 `protobuf:"bytes,4,opt,name=description,proto3" json:"description,omitempty"`
+	Difficulty  TourDifficulty         `protobuf:"varint,5,opt,name=difficulty,proto3,enum=tour.TourDifficulty" json:"difficulty,omitempty"`
+	Tags        []string               `protobuf:"bytes,6,rep,name=tags,proto3" json:"tags,omitempty"`
+	Status      TourStatus             `protobuf:"varint,7,opt,name=status,proto3,enum=tour.TourStatus" json:"status,omitempty"`
+	Price       float64                `protobuf:"fixed64,8,opt,name=price,proto3" json:"price,omitempty"`
+	Distance    float64                `protobuf:"fixed64,9,opt,name=distance,proto3" json:"distance,omitempty"`
+	PublishedAt *timestamppb.Timestamp `protobuf:"bytes,10,opt,name=published_at,json=publishedAt,proto3" json:"published_at,omitempty"`
+	ArchivedAt  *timestamppb.Timestamp `protobuf:"bytes,11,opt,name=archived_at,json=archivedAt,proto3" json:"archived_at,omitempty"`
+	Durations   []*TransportDuration   `protobuf:"bytes,14,rep,name=durations,proto3" json:"durations,omitempty"`
+	RatingStats *RatingStats           `protobuf:"bytes,15,opt,name=rating_stats,json=ratingStats,proto3" json:"rating_stats,omitempty"`
+	// Published versions so far; 0 until first published.
+	Revision int32 `protobuf:"varint,16,opt,name=revision,proto3" json:"revision,omitempty"`
+	// Set on a draft revision of a published tour.
+	RevisionOf    string `protobuf:"bytes,17,opt,name=revision_of,json=revisionOf,proto3" json:"revision_of,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -309,20 +313,317 @@ func (x *Tour) GetArchivedAt() *timestamppb.Timestamp {
 	return nil
 }
 
-func (x *Tour) GetDuration() float64 {
+func (x *Tour) GetDurations() []*TransportDuration {
+	if x != nil {
+		return x.Durations
+	}
+	return nil
+}
+
+func (x *Tour) GetRatingStats() *RatingStats {
+	if x != nil {
+		return x.RatingStats
+	}
+	return nil
+}
+
+func (x *Tour) GetRevision() int32 {
+	if x != nil {
+		return x.Revision
+	}
+	return 0
+}
+
+func (x *Tour) GetRevisionOf() string {
+	if x != nil {
+		return x.RevisionOf
+	}
+	return ""
+}
+
+// Summary of a tour's reviews; histogram[i] counts reviews rated i + 1.
+type RatingStats struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Average       float64                `protobuf:"fixed64,1,opt,name=average,proto3" json:"average,omitempty"`
+	Count         int32                  `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+	Histogram     []int32                `protobuf:"varint,3,rep,packed,name=histogram,proto3" json:"histogram,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RatingStats) Reset() {
+	*x = RatingStats{}
+	mi := &file_proto_tour_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RatingStats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RatingStats) ProtoMessage() {}
+
+func (x *RatingStats) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_tour_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RatingStats.ProtoReflect.Descriptor instead.
+func (*RatingStats) Descriptor() ([]byte, []int) {
+	return file_proto_tour_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *RatingStats) GetAverage() float64 {
+	if x != nil {
+		return x.Average
+	}
+	return 0
+}
+
+func (x *RatingStats) GetCount() int32 {
 	if x != nil {
-		return x.Duration
+		return x.Count
 	}
 	return 0
 }
 
-func (x *Tour) GetTransportType() TransportType {
+func (x *RatingStats) GetHistogram() []int32 {
+	if x != nil {
+		return x.Histogram
+	}
+	return nil
+}
+
+// How long a tour takes with one means of transport.
+type TransportDuration struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TransportType TransportType          `protobuf:"varint,1,opt,name=transport_type,json=transportType,proto3,enum=tour.TransportType" json:"transport_type,omitempty"`
+	Minutes       float64                `protobuf:"fixed64,2,opt,name=minutes,proto3" json:"minutes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TransportDuration) Reset() {
+	*x = TransportDuration{}
+	mi := &file_proto_tour_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TransportDuration) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TransportDuration) ProtoMessage() {}
+
+func (x *TransportDuration) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_tour_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TransportDuration.ProtoReflect.Descriptor instead.
+func (*TransportDuration) Descriptor() ([]byte, []int) {
+	return file_proto_tour_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *TransportDuration) GetTransportType() TransportType {
 	if x != nil {
 		return x.TransportType
 	}
 	return TransportType_TRANSPORT_TYPE_UNSPECIFIED
 }
 
+func (x *TransportDuration) GetMinutes() float64 {
+	if x != nil {
+		return x.Minutes
+	}
+	return 0
+}
+
+// Key point of a tour. Image bytes are never listed; fetch them from image_url.
+type KeyPoint struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	TourId        string                 `protobuf:"bytes,2,opt,name=tour_id,json=tourId,proto3" json:"tour_id,omitempty"`
+	Latitude      float64                `protobuf:"fixed64,3,opt,name=latitude,proto3" json:"latitude,omitempty"`
+	Longitude     float64                `protobuf:"fixed64,4,opt,name=longitude,proto3" json:"longitude,omitempty"`
+	Title         string                 `protobuf:"bytes,5,opt,name=title,proto3" json:"title,omitempty"`
+	Description   string                 `protobuf:"bytes,6,opt,name=description,proto3" json:"description,omitempty"`
+	Order         int32                  `protobuf:"varint,7,opt,name=order,proto3" json:"order,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	ImageUrl      string                 `protobuf:"bytes,9,opt,name=image_url,json=imageUrl,proto3" json:"image_url,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KeyPoint) Reset() {
+	*x = KeyPoint{}
+	mi := &file_proto_tour_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KeyPoint) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KeyPoint) ProtoMessage() {}
+
+func (x *KeyPoint) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_tour_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KeyPoint.ProtoReflect.Descriptor instead.
+func (*KeyPoint) Descriptor() ([]byte, []int) {
+	return file_proto_tour_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *KeyPoint) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *KeyPoint) GetTourId() string {
+	if x != nil {
+		return x.TourId
+	}
+	return ""
+}
+
+func (x *KeyPoint) GetLatitude() float64 {
+	if x != nil {
+		return x.Latitude
+	}
+	return 0
+}
+
+func (x *KeyPoint) GetLongitude() float64 {
+	if x != nil {
+		return x.Longitude
+	}
+	return 0
+}
+
+func (x *KeyPoint) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *KeyPoint) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *KeyPoint) GetOrder() int32 {
+	if x != nil {
+		return x.Order
+	}
+	return 0
+}
+
+func (x *KeyPoint) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *KeyPoint) GetImageUrl() string {
+	if x != nil {
+		return x.ImageUrl
+	}
+	return ""
+}
+
+type Image struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Data          []byte                 `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	MimeType      string                 `protobuf:"bytes,2,opt,name=mime_type,json=mimeType,proto3" json:"mime_type,omitempty"`
+	Filename      string                 `protobuf:"bytes,3,opt,name=filename,proto3" json:"filename,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Image) Reset() {
+	*x = Image{}
+	mi := &file_proto_tour_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Image) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Image) ProtoMessage() {}
+
+func (x *Image) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_tour_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Image.ProtoReflect.Descriptor instead.
+func (*Image) Descriptor() ([]byte, []int) {
+	return file_proto_tour_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Image) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *Image) GetMimeType() string {
+	if x != nil {
+		return x.MimeType
+	}
+	return ""
+}
+
+func (x *Image) GetFilename() string {
+	if x != nil {
+		return x.Filename
+	}
+	return ""
+}
+
 // Request and Response messages
 type CreateTourRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -331,14 +632,14 @@ type CreateTourRequest struct {
 	Description   string                 `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
 	Difficulty    TourDifficulty         `protobuf:"varint,4,opt,name=difficulty,proto3,enum=tour.TourDifficulty" json:"difficulty,omitempty"`
 	Tags          []string               `protobuf:"bytes,5,rep,name=tags,proto3" json:"tags,omitempty"`
-	TransportType TransportType          `protobuf:"varint,6,opt,name=transport_type,json=transportType,proto3,enum=tour.TransportType" json:"transport_type,omitempty"`
+	Durations     []*TransportDuration   `protobuf:"bytes,7,rep,name=durations,proto3" json:"durations,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *CreateTourRequest) Reset() {
 	*x = CreateTourRequest{}
-	mi := &file_proto_tour_proto_msgTypes[1]
+	mi := &file_proto_tour_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -350,7 +651,7 @@ func (x *CreateTourRequest) String() string {
 func (*CreateTourRequest) ProtoMessage() {}
 
 func (x *CreateTourRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_tour_proto_msgTypes[1]
+	mi := &file_proto_tour_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -363,7 +664,7 @@ func (x *CreateTourRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CreateTourRequest.ProtoReflect.Descriptor instead.
 func (*CreateTourRequest) Descriptor() ([]byte, []int) {
-	return file_proto_tour_proto_rawDescGZIP(), []int{1}
+	return file_proto_tour_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *CreateTourRequest) GetAuthorId() string {
@@ -401,11 +702,11 @@ func (x *CreateTourRequest) GetTags() []string {
 	return nil
 }
 
-func (x *CreateTourRequest) GetTransportType() TransportType {
+func (x *CreateTourRequest) GetDurations() []*TransportDuration {
 	if x != nil {
-		return x.TransportType
+		return x.Durations
 	}
-	return TransportType_TRANSPORT_TYPE_UNSPECIFIED
+	return nil
 }
 
 type CreateTourResponse struct {
@@ -418,7 +719,7 @@ type CreateTourResponse struct {
 
 func (x *CreateTourResponse) Reset() {
 	*x = CreateTourResponse{}
-	mi := &file_proto_tour_proto_msgTypes[2]
+	mi := &file_proto_tour_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -430,7 +731,7 @@ func (x *CreateTourResponse) String() string {
 func (*CreateTourResponse) ProtoMessage() {}
 
 func (x *CreateTourResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_tour_proto_msgTypes[2]
+	mi := &file_proto_tour_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -443,7 +744,7 @@ func (x *CreateTourResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CreateTourResponse.ProtoReflect.Descriptor instead.
 func (*CreateTourResponse) Descriptor() ([]byte, []int) {
-	return file_proto_tour_proto_rawDescGZIP(), []int{2}
+	return file_proto_tour_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *CreateTourResponse) GetTour() *Tour {
@@ -469,7 +770,7 @@ type DeleteTourRequest struct {
 
 func (x *DeleteTourRequest) Reset() {
 	*x = DeleteTourRequest{}
-	mi := &file_proto_tour_proto_msgTypes[3]
+	mi := &file_proto_tour_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -481,7 +782,7 @@ func (x *DeleteTourRequest) String() string {
 func (*DeleteTourRequest) ProtoMessage() {}
 
 func (x *DeleteTourRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_tour_proto_msgTypes[3]
+	mi := &file_proto_tour_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -494,7 +795,7 @@ func (x *DeleteTourRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DeleteTourRequest.ProtoReflect.Descriptor instead.
 func (*DeleteTourRequest) Descriptor() ([]byte, []int) {
-	return file_proto_tour_proto_rawDescGZIP(), []int{3}
+	return file_proto_tour_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *DeleteTourRequest) GetId() string {
@@ -514,7 +815,7 @@ type DeleteTourResponse struct {
 
 func (x *DeleteTourResponse) Reset() {
 	*x = DeleteTourResponse{}
-	mi := &file_proto_tour_proto_msgTypes[4]
+	mi := &file_proto_tour_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -526,7 +827,7 @@ func (x *DeleteTourResponse) String() string {
 func (*DeleteTourResponse) ProtoMessage() {}
 
 func (x *DeleteTourResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_tour_proto_msgTypes[4]
+	mi := &file_proto_tour_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -539,7 +840,7 @@ func (x *DeleteTourResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DeleteTourResponse.ProtoReflect.Descriptor instead.
 func (*DeleteTourResponse) Descriptor() ([]byte, []int) {
-	return file_proto_tour_proto_rawDescGZIP(), []int{4}
+	return file_proto_tour_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *DeleteTourResponse) GetMessage() string {
@@ -556,38 +857,963 @@ func (x *DeleteTourResponse) GetSuccess() bool {
 	return false
 }
 
-var File_proto_tour_proto protoreflect.FileDescriptor
+type GetTourRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
 
-const file_proto_tour_proto_rawDesc = "" +
-	"\n" +
-	"\x10proto/tour.proto\x12\x04tour\x1a\x1fgoogle/protobuf/timestamp.proto\"\xe5\x03\n" +
-	"\x04Tour\x12\x0e\n" +
-	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1b\n" +
-	"\tauthor_id\x18\x02 \x01(\tR\bauthorId\x12\x14\n" +
-	"\x05title\x18\x03 \x01(\tR\x05title\x12 \n" +
-	"\vdescription\x18\x04 \x01(\tR\vdescription\x124\n" +
-	"\n" +
-	"difficulty\x18\x05 \x01(\x0e2\x14.tour.TourDifficultyR\n" +
-	"difficulty\x12\x12\n" +
-	"\x04tags\x18\x06 \x03(\tR\x04tags\x12(\n" +
-	"\x06status\x18\a \x01(\x0e2\x10.tour.TourStatusR\x06status\x12\x14\n" +
-	"\x05price\x18\b \x01(\x01R\x05price\x12\x1a\n" +
-	"\bdistance\x18\t \x01(\x01R\bdistance\x12=\n" +
-	"\fpublished_at\x18\n" +
-	" \x01(\v2\x1a.google.protobuf.TimestampR\vpublishedAt\x12;\n" +
-	"\varchived_at\x18\v \x01(\v2\x1a.google.protobuf.TimestampR\n" +
-	"archivedAt\x12\x1a\n" +
-	"\bduration\x18\f \x01(\x01R\bduration\x12:\n" +
-	"\x0etransport_type\x18\r \x01(\x0e2\x13.tour.TransportTypeR\rtransportType\"\xee\x01\n" +
-	"\x11CreateTourRequest\x12\x1b\n" +
-	"\tauthor_id\x18\x01 \x01(\tR\bauthorId\x12\x14\n" +
-	"\x05title\x18\x02 \x01(\tR\x05title\x12 \n" +
-	"\vdescription\x18\x03 \x01(\tR\vdescription\x124\n" +
-	"\n" +
+func (x *GetTourRequest) Reset() {
+	*x = GetTourRequest{}
+	mi := &file_proto_tour_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetTourRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetTourRequest) ProtoMessage() {}
+
+func (x *GetTourRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_tour_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetTourRequest.ProtoReflect.Descriptor instead.
+func (*GetTourRequest) Descriptor() ([]byte, []int) {
+	return file_proto_tour_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *GetTourRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type GetTourResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Tour          *Tour                  `protobuf:"bytes,1,opt,name=tour,proto3" json:"tour,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetTourResponse) Reset() {
+	*x = GetTourResponse{}
+	mi := &file_proto_tour_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetTourResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetTourResponse) ProtoMessage() {}
+
+func (x *GetTourResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_tour_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetTourResponse.ProtoReflect.Descriptor instead.
+func (*GetTourResponse) Descriptor() ([]byte, []int) {
+	return file_proto_tour_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *GetTourResponse) GetTour() *Tour {
+	if x != nil {
+		return x.Tour
+	}
+	return nil
+}
+
+// Filters left at their zero value (UNSPECIFIED / empty) are not applied.
+type ListToursRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Page          int32                  `protobuf:"varint,1,opt,name=page,proto3" json:"page,omitempty"`
+	PageSize      int32                  `protobuf:"varint,2,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	Status        TourStatus             `protobuf:"varint,3,opt,name=status,proto3,enum=tour.TourStatus" json:"status,omitempty"`
+	Difficulty    TourDifficulty         `protobuf:"varint,4,opt,name=difficulty,proto3,enum=tour.TourDifficulty" json:"difficulty,omitempty"`
+	Tag           string                 `protobuf:"bytes,5,opt,name=tag,proto3" json:"tag,omitempty"`
+	AuthorId      string                 `protobuf:"bytes,6,opt,name=author_id,json=authorId,proto3" json:"author_id,omitempty"`
+	TransportType TransportType          `protobuf:"varint,7,opt,name=transport_type,json=transportType,proto3,enum=tour.TransportType" json:"transport_type,omitempty"`
+	MinPrice      *float64               `protobuf:"fixed64,8,opt,name=min_price,json=minPrice,proto3,oneof" json:"min_price,omitempty"`
+	MaxPrice      *float64               `protobuf:"fixed64,9,opt,name=max_price,json=maxPrice,proto3,oneof" json:"max_price,omitempty"`
+	// One of publishedAt, price, distance or rating; empty keeps the default order.
+	SortBy     string `protobuf:"bytes,10,opt,name=sort_by,json=sortBy,proto3" json:"sort_by,omitempty"`
+	Descending bool   `protobuf:"varint,11,opt,name=descending,proto3" json:"descending,omitempty"`
+	// Longest acceptable duration in minutes, with transport_type if it is set.
+	MaxDuration   *float64 `protobuf:"fixed64,12,opt,name=max_duration,json=maxDuration,proto3,oneof" json:"max_duration,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListToursRequest) Reset() {
+	*x = ListToursRequest{}
+	mi := &file_proto_tour_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListToursRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListToursRequest) ProtoMessage() {}
+
+func (x *ListToursRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_tour_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListToursRequest.ProtoReflect.Descriptor instead.
+func (*ListToursRequest) Descriptor() ([]byte, []int) {
+	return file_proto_tour_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *ListToursRequest) GetPage() int32 {
+	if x != nil {
+		return x.Page
+	}
+	return 0
+}
+
+func (x *ListToursRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListToursRequest) GetStatus() TourStatus {
+	if x != nil {
+		return x.Status
+	}
+	return TourStatus_TOUR_STATUS_UNSPECIFIED
+}
+
+func (x *ListToursRequest) GetDifficulty() TourDifficulty {
+	if x != nil {
+		return x.Difficulty
+	}
+	return TourDifficulty_TOUR_DIFFICULTY_UNSPECIFIED
+}
+
+func (x *ListToursRequest) GetTag() string {
+	if x != nil {
+		return x.Tag
+	}
+	return ""
+}
+
+func (x *ListToursRequest) GetAuthorId() string {
+	if x != nil {
+		return x.AuthorId
+	}
+	return ""
+}
+
+func (x *ListToursRequest) GetTransportType() TransportType {
+	if x != nil {
+		return x.TransportType
+	}
+	return TransportType_TRANSPORT_TYPE_UNSPECIFIED
+}
+
+func (x *ListToursRequest) GetMinPrice() float64 {
+	if x != nil && x.MinPrice != nil {
+		return *x.MinPrice
+	}
+	return 0
+}
+
+func (x *ListToursRequest) GetMaxPrice() float64 {
+	if x != nil && x.MaxPrice != nil {
+		return *x.MaxPrice
+	}
+	return 0
+}
+
+func (x *ListToursRequest) GetSortBy() string {
+	if x != nil {
+		return x.SortBy
+	}
+	return ""
+}
+
+func (x *ListToursRequest) GetDescending() bool {
+	if x != nil {
+		return x.Descending
+	}
+	return false
+}
+
+func (x *ListToursRequest) GetMaxDuration() float64 {
+	if x != nil && x.MaxDuration != nil {
+		return *x.MaxDuration
+	}
+	return 0
+}
+
+type ListToursByAuthorRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AuthorId      string                 `protobuf:"bytes,1,opt,name=author_id,json=authorId,proto3" json:"author_id,omitempty"`
+	Page          int32                  `protobuf:"varint,2,opt,name=page,proto3" json:"page,omitempty"`
+	PageSize      int32                  `protobuf:"varint,3,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListToursByAuthorRequest) Reset() {
+	*x = ListToursByAuthorRequest{}
+	mi := &file_proto_tour_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListToursByAuthorRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListToursByAuthorRequest) ProtoMessage() {}
+
+func (x *ListToursByAuthorRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_tour_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListToursByAuthorRequest.ProtoReflect.Descriptor instead.
+func (*ListToursByAuthorRequest) Descriptor() ([]byte, []int) {
+	return file_proto_tour_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *ListToursByAuthorRequest) GetAuthorId() string {
+	if x != nil {
+		return x.AuthorId
+	}
+	return ""
+}
+
+func (x *ListToursByAuthorRequest) GetPage() int32 {
+	if x != nil {
+		return x.Page
+	}
+	return 0
+}
+
+func (x *ListToursByAuthorRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+type ListToursResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Tours         []*Tour                `protobuf:"bytes,1,rep,name=tours,proto3" json:"tours,omitempty"`
+	Page          int32                  `protobuf:"varint,2,opt,name=page,proto3" json:"page,omitempty"`
+	PageSize      int32                  `protobuf:"varint,3,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	Total         int64                  `protobuf:"varint,4,opt,name=total,proto3" json:"total,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListToursResponse) Reset() {
+	*x = ListToursResponse{}
+	mi := &file_proto_tour_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListToursResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListToursResponse) ProtoMessage() {}
+
+func (x *ListToursResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_tour_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListToursResponse.ProtoReflect.Descriptor instead.
+func (*ListToursResponse) Descriptor() ([]byte, []int) {
+	return file_proto_tour_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ListToursResponse) GetTours() []*Tour {
+	if x != nil {
+		return x.Tours
+	}
+	return nil
+}
+
+func (x *ListToursResponse) GetPage() int32 {
+	if x != nil {
+		return x.Page
+	}
+	return 0
+}
+
+func (x *ListToursResponse) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListToursResponse) GetTotal() int64 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+type UpdateTourRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Title         string                 `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	Description   string                 `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
+	Difficulty    TourDifficulty         `protobuf:"varint,4,opt,name=difficulty,proto3,enum=tour.TourDifficulty" json:"difficulty,omitempty"`
+	Tags          []string               `protobuf:"bytes,5,rep,name=tags,proto3" json:"tags,omitempty"`
+	Price         float64                `protobuf:"fixed64,6,opt,name=price,proto3" json:"price,omitempty"`
+	Durations     []*TransportDuration   `protobuf:"bytes,9,rep,name=durations,proto3" json:"durations,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateTourRequest) Reset() {
+	*x = UpdateTourRequest{}
+	mi := &file_proto_tour_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateTourRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateTourRequest) ProtoMessage() {}
+
+func (x *UpdateTourRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_tour_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateTourRequest.ProtoReflect.Descriptor instead.
+func (*UpdateTourRequest) Descriptor() ([]byte, []int) {
+	return file_proto_tour_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *UpdateTourRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *UpdateTourRequest) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *UpdateTourRequest) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *UpdateTourRequest) GetDifficulty() TourDifficulty {
+	if x != nil {
+		return x.Difficulty
+	}
+	return TourDifficulty_TOUR_DIFFICULTY_UNSPECIFIED
+}
+
+func (x *UpdateTourRequest) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *UpdateTourRequest) GetPrice() float64 {
+	if x != nil {
+		return x.Price
+	}
+	return 0
+}
+
+func (x *UpdateTourRequest) GetDurations() []*TransportDuration {
+	if x != nil {
+		return x.Durations
+	}
+	return nil
+}
+
+type UpdateTourResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Tour          *Tour                  `protobuf:"bytes,1,opt,name=tour,proto3" json:"tour,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateTourResponse) Reset() {
+	*x = UpdateTourResponse{}
+	mi := &file_proto_tour_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateTourResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateTourResponse) ProtoMessage() {}
+
+func (x *UpdateTourResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_tour_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateTourResponse.ProtoReflect.Descriptor instead.
+func (*UpdateTourResponse) Descriptor() ([]byte, []int) {
+	return file_proto_tour_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *UpdateTourResponse) GetTour() *Tour {
+	if x != nil {
+		return x.Tour
+	}
+	return nil
+}
+
+func (x *UpdateTourResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type ListKeyPointsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TourId        string                 `protobuf:"bytes,1,opt,name=tour_id,json=tourId,proto3" json:"tour_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListKeyPointsRequest) Reset() {
+	*x = ListKeyPointsRequest{}
+	mi := &file_proto_tour_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListKeyPointsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListKeyPointsRequest) ProtoMessage() {}
+
+func (x *ListKeyPointsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_tour_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListKeyPointsRequest.ProtoReflect.Descriptor instead.
+func (*ListKeyPointsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_tour_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *ListKeyPointsRequest) GetTourId() string {
+	if x != nil {
+		return x.TourId
+	}
+	return ""
+}
+
+type ListKeyPointsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	KeyPoints     []*KeyPoint            `protobuf:"bytes,1,rep,name=key_points,json=keyPoints,proto3" json:"key_points,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListKeyPointsResponse) Reset() {
+	*x = ListKeyPointsResponse{}
+	mi := &file_proto_tour_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListKeyPointsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListKeyPointsResponse) ProtoMessage() {}
+
+func (x *ListKeyPointsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_tour_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListKeyPointsResponse.ProtoReflect.Descriptor instead.
+func (*ListKeyPointsResponse) Descriptor() ([]byte, []int) {
+	return file_proto_tour_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *ListKeyPointsResponse) GetKeyPoints() []*KeyPoint {
+	if x != nil {
+		return x.KeyPoints
+	}
+	return nil
+}
+
+type CreateKeyPointRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TourId        string                 `protobuf:"bytes,1,opt,name=tour_id,json=tourId,proto3" json:"tour_id,omitempty"`
+	Latitude      float64                `protobuf:"fixed64,2,opt,name=latitude,proto3" json:"latitude,omitempty"`
+	Longitude     float64                `protobuf:"fixed64,3,opt,name=longitude,proto3" json:"longitude,omitempty"`
+	Title         string                 `protobuf:"bytes,4,opt,name=title,proto3" json:"title,omitempty"`
+	Description   string                 `protobuf:"bytes,5,opt,name=description,proto3" json:"description,omitempty"`
+	Image         *Image                 `protobuf:"bytes,6,opt,name=image,proto3" json:"image,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateKeyPointRequest) Reset() {
+	*x = CreateKeyPointRequest{}
+	mi := &file_proto_tour_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateKeyPointRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateKeyPointRequest) ProtoMessage() {}
+
+func (x *CreateKeyPointRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_tour_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateKeyPointRequest.ProtoReflect.Descriptor instead.
+func (*CreateKeyPointRequest) Descriptor() ([]byte, []int) {
+	return file_proto_tour_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *CreateKeyPointRequest) GetTourId() string {
+	if x != nil {
+		return x.TourId
+	}
+	return ""
+}
+
+func (x *CreateKeyPointRequest) GetLatitude() float64 {
+	if x != nil {
+		return x.Latitude
+	}
+	return 0
+}
+
+func (x *CreateKeyPointRequest) GetLongitude() float64 {
+	if x != nil {
+		return x.Longitude
+	}
+	return 0
+}
+
+func (x *CreateKeyPointRequest) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *CreateKeyPointRequest) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *CreateKeyPointRequest) GetImage() *Image {
+	if x != nil {
+		return x.Image
+	}
+	return nil
+}
+
+// Leaving image unset keeps the key point's current image.
+type UpdateKeyPointRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Latitude      float64                `protobuf:"fixed64,2,opt,name=latitude,proto3" json:"latitude,omitempty"`
+	Longitude     float64                `protobuf:"fixed64,3,opt,name=longitude,proto3" json:"longitude,omitempty"`
+	Title         string                 `protobuf:"bytes,4,opt,name=title,proto3" json:"title,omitempty"`
+	Description   string                 `protobuf:"bytes,5,opt,name=description,proto3" json:"description,omitempty"`
+	Image         *Image                 `protobuf:"bytes,6,opt,name=image,proto3" json:"image,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateKeyPointRequest) Reset() {
+	*x = UpdateKeyPointRequest{}
+	mi := &file_proto_tour_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateKeyPointRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateKeyPointRequest) ProtoMessage() {}
+
+func (x *UpdateKeyPointRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_tour_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateKeyPointRequest.ProtoReflect.Descriptor instead.
+func (*UpdateKeyPointRequest) Descriptor() ([]byte, []int) {
+	return file_proto_tour_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *UpdateKeyPointRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *UpdateKeyPointRequest) GetLatitude() float64 {
+	if x != nil {
+		return x.Latitude
+	}
+	return 0
+}
+
+func (x *UpdateKeyPointRequest) GetLongitude() float64 {
+	if x != nil {
+		return x.Longitude
+	}
+	return 0
+}
+
+func (x *UpdateKeyPointRequest) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *UpdateKeyPointRequest) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *UpdateKeyPointRequest) GetImage() *Image {
+	if x != nil {
+		return x.Image
+	}
+	return nil
+}
+
+type KeyPointResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	KeyPoint      *KeyPoint              `protobuf:"bytes,1,opt,name=key_point,json=keyPoint,proto3" json:"key_point,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KeyPointResponse) Reset() {
+	*x = KeyPointResponse{}
+	mi := &file_proto_tour_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KeyPointResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KeyPointResponse) ProtoMessage() {}
+
+func (x *KeyPointResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_tour_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KeyPointResponse.ProtoReflect.Descriptor instead.
+func (*KeyPointResponse) Descriptor() ([]byte, []int) {
+	return file_proto_tour_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *KeyPointResponse) GetKeyPoint() *KeyPoint {
+	if x != nil {
+		return x.KeyPoint
+	}
+	return nil
+}
+
+func (x *KeyPointResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type DeleteKeyPointRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteKeyPointRequest) Reset() {
+	*x = DeleteKeyPointRequest{}
+	mi := &file_proto_tour_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteKeyPointRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteKeyPointRequest) ProtoMessage() {}
+
+func (x *DeleteKeyPointRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_tour_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteKeyPointRequest.ProtoReflect.Descriptor instead.
+func (*DeleteKeyPointRequest) Descriptor() ([]byte, []int) {
+	return file_proto_tour_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *DeleteKeyPointRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type DeleteKeyPointResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Message       string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	Success       bool                   `protobuf:"varint,2,opt,name=success,proto3" json:"success,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteKeyPointResponse) Reset() {
+	*x = DeleteKeyPointResponse{}
+	mi := &file_proto_tour_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteKeyPointResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteKeyPointResponse) ProtoMessage() {}
+
+func (x *DeleteKeyPointResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_tour_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteKeyPointResponse.ProtoReflect.Descriptor instead.
+func (*DeleteKeyPointResponse) Descriptor() ([]byte, []int) {
+	return file_proto_tour_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *DeleteKeyPointResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *DeleteKeyPointResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+var File_proto_tour_proto protoreflect.FileDescriptor
+
+const file_proto_tour_proto_rawDesc = "" +
+	"\n" +
+	"\x10proto/tour.proto\x12\x04tour\x1a\x1fgoogle/protobuf/timestamp.proto\"\xdd\x04\n" +
+	"\x04Tour\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1b\n" +
+	"\tauthor_id\x18\x02 \x01(\tR\bauthorId\x12\x14\n" +
+	"\x05title\x18\x03 \x01(\tR\x05title\x12 \n" +
+	"\vdescription\x18\x04 \x01(\tR\vdescription\x124\n" +
+	"\n" +
+	"difficulty\x18\x05 \x01(\x0e2\x14.tour.TourDifficultyR\n" +
+	"difficulty\x12\x12\n" +
+	"\x04tags\x18\x06 \x03(\tR\x04tags\x12(\n" +
+	"\x06status\x18\a \x01(\x0e2\x10.tour.TourStatusR\x06status\x12\x14\n" +
+	"\x05price\x18\b \x01(\x01R\x05price\x12\x1a\n" +
+	"\bdistance\x18\t \x01(\x01R\bdistance\x12=\n" +
+	"\fpublished_at\x18\n" +
+	" \x01(\v2\x1a.google.protobuf.TimestampR\vpublishedAt\x12;\n" +
+	"\varchived_at\x18\v \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"archivedAt\x125\n" +
+	"\tdurations\x18\x0e \x03(\v2\x17.tour.TransportDurationR\tdurations\x124\n" +
+	"\frating_stats\x18\x0f \x01(\v2\x11.tour.RatingStatsR\vratingStats\x12\x1a\n" +
+	"\brevision\x18\x10 \x01(\x05R\brevision\x12\x1f\n" +
+	"\vrevision_of\x18\x11 \x01(\tR\n" +
+	"revisionOfJ\x04\b\f\x10\rJ\x04\b\r\x10\x0eR\bdurationR\x0etransport_type\"[\n" +
+	"\vRatingStats\x12\x18\n" +
+	"\aaverage\x18\x01 \x01(\x01R\aaverage\x12\x14\n" +
+	"\x05count\x18\x02 \x01(\x05R\x05count\x12\x1c\n" +
+	"\thistogram\x18\x03 \x03(\x05R\thistogram\"i\n" +
+	"\x11TransportDuration\x12:\n" +
+	"\x0etransport_type\x18\x01 \x01(\x0e2\x13.tour.TransportTypeR\rtransportType\x12\x18\n" +
+	"\aminutes\x18\x02 \x01(\x01R\aminutes\"\x93\x02\n" +
+	"\bKeyPoint\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x17\n" +
+	"\atour_id\x18\x02 \x01(\tR\x06tourId\x12\x1a\n" +
+	"\blatitude\x18\x03 \x01(\x01R\blatitude\x12\x1c\n" +
+	"\tlongitude\x18\x04 \x01(\x01R\tlongitude\x12\x14\n" +
+	"\x05title\x18\x05 \x01(\tR\x05title\x12 \n" +
+	"\vdescription\x18\x06 \x01(\tR\vdescription\x12\x14\n" +
+	"\x05order\x18\a \x01(\x05R\x05order\x129\n" +
+	"\n" +
+	"created_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x12\x1b\n" +
+	"\timage_url\x18\t \x01(\tR\bimageUrl\"T\n" +
+	"\x05Image\x12\x12\n" +
+	"\x04data\x18\x01 \x01(\fR\x04data\x12\x1b\n" +
+	"\tmime_type\x18\x02 \x01(\tR\bmimeType\x12\x1a\n" +
+	"\bfilename\x18\x03 \x01(\tR\bfilename\"\xff\x01\n" +
+	"\x11CreateTourRequest\x12\x1b\n" +
+	"\tauthor_id\x18\x01 \x01(\tR\bauthorId\x12\x14\n" +
+	"\x05title\x18\x02 \x01(\tR\x05title\x12 \n" +
+	"\vdescription\x18\x03 \x01(\tR\vdescription\x124\n" +
+	"\n" +
 	"difficulty\x18\x04 \x01(\x0e2\x14.tour.TourDifficultyR\n" +
 	"difficulty\x12\x12\n" +
-	"\x04tags\x18\x05 \x03(\tR\x04tags\x12:\n" +
-	"\x0etransport_type\x18\x06 \x01(\x0e2\x13.tour.TransportTypeR\rtransportType\"N\n" +
+	"\x04tags\x18\x05 \x03(\tR\x04tags\x125\n" +
+	"\tdurations\x18\a \x03(\v2\x17.tour.TransportDurationR\tdurationsJ\x04\b\x06\x10\aR\x0etransport_type\"N\n" +
 	"\x12CreateTourResponse\x12\x1e\n" +
 	"\x04tour\x18\x01 \x01(\v2\n" +
 	".tour.TourR\x04tour\x12\x18\n" +
@@ -596,6 +1822,85 @@ const file_proto_tour_proto_rawDesc = "" +
 	"\x02id\x18\x01 \x01(\tR\x02id\"H\n" +
 	"\x12DeleteTourResponse\x12\x18\n" +
 	"\amessage\x18\x01 \x01(\tR\amessage\x12\x18\n" +
+	"\asuccess\x18\x02 \x01(\bR\asuccess\" \n" +
+	"\x0eGetTourRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"1\n" +
+	"\x0fGetTourResponse\x12\x1e\n" +
+	"\x04tour\x18\x01 \x01(\v2\n" +
+	".tour.TourR\x04tour\"\xe0\x03\n" +
+	"\x10ListToursRequest\x12\x12\n" +
+	"\x04page\x18\x01 \x01(\x05R\x04page\x12\x1b\n" +
+	"\tpage_size\x18\x02 \x01(\x05R\bpageSize\x12(\n" +
+	"\x06status\x18\x03 \x01(\x0e2\x10.tour.TourStatusR\x06status\x124\n" +
+	"\n" +
+	"difficulty\x18\x04 \x01(\x0e2\x14.tour.TourDifficultyR\n" +
+	"difficulty\x12\x10\n" +
+	"\x03tag\x18\x05 \x01(\tR\x03tag\x12\x1b\n" +
+	"\tauthor_id\x18\x06 \x01(\tR\bauthorId\x12:\n" +
+	"\x0etransport_type\x18\a \x01(\x0e2\x13.tour.TransportTypeR\rtransportType\x12 \n" +
+	"\tmin_price\x18\b \x01(\x01H\x00R\bminPrice\x88\x01\x01\x12 \n" +
+	"\tmax_price\x18\t \x01(\x01H\x01R\bmaxPrice\x88\x01\x01\x12\x17\n" +
+	"\asort_by\x18\n" +
+	" \x01(\tR\x06sortBy\x12\x1e\n" +
+	"\n" +
+	"descending\x18\v \x01(\bR\n" +
+	"descending\x12&\n" +
+	"\fmax_duration\x18\f \x01(\x01H\x02R\vmaxDuration\x88\x01\x01B\f\n" +
+	"\n" +
+	"_min_priceB\f\n" +
+	"\n" +
+	"_max_priceB\x0f\n" +
+	"\r_max_duration\"h\n" +
+	"\x18ListToursByAuthorRequest\x12\x1b\n" +
+	"\tauthor_id\x18\x01 \x01(\tR\bauthorId\x12\x12\n" +
+	"\x04page\x18\x02 \x01(\x05R\x04page\x12\x1b\n" +
+	"\tpage_size\x18\x03 \x01(\x05R\bpageSize\"|\n" +
+	"\x11ListToursResponse\x12 \n" +
+	"\x05tours\x18\x01 \x03(\v2\n" +
+	".tour.TourR\x05tours\x12\x12\n" +
+	"\x04page\x18\x02 \x01(\x05R\x04page\x12\x1b\n" +
+	"\tpage_size\x18\x03 \x01(\x05R\bpageSize\x12\x14\n" +
+	"\x05total\x18\x04 \x01(\x03R\x05total\"\x98\x02\n" +
+	"\x11UpdateTourRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x14\n" +
+	"\x05title\x18\x02 \x01(\tR\x05title\x12 \n" +
+	"\vdescription\x18\x03 \x01(\tR\vdescription\x124\n" +
+	"\n" +
+	"difficulty\x18\x04 \x01(\x0e2\x14.tour.TourDifficultyR\n" +
+	"difficulty\x12\x12\n" +
+	"\x04tags\x18\x05 \x03(\tR\x04tags\x12\x14\n" +
+	"\x05price\x18\x06 \x01(\x01R\x05price\x125\n" +
+	"\tdurations\x18\t \x03(\v2\x17.tour.TransportDurationR\tdurationsJ\x04\b\a\x10\bJ\x04\b\b\x10\tR\bdurationR\x0etransport_type\"N\n" +
+	"\x12UpdateTourResponse\x12\x1e\n" +
+	"\x04tour\x18\x01 \x01(\v2\n" +
+	".tour.TourR\x04tour\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\"/\n" +
+	"\x14ListKeyPointsRequest\x12\x17\n" +
+	"\atour_id\x18\x01 \x01(\tR\x06tourId\"F\n" +
+	"\x15ListKeyPointsResponse\x12-\n" +
+	"\n" +
+	"key_points\x18\x01 \x03(\v2\x0e.tour.KeyPointR\tkeyPoints\"\xc5\x01\n" +
+	"\x15CreateKeyPointRequest\x12\x17\n" +
+	"\atour_id\x18\x01 \x01(\tR\x06tourId\x12\x1a\n" +
+	"\blatitude\x18\x02 \x01(\x01R\blatitude\x12\x1c\n" +
+	"\tlongitude\x18\x03 \x01(\x01R\tlongitude\x12\x14\n" +
+	"\x05title\x18\x04 \x01(\tR\x05title\x12 \n" +
+	"\vdescription\x18\x05 \x01(\tR\vdescription\x12!\n" +
+	"\x05image\x18\x06 \x01(\v2\v.tour.ImageR\x05image\"\xbc\x01\n" +
+	"\x15UpdateKeyPointRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1a\n" +
+	"\blatitude\x18\x02 \x01(\x01R\blatitude\x12\x1c\n" +
+	"\tlongitude\x18\x03 \x01(\x01R\tlongitude\x12\x14\n" +
+	"\x05title\x18\x04 \x01(\tR\x05title\x12 \n" +
+	"\vdescription\x18\x05 \x01(\tR\vdescription\x12!\n" +
+	"\x05image\x18\x06 \x01(\v2\v.tour.ImageR\x05image\"Y\n" +
+	"\x10KeyPointResponse\x12+\n" +
+	"\tkey_point\x18\x01 \x01(\v2\x0e.tour.KeyPointR\bkeyPoint\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\"'\n" +
+	"\x15DeleteKeyPointRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"L\n" +
+	"\x16DeleteKeyPointResponse\x12\x18\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\x12\x18\n" +
 	"\asuccess\x18\x02 \x01(\bR\asuccess*u\n" +
 	"\n" +
 	"TourStatus\x12\x1b\n" +
@@ -613,12 +1918,21 @@ const file_proto_tour_proto_rawDesc = "" +
 	"\x1aTRANSPORT_TYPE_UNSPECIFIED\x10\x00\x12\x1a\n" +
 	"\x16TRANSPORT_TYPE_WALKING\x10\x01\x12\x1a\n" +
 	"\x16TRANSPORT_TYPE_BICYCLE\x10\x02\x12\x16\n" +
-	"\x12TRANSPORT_TYPE_BUS\x10\x032\x8f\x01\n" +
+	"\x12TRANSPORT_TYPE_BUS\x10\x032\xb9\x05\n" +
 	"\vTourService\x12?\n" +
 	"\n" +
 	"CreateTour\x12\x17.tour.CreateTourRequest\x1a\x18.tour.CreateTourResponse\x12?\n" +
 	"\n" +
-	"DeleteTour\x12\x17.tour.DeleteTourRequest\x1a\x18.tour.DeleteTourResponseB\x1bZ\x19tour.xws.com/proto/tourpbb\x06proto3"
+	"DeleteTour\x12\x17.tour.DeleteTourRequest\x1a\x18.tour.DeleteTourResponse\x126\n" +
+	"\aGetTour\x12\x14.tour.GetTourRequest\x1a\x15.tour.GetTourResponse\x12<\n" +
+	"\tListTours\x12\x16.tour.ListToursRequest\x1a\x17.tour.ListToursResponse\x12L\n" +
+	"\x11ListToursByAuthor\x12\x1e.tour.ListToursByAuthorRequest\x1a\x17.tour.ListToursResponse\x12?\n" +
+	"\n" +
+	"UpdateTour\x12\x17.tour.UpdateTourRequest\x1a\x18.tour.UpdateTourResponse\x12H\n" +
+	"\rListKeyPoints\x12\x1a.tour.ListKeyPointsRequest\x1a\x1b.tour.ListKeyPointsResponse\x12E\n" +
+	"\x0eCreateKeyPoint\x12\x1b.tour.CreateKeyPointRequest\x1a\x16.tour.KeyPointResponse\x12E\n" +
+	"\x0eUpdateKeyPoint\x12\x1b.tour.UpdateKeyPointRequest\x1a\x16.tour.KeyPointResponse\x12K\n" +
+	"\x0eDeleteKeyPoint\x12\x1b.tour.DeleteKeyPointRequest\x1a\x1c.tour.DeleteKeyPointResponseB\x1bZ\x19tour.xws.com/proto/tourpbb\x06proto3"
 
 var (
 	file_proto_tour_proto_rawDescOnce sync.Once
@@ -633,36 +1947,85 @@ func file_proto_tour_proto_rawDescGZIP() []byte {
 }
 
 var file_proto_tour_proto_enumTypes = make([]protoimpl.EnumInfo, 3)
-var file_proto_tour_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
+var file_proto_tour_proto_msgTypes = make([]protoimpl.MessageInfo, 23)
 var file_proto_tour_proto_goTypes = []any{
-	(TourStatus)(0),               // 0: tour.TourStatus
-	(TourDifficulty)(0),           // 1: tour.TourDifficulty
-	(TransportType)(0),            // 2: tour.TransportType
-	(*Tour)(nil),                  // 3: tour.Tour
-	(*CreateTourRequest)(nil),     // 4: tour.CreateTourRequest
-	(*CreateTourResponse)(nil),    // 5: tour.CreateTourResponse
-	(*DeleteTourRequest)(nil),     // 6: tour.DeleteTourRequest
-	(*DeleteTourResponse)(nil),    // 7: tour.DeleteTourResponse
-	(*timestamppb.Timestamp)(nil), // 8: google.protobuf.Timestamp
+	(TourStatus)(0),                  // 0: tour.TourStatus
+	(TourDifficulty)(0),              // 1: tour.TourDifficulty
+	(TransportType)(0),               // 2: tour.TransportType
+	(*Tour)(nil),                     // 3: tour.Tour
+	(*RatingStats)(nil),              // 4: tour.RatingStats
+	(*TransportDuration)(nil),        // 5: tour.TransportDuration
+	(*KeyPoint)(nil),                 // 6: tour.KeyPoint
+	(*Image)(nil),                    // 7: tour.Image
+	(*CreateTourRequest)(nil),        // 8: tour.CreateTourRequest
+	(*CreateTourResponse)(nil),       // 9: tour.CreateTourResponse
+	(*DeleteTourRequest)(nil),        // 10: tour.DeleteTourRequest
+	(*DeleteTourResponse)(nil),       // 11: tour.DeleteTourResponse
+	(*GetTourRequest)(nil),           // 12: tour.GetTourRequest
+	(*GetTourResponse)(nil),          // 13: tour.GetTourResponse
+	(*ListToursRequest)(nil),         // 14: tour.ListToursRequest
+	(*ListToursByAuthorRequest)(nil), // 15: tour.ListToursByAuthorRequest
+	(*ListToursResponse)(nil),        // 16: tour.ListToursResponse
+	(*UpdateTourRequest)(nil),        // 17: tour.UpdateTourRequest
+	(*UpdateTourResponse)(nil),       // 18: tour.UpdateTourResponse
+	(*ListKeyPointsRequest)(nil),     // 19: tour.ListKeyPointsRequest
+	(*ListKeyPointsResponse)(nil),    // 20: tour.ListKeyPointsResponse
+	(*CreateKeyPointRequest)(nil),    // 21: tour.CreateKeyPointRequest
+	(*UpdateKeyPointRequest)(nil),    // 22: tour.UpdateKeyPointRequest
+	(*KeyPointResponse)(nil),         // 23: tour.KeyPointResponse
+	(*DeleteKeyPointRequest)(nil),    // 24: tour.DeleteKeyPointRequest
+	(*DeleteKeyPointResponse)(nil),   // 25: tour.DeleteKeyPointResponse
+	(*timestamppb.Timestamp)(nil),    // 26: google.protobuf.Timestamp
 }
 var file_proto_tour_proto_depIdxs = []int32{
 	1,  // 0: tour.Tour.difficulty:type_name -> tour.TourDifficulty
 	0,  // 1: tour.Tour.status:type_name -> tour.TourStatus
-	8,  // 2: tour.Tour.published_at:type_name -> google.protobuf.Timestamp
-	8,  // 3: tour.Tour.archived_at:type_name -> google.protobuf.Timestamp
-	2,  // 4: tour.Tour.transport_type:type_name -> tour.TransportType
-	1,  // 5: tour.CreateTourRequest.difficulty:type_name -> tour.TourDifficulty
-	2,  // 6: tour.CreateTourRequest.transport_type:type_name -> tour.TransportType
-	3,  // 7: tour.CreateTourResponse.tour:type_name -> tour.Tour
-	4,  // 8: tour.TourService.CreateTour:input_type -> tour.CreateTourRequest
-	6,  // 9: tour.TourService.DeleteTour:input_type -> tour.DeleteTourRequest
-	5,  // 10: tour.TourService.CreateTour:output_type -> tour.CreateTourResponse
-	7,  // 11: tour.TourService.DeleteTour:output_type -> tour.DeleteTourResponse
-	10, // [10:12] is the sub-list for method output_type
-	8,  // [8:10] is the sub-list for method input_type
-	8,  // [8:8] is the sub-list for extension type_name
-	8,  // [8:8] is the sub-list for extension extendee
-	0,  // [0:8] is the sub-list for field type_name
+	26, // 2: tour.Tour.published_at:type_name -> google.protobuf.Timestamp
+	26, // 3: tour.Tour.archived_at:type_name -> google.protobuf.Timestamp
+	5,  // 4: tour.Tour.durations:type_name -> tour.TransportDuration
+	4,  // 5: tour.Tour.rating_stats:type_name -> tour.RatingStats
+	2,  // 6: tour.TransportDuration.transport_type:type_name -> tour.TransportType
+	26, // 7: tour.KeyPoint.created_at:type_name -> google.protobuf.Timestamp
+	1,  // 8: tour.CreateTourRequest.difficulty:type_name -> tour.TourDifficulty
+	5,  // 9: tour.CreateTourRequest.durations:type_name -> tour.TransportDuration
+	3,  // 10: tour.CreateTourResponse.tour:type_name -> tour.Tour
+	3,  // 11: tour.GetTourResponse.tour:type_name -> tour.Tour
+	0,  // 12: tour.ListToursRequest.status:type_name -> tour.TourStatus
+	1,  // 13: tour.ListToursRequest.difficulty:type_name -> tour.TourDifficulty
+	2,  // 14: tour.ListToursRequest.transport_type:type_name -> tour.TransportType
+	3,  // 15: tour.ListToursResponse.tours:type_name -> tour.Tour
+	1,  // 16: tour.UpdateTourRequest.difficulty:type_name -> tour.TourDifficulty
+	5,  // 17: tour.UpdateTourRequest.durations:type_name -> tour.TransportDuration
+	3,  // 18: tour.UpdateTourResponse.tour:type_name -> tour.Tour
+	6,  // 19: tour.ListKeyPointsResponse.key_points:type_name -> tour.KeyPoint
+	7,  // 20: tour.CreateKeyPointRequest.image:type_name -> tour.Image
+	7,  // 21: tour.UpdateKeyPointRequest.image:type_name -> tour.Image
+	6,  // 22: tour.KeyPointResponse.key_point:type_name -> tour.KeyPoint
+	8,  // 23: tour.TourService.CreateTour:input_type -> tour.CreateTourRequest
+	10, // 24: tour.TourService.DeleteTour:input_type -> tour.DeleteTourRequest
+	12, // 25: tour.TourService.GetTour:input_type -> tour.GetTourRequest
+	14, // 26: tour.TourService.ListTours:input_type -> tour.ListToursRequest
+	15, // 27: tour.TourService.ListToursByAuthor:input_type -> tour.ListToursByAuthorRequest
+	17, // 28: tour.TourService.UpdateTour:input_type -> tour.UpdateTourRequest
+	19, // 29: tour.TourService.ListKeyPoints:input_type -> tour.ListKeyPointsRequest
+	21, // 30: tour.TourService.CreateKeyPoint:input_type -> tour.CreateKeyPointRequest
+	22, // 31: tour.TourService.UpdateKeyPoint:input_type -> tour.UpdateKeyPointRequest
+	24, // 32: tour.TourService.DeleteKeyPoint:input_type -> tour.DeleteKeyPointRequest
+	9,  // 33: tour.TourService.CreateTour:output_type -> tour.CreateTourResponse
+	11, // 34: tour.TourService.DeleteTour:output_type -> tour.DeleteTourResponse
+	13, // 35: tour.TourService.GetTour:output_type -> tour.GetTourResponse
+	16, // 36: tour.TourService.ListTours:output_type -> tour.ListToursResponse
+	16, // 37: tour.TourService.ListToursByAuthor:output_type -> tour.ListToursResponse
+	18, // 38: tour.TourService.UpdateTour:output_type -> tour.UpdateTourResponse
+	20, // 39: tour.TourService.ListKeyPoints:output_type -> tour.ListKeyPointsResponse
+	23, // 40: tour.TourService.CreateKeyPoint:output_type -> tour.KeyPointResponse
+	23, // 41: tour.TourService.UpdateKeyPoint:output_type -> tour.KeyPointResponse
+	25, // 42: tour.TourService.DeleteKeyPoint:output_type -> tour.DeleteKeyPointResponse
+	33, // [33:43] is the sub-list for method output_type
+	23, // [23:33] is the sub-list for method input_type
+	23, // [23:23] is the sub-list for extension type_name
+	23, // [23:23] is the sub-list for extension extendee
+	0,  // [0:23] is the sub-list for field type_name
 }
 
 func init() { file_proto_tour_proto_init() }
@@ -670,13 +2033,14 @@ func file_proto_tour_proto_init() {
 	if File_proto_tour_proto != nil {
 		return
 	}
+	file_proto_tour_proto_msgTypes[11].OneofWrappers = []any{}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_tour_proto_rawDesc), len(file_proto_tour_proto_rawDesc)),
 			NumEnums:      3,
-			NumMessages:   5,
+			NumMessages:   23,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -19,8 +19,16 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	TourService_CreateTour_FullMethodName = "/tour.TourService/CreateTour"
-	TourService_DeleteTour_FullMethodName = "/tour.TourService/DeleteTour"
+	TourService_CreateTour_FullMethodName        = "/tour.TourService/CreateTour"
+	TourService_DeleteTour_FullMethodName        = "/tour.TourService/DeleteTour"
+	TourService_GetTour_FullMethodName           = "/tour.TourService/GetTour"
+	TourService_ListTours_FullMethodName         = "/tour.TourService/ListTours"
+	TourService_ListToursByAuthor_FullMethodName = "/tour.TourService/ListToursByAuthor"
+	TourService_UpdateTour_FullMethodName        = "/tour.TourService/UpdateTour"
+	TourService_ListKeyPoints_FullMethodName     = "/tour.TourService/ListKeyPoints"
+	TourService_CreateKeyPoint_FullMethodName    = "/tour.TourService/CreateKeyPoint"
+	TourService_UpdateKeyPoint_FullMethodName    = "/tour.TourService/UpdateKeyPoint"
+	TourService_DeleteKeyPoint_FullMethodName    = "/tour.TourService/DeleteKeyPoint"
 )
 
 // TourServiceClient is the client API for TourService service.
@@ -31,6 +39,14 @@ const (
 type TourServiceClient interface {
 	CreateTour(ctx context.Context, in *CreateTourRequest, opts ...grpc.CallOption) (*CreateTourResponse, error)
 	DeleteTour(ctx context.Context, in *DeleteTourRequest, opts ...grpc.CallOption) (*DeleteTourResponse, error)
+	GetTour(ctx context.Context, in *GetTourRequest, opts ...grpc.CallOption) (*GetTourResponse, error)
+	ListTours(ctx context.Context, in *ListToursRequest, opts ...grpc.CallOption) (*ListToursResponse, error)
+	ListToursByAuthor(ctx context.Context, in *ListToursByAuthorRequest, opts ...grpc.CallOption) (*ListToursResponse, error)
+	UpdateTour(ctx context.Context, in *UpdateTourRequest, opts ...grpc.CallOption) (*UpdateTourResponse, error)
+	ListKeyPoints(ctx context.Context, in *ListKeyPointsRequest, opts ...grpc.CallOption) (*ListKeyPointsResponse, error)
+	CreateKeyPoint(ctx context.Context, in *CreateKeyPointRequest, opts ...grpc.CallOption) (*KeyPointResponse, error)
+	UpdateKeyPoint(ctx context.Context, in *UpdateKeyPointRequest, opts ...grpc.CallOption) (*KeyPointResponse, error)
+	DeleteKeyPoint(ctx context.Context, in *DeleteKeyPointRequest, opts ...grpc.CallOption) (*DeleteKeyPointResponse, error)
 }
 
 type tourServiceClient struct {
@@ -61,6 +77,86 @@ func (c *tourServiceClient) DeleteTour(ctx context.Context, in *DeleteTourReques
 	return out, nil
 }
 
+func (c *tourServiceClient) GetTour(ctx context.Context, in *GetTourRequest, opts ...grpc.CallOption) (*GetTourResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetTourResponse)
+	err := c.cc.Invoke(ctx, TourService_GetTour_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *tourServiceClient) ListTours(ctx context.Context, in *ListToursRequest, opts ...grpc.CallOption) (*ListToursResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListToursResponse)
+	err := c.cc.Invoke(ctx, TourService_ListTours_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *tourServiceClient) ListToursByAuthor(ctx context.Context, in *ListToursByAuthorRequest, opts ...grpc.CallOption) (*ListToursResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListToursResponse)
+	err := c.cc.Invoke(ctx, TourService_ListToursByAuthor_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *tourServiceClient) UpdateTour(ctx context.Context, in *UpdateTourRequest, opts ...grpc.CallOption) (*UpdateTourResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UpdateTourResponse)
+	err := c.cc.Invoke(ctx, TourService_UpdateTour_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *tourServiceClient) ListKeyPoints(ctx context.Context, in *ListKeyPointsRequest, opts ...grpc.CallOption) (*ListKeyPointsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListKeyPointsResponse)
+	err := c.cc.Invoke(ctx, TourService_ListKeyPoints_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *tourServiceClient) CreateKeyPoint(ctx context.Context, in *CreateKeyPointRequest, opts ...grpc.CallOption) (*KeyPointResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(KeyPointResponse)
+	err := c.cc.Invoke(ctx, TourService_CreateKeyPoint_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *tourServiceClient) UpdateKeyPoint(ctx context.Context, in *UpdateKeyPointRequest, opts ...grpc.CallOption) (*KeyPointResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(KeyPointResponse)
+	err := c.cc.Invoke(ctx, TourService_UpdateKeyPoint_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *tourServiceClient) DeleteKeyPoint(ctx context.Context, in *DeleteKeyPointRequest, opts ...grpc.CallOption) (*DeleteKeyPointResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteKeyPointResponse)
+	err := c.cc.Invoke(ctx, TourService_DeleteKeyPoint_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // TourServiceServer is the server API for TourService service.
 // All implementations must embed UnimplementedTourServiceServer
 // for forward compatibility.
@@ -69,6 +165,14 @@ func (c *tourServiceClient) DeleteTour(ctx context.Context, in *DeleteTourReques
 type TourServiceServer interface {
 	CreateTour(context.Context, *CreateTourRequest) (*CreateTourResponse, error)
 	DeleteTour(context.Context, *DeleteTourRequest) (*DeleteTourResponse, error)
+	GetTour(context.Context, *GetTourRequest) (*GetTourResponse, error)
+	ListTours(context.Context, *ListToursRequest) (*ListToursResponse, error)
+	ListToursByAuthor(context.Context, *ListToursByAuthorRequest) (*ListToursResponse, error)
+	UpdateTour(context.Context, *UpdateTourRequest) (*UpdateTourResponse, error)
+	ListKeyPoints(context.Context, *ListKeyPointsRequest) (*ListKeyPointsResponse, error)
+	CreateKeyPoint(context.Context, *CreateKeyPointRequest) (*KeyPointResponse, error)
+	UpdateKeyPoint(context.Context, *UpdateKeyPointRequest) (*KeyPointResponse, error)
+	DeleteKeyPoint(context.Context, *DeleteKeyPointRequest) (*DeleteKeyPointResponse, error)
 	mustEmbedUnimplementedTourServiceServer()
 }
 
@@ -85,6 +189,30 @@ func (UnimplementedTourServiceServer) CreateTour(context.Context, *CreateTourReq
 func (UnimplementedTourServiceServer) DeleteTour(context.Context, *DeleteTourRequest) (*DeleteTourResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DeleteTour not implemented")
 }
+func (UnimplementedTourServiceServer) GetTour(context.Context, *GetTourRequest) (*GetTourResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTour not implemented")
+}
+func (UnimplementedTourServiceServer) ListTours(context.Context, *ListToursRequest) (*ListToursResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListTours not implemented")
+}
+func (UnimplementedTourServiceServer) ListToursByAuthor(context.Context, *ListToursByAuthorRequest) (*ListToursResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListToursByAuthor not implemented")
+}
+func (UnimplementedTourServiceServer) UpdateTour(context.Context, *UpdateTourRequest) (*UpdateTourResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateTour not implemented")
+}
+func (UnimplementedTourServiceServer) ListKeyPoints(context.Context, *ListKeyPointsRequest) (*ListKeyPointsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListKeyPoints not implemented")
+}
+func (UnimplementedTourServiceServer) CreateKeyPoint(context.Context, *CreateKeyPointRequest) (*KeyPointResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateKeyPoint not implemented")
+}
+func (UnimplementedTourServiceServer) UpdateKeyPoint(context.Context, *UpdateKeyPointRequest) (*KeyPointResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateKeyPoint not implemented")
+}
+func (UnimplementedTourServiceServer) DeleteKeyPoint(context.Context, *DeleteKeyPointRequest) (*DeleteKeyPointResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteKeyPoint not implemented")
+}
 func (UnimplementedTourServiceServer) mustEmbedUnimplementedTourServiceServer() {}
 func (UnimplementedTourServiceServer) testEmbeddedByValue()                     {}
 
@@ -142,6 +270,150 @@ func _TourService_DeleteTour_Handler(srv interface{}, ctx context.Context, dec f
 	return interceptor(ctx, in, info, handler)
 }
 
+func _TourService_GetTour_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTourRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TourServiceServer).GetTour(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TourService_GetTour_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TourServiceServer).GetTour(ctx, req.(*GetTourRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TourService_ListTours_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListToursRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TourServiceServer).ListTours(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TourService_ListTours_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TourServiceServer).ListTours(ctx, req.(*ListToursRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TourService_ListToursByAuthor_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListToursByAuthorRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TourServiceServer).ListToursByAuthor(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TourService_ListToursByAuthor_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TourServiceServer).ListToursByAuthor(ctx, req.(*ListToursByAuthorRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TourService_UpdateTour_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateTourRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TourServiceServer).UpdateTour(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TourService_UpdateTour_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TourServiceServer).UpdateTour(ctx, req.(*UpdateTourRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TourService_ListKeyPoints_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListKeyPointsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TourServiceServer).ListKeyPoints(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TourService_ListKeyPoints_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TourServiceServer).ListKeyPoints(ctx, req.(*ListKeyPointsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TourService_CreateKeyPoint_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateKeyPointRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TourServiceServer).CreateKeyPoint(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TourService_CreateKeyPoint_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TourServiceServer).CreateKeyPoint(ctx, req.(*CreateKeyPointRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TourService_UpdateKeyPoint_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateKeyPointRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TourServiceServer).UpdateKeyPoint(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TourService_UpdateKeyPoint_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TourServiceServer).UpdateKeyPoint(ctx, req.(*UpdateKeyPointRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TourService_DeleteKeyPoint_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteKeyPointRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TourServiceServer).DeleteKeyPoint(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TourService_DeleteKeyPoint_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TourServiceServer).DeleteKeyPoint(ctx, req.(*DeleteKeyPointRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // TourService_ServiceDesc is the grpc.ServiceDesc for TourService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -157,6 +429,38 @@ var TourService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "DeleteTour",
 			Handler:    _TourService_DeleteTour_Handler,
 		},
+		{
+			MethodName: "GetTour",
+			Handler:    _TourService_GetTour_Handler,
+		},
+		{
+			MethodName: "ListTours",
+			Handler:    _TourService_ListTours_Handler,
+		},
+		{
+			MethodName: "ListToursByAuthor",
+			Handler:    _TourService_ListToursByAuthor_Handler,
+		},
+		{
+			MethodName: "UpdateTour",
+			Handler:    _TourService_UpdateTour_Handler,
+		},
+		{
+			MethodName: "ListKeyPoints",
+			Handler:    _TourService_ListKeyPoints_Handler,
+		},
+		{
+			MethodName: "CreateKeyPoint",
+			Handler:    _TourService_CreateKeyPoint_Handler,
+		},
+		{
+			MethodName: "UpdateKeyPoint",
+			Handler:    _TourService_UpdateKeyPoint_Handler,
+		},
+		{
+			MethodName: "DeleteKeyPoint",
+			Handler:    _TourService_DeleteKeyPoint_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "proto/tour.proto",
@@ -12,6 +12,8 @@ import (
 	"tour.xws.com/model"
 )
 
+var ErrKeyPointNotFound = errors.New("keyPoint not found")
+
 type KeyPointRepository struct {
 	Collection *mongo.Collection
 }
@@ -39,9 +41,12 @@ func (repo *KeyPointRepository) GetAll() ([]model.KeyPoint, error) {
 	return keyPoints, nil
 }
 
+// GetAllByTour returns the tour's key points in route order. Key points created
+// before ordering existed all have order 0 and fall back to creation time.
 func (repo *KeyPointRepository) GetAllByTour(tourId uuid.UUID) ([]model.KeyPoint, error) {
 	filter := bson.M{"tourId": tourId}
-	cursor, err := repo.Collection.Find(context.TODO(), filter)
+	opts := options.Find().SetSort(bson.D{{Key: "order", Value: 1}, {Key: "createdAt", Value: 1}})
+	cursor, err := repo.Collection.Find(context.TODO(), filter, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -65,7 +70,7 @@ func (repo *KeyPointRepository) GetAllByTour(tourId uuid.UUID) ([]model.KeyPoint
 
 func (repo *KeyPointRepository) GetAllByTourSortedByCreatedAt(tourId uuid.UUID) ([]model.KeyPoint, error) {
 	filter := bson.M{"tourId": tourId}
-	opts := options.Find().SetSort(bson.D{{"createdAt", 1}})
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}})
 	cursor, err := repo.Collection.Find(context.TODO(), filter, opts)
 	if err != nil {
 		return nil, err
@@ -93,28 +98,87 @@ func (repo *KeyPointRepository) GetById(id uuid.UUID) (*model.KeyPoint, error) {
 	err := repo.Collection.FindOne(context.TODO(), bson.M{"_id": id}).Decode(&keyPoint)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			return nil, errors.New("keyPoint not found")
+			return nil, ErrKeyPointNotFound
 		}
 		return nil, err
 	}
 	return &keyPoint, nil
 }
 
+// GetImageById loads only what serving the key point's image needs: its tour
+// and the image reference.
+func (repo *KeyPointRepository) GetImageById(id uuid.UUID) (*model.KeyPoint, error) {
+	var keyPoint model.KeyPoint
+	opts := options.FindOne().SetProjection(bson.M{"tourId": 1, "image": 1})
+	err := repo.Collection.FindOne(context.TODO(), bson.M{"_id": id}, opts).Decode(&keyPoint)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrKeyPointNotFound
+		}
+		return nil, err
+	}
+	return &keyPoint, nil
+}
+
+// GetFirstIdOfTour returns the id of the tour's starting key point, or
+// uuid.Nil if the tour has none.
+func (repo *KeyPointRepository) GetFirstIdOfTour(tourId uuid.UUID) (uuid.UUID, error) {
+	var keyPoint model.KeyPoint
+	opts := options.FindOne().
+		SetSort(bson.D{{Key: "order", Value: 1}, {Key: "createdAt", Value: 1}}).
+		SetProjection(bson.M{"_id": 1})
+	err := repo.Collection.FindOne(context.TODO(), bson.M{"tourId": tourId}, opts).Decode(&keyPoint)
+	if err == mongo.ErrNoDocuments {
+		return uuid.Nil, nil
+	}
+	return keyPoint.Id, err
+}
+
 func (repo *KeyPointRepository) Create(keyPoint *model.KeyPoint) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	keyPoint.Location = keyPoint.Coordinates.GeoPoint()
 	_, err := repo.Collection.InsertOne(ctx, keyPoint)
 	return err
 }
 
+// CreateMany stores the key points of a copied tour.
+func (repo *KeyPointRepository) CreateMany(keyPoints []model.KeyPoint) error {
+	if len(keyPoints) == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	docs := make([]interface{}, 0, len(keyPoints))
+	for _, keyPoint := range keyPoints {
+		keyPoint.Location = keyPoint.Coordinates.GeoPoint()
+		docs = append(docs, keyPoint)
+	}
+	_, err := repo.Collection.InsertMany(ctx, docs)
+	return err
+}
+
+// MoveToTour hands every key point of tour from over to tour to.
+func (repo *KeyPointRepository) MoveToTour(from uuid.UUID, to uuid.UUID) error {
+	_, err := repo.Collection.UpdateMany(context.TODO(), bson.M{"tourId": from}, bson.M{"$set": bson.M{"tourId": to}})
+	return err
+}
+
+// DeleteAllByTour removes the tour's key points; their images are left to the caller.
+func (repo *KeyPointRepository) DeleteAllByTour(tourId uuid.UUID) error {
+	_, err := repo.Collection.DeleteMany(context.TODO(), bson.M{"tourId": tourId})
+	return err
+}
+
 func (repo *KeyPointRepository) Delete(id uuid.UUID) error {
 	res, err := repo.Collection.DeleteOne(context.TODO(), bson.M{"_id": id})
 	if err != nil {
 		return err
 	}
 	if res.DeletedCount == 0 {
-		return errors.New("keyPoint not found")
+		return ErrKeyPointNotFound
 	}
 	return nil
 }
@@ -123,6 +187,7 @@ func (repo *KeyPointRepository) Update(id uuid.UUID, updatedKeyPoint model.KeyPo
 	update := bson.M{
 		"$set": bson.M{
 			"coordinates": updatedKeyPoint.Coordinates,
+			"location":    updatedKeyPoint.Coordinates.GeoPoint(),
 			"title":       updatedKeyPoint.Title,
 			"description": updatedKeyPoint.Description,
 			"image":       updatedKeyPoint.Image,
@@ -138,3 +203,88 @@ func (repo *KeyPointRepository) Update(id uuid.UUID, updatedKeyPoint model.KeyPo
 	}
 	return nil
 }
+
+// SetOrder assigns each key point its position in orderedIds.
+func (repo *KeyPointRepository) SetOrder(tourId uuid.UUID, orderedIds []uuid.UUID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	models := make([]mongo.WriteModel, 0, len(orderedIds))
+	for i, id := range orderedIds {
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": id, "tourId": tourId}).
+			SetUpdate(bson.M{"$set": bson.M{"order": i}}))
+	}
+	if len(models) == 0 {
+		return nil
+	}
+
+	_, err := repo.Collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	return err
+}
+
+// BackfillLocations derives the GeoJSON location of key points stored before it existed.
+func (repo *KeyPointRepository) BackfillLocations() (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	res, err := repo.Collection.UpdateMany(ctx,
+		bson.M{"location": bson.M{"$exists": false}},
+		mongo.Pipeline{{{Key: "$set", Value: bson.M{
+			"location": bson.M{
+				"type":        "Point",
+				"coordinates": bson.A{"$coordinates.longitude", "$coordinates.latitude"},
+			},
+		}}}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.ModifiedCount, nil
+}
+
+// NearbyKeyPoint is a key point within a search radius.
+type NearbyKeyPoint struct {
+	Id             uuid.UUID `bson:"id"`
+	DistanceMeters float64   `bson:"distance"`
+}
+
+// NearbyTourKeyPoints groups the key points within a search radius by tour.
+type NearbyTourKeyPoints struct {
+	TourId         uuid.UUID        `bson:"_id"`
+	DistanceMeters float64          `bson:"distance"`
+	KeyPoints      []NearbyKeyPoint `bson:"keyPoints"`
+}
+
+// FindNearby returns, closest first, every tour with a key point within
+// radiusMeters of center, along with those key points.
+func (repo *KeyPointRepository) FindNearby(center model.Coordinates, radiusMeters float64) ([]NearbyTourKeyPoints, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cursor, err := repo.Collection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$geoNear", Value: bson.M{
+			"near":          center.GeoPoint(),
+			"key":           "location",
+			"distanceField": "distance",
+			"maxDistance":   radiusMeters,
+			"spherical":     true,
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":       "$tourId",
+			"distance":  bson.M{"$min": "$distance"},
+			"keyPoints": bson.M{"$push": bson.M{"id": "$_id", "distance": "$distance"}},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "distance", Value: 1}, {Key: "_id", Value: 1}}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var matches []NearbyTourKeyPoints
+	if err := cursor.All(ctx, &matches); err != nil {
+		return nil, err
+	}
+	return matches, nil
+}
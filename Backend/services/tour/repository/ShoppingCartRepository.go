@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"tour.xws.com/model"
+)
+
+type ShoppingCartRepository struct {
+	Collection *mongo.Collection
+}
+
+// GetByTourist returns an empty cart for tourists who never added anything.
+func (repo *ShoppingCartRepository) GetByTourist(touristId string) (*model.ShoppingCart, error) {
+	var cart model.ShoppingCart
+	err := repo.Collection.FindOne(context.TODO(), bson.M{"_id": touristId}).Decode(&cart)
+	if err == mongo.ErrNoDocuments {
+		return model.NewShoppingCart(touristId), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &cart, nil
+}
+
+func (repo *ShoppingCartRepository) Save(cart *model.ShoppingCart) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := repo.Collection.ReplaceOne(ctx, bson.M{"_id": cart.TouristId}, cart, options.Replace().SetUpsert(true))
+	return err
+}
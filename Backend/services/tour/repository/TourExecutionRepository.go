@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"tour.xws.com/model"
+)
+
+var ErrTourExecutionNotFound = errors.New("tour execution not found")
+
+type TourExecutionRepository struct {
+	Collection *mongo.Collection
+}
+
+func (repo *TourExecutionRepository) Create(execution *model.TourExecution) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := repo.Collection.InsertOne(ctx, execution)
+	return err
+}
+
+func (repo *TourExecutionRepository) GetById(id uuid.UUID) (*model.TourExecution, error) {
+	var execution model.TourExecution
+	err := repo.Collection.FindOne(context.TODO(), bson.M{"_id": id}).Decode(&execution)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrTourExecutionNotFound
+		}
+		return nil, err
+	}
+	return &execution, nil
+}
+
+// GetActiveByTourist returns nil, nil when the tourist is not on a tour.
+func (repo *TourExecutionRepository) GetActiveByTourist(touristId string) (*model.TourExecution, error) {
+	var execution model.TourExecution
+	err := repo.Collection.FindOne(context.TODO(), bson.M{"touristId": touristId, "status": model.Active}).Decode(&execution)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &execution, nil
+}
+
+// HasActiveOnTour reports whether any tourist is on the tour right now.
+func (repo *TourExecutionRepository) HasActiveOnTour(tourId uuid.UUID) (bool, error) {
+	filter := bson.M{"tourId": tourId, "status": model.Active}
+	count, err := repo.Collection.CountDocuments(context.TODO(), filter, options.Count().SetLimit(1))
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// HasActivitySince reports whether the tourist was on the tour at or after since.
+func (repo *TourExecutionRepository) HasActivitySince(touristId string, tourId uuid.UUID, since time.Time) (bool, error) {
+	filter := bson.M{"touristId": touristId, "tourId": tourId, "lastActivity": bson.M{"$gte": since}}
+	count, err := repo.Collection.CountDocuments(context.TODO(), filter, options.Count().SetLimit(1))
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (repo *TourExecutionRepository) UpdateProgress(id uuid.UUID, completedKeyPoints []model.CompletedKeyPoint, lastActivity time.Time) error {
+	update := bson.M{
+		"$set": bson.M{
+			"completedKeyPoints": completedKeyPoints,
+			"lastActivity":       lastActivity,
+		},
+	}
+
+	res, err := repo.Collection.UpdateOne(context.TODO(), bson.M{"_id": id}, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// Finish ends an execution. The status filter makes it a no-op for
+// executions that were already completed or abandoned concurrently.
+func (repo *TourExecutionRepository) Finish(id uuid.UUID, status model.TourExecutionStatus, endedAt time.Time) error {
+	update := bson.M{
+		"$set": bson.M{
+			"status":       status,
+			"endedAt":      endedAt,
+			"lastActivity": endedAt,
+		},
+	}
+
+	res, err := repo.Collection.UpdateOne(context.TODO(), bson.M{"_id": id, "status": model.Active}, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"tour.xws.com/model"
+)
+
+type TourPurchaseTokenRepository struct {
+	Collection *mongo.Collection
+}
+
+func (repo *TourPurchaseTokenRepository) CreateMany(tokens []*model.TourPurchaseToken) error {
+	if len(tokens) == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	docs := make([]interface{}, 0, len(tokens))
+	for _, token := range tokens {
+		docs = append(docs, token)
+	}
+	_, err := repo.Collection.InsertMany(ctx, docs)
+	return err
+}
+
+func (repo *TourPurchaseTokenRepository) Exists(touristId string, tourId uuid.UUID) (bool, error) {
+	count, err := repo.Collection.CountDocuments(context.TODO(), bson.M{"touristId": touristId, "tourId": tourId})
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// ExistsForTour reports whether anyone has bought the tour.
+func (repo *TourPurchaseTokenRepository) ExistsForTour(tourId uuid.UUID) (bool, error) {
+	count, err := repo.Collection.CountDocuments(context.TODO(), bson.M{"tourId": tourId}, options.Count().SetLimit(1))
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (repo *TourPurchaseTokenRepository) GetByTourist(touristId string) ([]model.TourPurchaseToken, error) {
+	cur, err := repo.Collection.Find(context.TODO(), bson.M{"touristId": touristId})
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(context.TODO())
+
+	var tokens []model.TourPurchaseToken
+	for cur.Next(context.TODO()) {
+		var token model.TourPurchaseToken
+		if err := cur.Decode(&token); err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens, cur.Err()
+}
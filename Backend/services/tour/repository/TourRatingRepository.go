@@ -2,6 +2,9 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	"go.mongodb.org/mongo-driver/bson"
@@ -9,6 +12,12 @@ import (
 	"tour.xws.com/model"
 )
 
+var (
+	ErrReviewNotFound      = errors.New("review not found")
+	ErrReviewImageNotFound = errors.New("review image not found")
+	ErrReviewImagesFull    = errors.New("review has no room for more images")
+)
+
 type TourRatingRepository struct {
 	Collection *mongo.Collection
 }
@@ -31,3 +40,116 @@ func (r *TourRatingRepository) GetByTour(tourId uuid.UUID) ([]model.TourRating,
 	}
 	return out, cur.Err()
 }
+
+func (r *TourRatingRepository) GetById(id uuid.UUID) (*model.TourRating, error) {
+	var item model.TourRating
+	err := r.Collection.FindOne(context.TODO(), bson.M{"_id": id}).Decode(&item)
+	if err == mongo.ErrNoDocuments { return nil, ErrReviewNotFound }
+	if err != nil { return nil, err }
+	return &item, nil
+}
+
+// GetByTourist returns the tourist's review of the tour, or nil if there is none.
+func (r *TourRatingRepository) GetByTourist(tourId uuid.UUID, touristId string) (*model.TourRating, error) {
+	var item model.TourRating
+	err := r.Collection.FindOne(context.TODO(), bson.M{"tourId": tourId, "touristId": touristId}).Decode(&item)
+	if err == mongo.ErrNoDocuments { return nil, nil }
+	if err != nil { return nil, err }
+	return &item, nil
+}
+
+func (r *TourRatingRepository) Update(m *model.TourRating) error {
+	res, err := r.Collection.UpdateOne(context.TODO(), bson.M{"_id": m.Id}, bson.M{"$set": bson.M{
+		"rating":      m.Rating,
+		"comment":     m.Comment,
+		"visitedAt":   m.VisitedAt,
+		"commentedAt": m.CommentedAt,
+		"updatedAt":   m.UpdatedAt,
+	}})
+	if err != nil { return err }
+	if res.MatchedCount == 0 { return ErrReviewNotFound }
+	return nil
+}
+
+// AddImages appends images as long as the review stays within
+// model.MaxReviewImages; the limit is part of the filter so concurrent uploads
+// can't push past it.
+func (r *TourRatingRepository) AddImages(id uuid.UUID, images []model.ReviewImage) error {
+	if len(images) > model.MaxReviewImages { return ErrReviewImagesFull }
+	// the review has room when the image that would overflow it isn't there yet
+	overflow := fmt.Sprintf("images.%d", model.MaxReviewImages-len(images))
+	filter := bson.M{"_id": id, overflow: bson.M{"$exists": false}}
+	res, err := r.Collection.UpdateOne(context.TODO(), filter, bson.M{"$push": bson.M{"images": bson.M{"$each": images}}})
+	if err != nil { return err }
+	if res.MatchedCount == 0 {
+		n, err := r.Collection.CountDocuments(context.TODO(), bson.M{"_id": id})
+		if err != nil { return err }
+		if n == 0 { return ErrReviewNotFound }
+		return ErrReviewImagesFull
+	}
+	return nil
+}
+
+func (r *TourRatingRepository) RemoveImage(id uuid.UUID, imageId uuid.UUID) error {
+	res, err := r.Collection.UpdateOne(context.TODO(), bson.M{"_id": id}, bson.M{"$pull": bson.M{"images": bson.M{"id": imageId}}})
+	if err != nil { return err }
+	if res.ModifiedCount == 0 { return ErrReviewImageNotFound }
+	return nil
+}
+
+// SetReply stores the author's reply on the review; a nil reply removes it.
+func (r *TourRatingRepository) SetReply(id uuid.UUID, reply *model.ReviewReply) error {
+	update := bson.M{"$set": bson.M{"reply": reply}}
+	if reply == nil {
+		update = bson.M{"$unset": bson.M{"reply": ""}}
+	}
+	res, err := r.Collection.UpdateOne(context.TODO(), bson.M{"_id": id}, update)
+	if err != nil { return err }
+	if res.MatchedCount == 0 { return ErrReviewNotFound }
+	return nil
+}
+
+type ratingHistogram struct {
+	TourId uuid.UUID `bson:"_id"`
+	Counts []struct {
+		Rating int `bson:"rating"`
+		Count  int `bson:"count"`
+	} `bson:"counts"`
+}
+
+// Histograms counts reviews per rating for each reviewed tour, or only for
+// tourId when it is given.
+func (r *TourRatingRepository) Histograms(tourId *uuid.UUID) (map[uuid.UUID][model.MaxRating]int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	match := bson.M{"rating": bson.M{"$gte": model.MinRating, "$lte": model.MaxRating}}
+	if tourId != nil { match["tourId"] = *tourId }
+
+	cur, err := r.Collection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   bson.M{"tourId": "$tourId", "rating": "$rating"},
+			"count": bson.M{"$sum": 1},
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":    "$_id.tourId",
+			"counts": bson.M{"$push": bson.M{"rating": "$_id.rating", "count": "$count"}},
+		}}},
+	})
+	if err != nil { return nil, err }
+	defer cur.Close(ctx)
+
+	var rows []ratingHistogram
+	if err := cur.All(ctx, &rows); err != nil { return nil, err }
+
+	out := make(map[uuid.UUID][model.MaxRating]int, len(rows))
+	for _, row := range rows {
+		var histogram [model.MaxRating]int
+		for _, c := range row.Counts {
+			histogram[c.Rating-model.MinRating] = c.Count
+		}
+		out[row.TourId] = histogram
+	}
+	return out, nil
+}
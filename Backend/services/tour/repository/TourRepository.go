@@ -8,6 +8,7 @@ import (
 	"github.com/google/uuid"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 	"tour.xws.com/model"
 )
 
@@ -15,51 +16,162 @@ type TourRepository struct {
 	Collection *mongo.Collection
 }
 
-func (repo *TourRepository) GetAll() ([]model.Tour, error) {
-	cursor, err := repo.Collection.Find(context.TODO(), bson.M{})
-	if err != nil {
-		return nil, err
+// ErrTourNotFound is returned when no tour has the requested id.
+var ErrTourNotFound = errors.New("tour not found")
+
+// ErrStatusChanged is returned by UpdateStatus when the tour is no longer in
+// the status the transition started from.
+var ErrStatusChanged = errors.New("tour status changed concurrently")
+
+// TourSortField names a field tour listings can be ordered by.
+type TourSortField string
+
+const (
+	SortByPublishedAt TourSortField = "publishedAt"
+	SortByPrice       TourSortField = "price"
+	SortByDistance    TourSortField = "distance"
+	SortByRating      TourSortField = "rating"
+)
+
+// Valid reports whether field is one of the supported sort fields; the empty
+// field (no explicit order) is valid too.
+func (field TourSortField) Valid() bool {
+	switch field {
+	case "", SortByPublishedAt, SortByPrice, SortByDistance, SortByRating:
+		return true
 	}
-	defer cursor.Close(context.TODO())
+	return false
+}
 
-	var tours []model.Tour
-	for cursor.Next(context.TODO()) {
-		var tour model.Tour
-		if err := cursor.Decode(&tour); err != nil {
-			return nil, err
+// TourQuery selects one page of tours. Nil or empty filters are not applied;
+// Page is 1-based and both paging fields are expected to be normalised.
+// MaxDuration, in minutes, applies to the TransportType filter when both are
+// set, otherwise to any means of transport.
+type TourQuery struct {
+	Status        *model.TourStatus
+	Difficulty    *model.TourDifficulty
+	TransportType *model.TransportType
+	Tag           string
+	AuthorId      string
+	MinPrice      *float64
+	MaxPrice      *float64
+	MaxDuration   *float64
+	SortBy        TourSortField
+	Descending    bool
+	Page          int
+	PageSize      int
+	// CallerId is who the tours are listed for.
+	CallerId string
+}
+
+func (query TourQuery) filter() bson.M {
+	// draft revisions are pending edits, only listed for their author when
+	// they list their own tours
+	filter := bson.M{}
+	if query.AuthorId == "" || query.CallerId != query.AuthorId {
+		filter["revisionOf"] = bson.M{"$exists": false}
+	}
+	if query.Status != nil {
+		filter["status"] = *query.Status
+	}
+	if query.Difficulty != nil {
+		filter["difficulty"] = *query.Difficulty
+	}
+	switch {
+	case query.TransportType != nil && query.MaxDuration != nil:
+		filter["durations"] = bson.M{"$elemMatch": bson.M{
+			"transportType": *query.TransportType,
+			"minutes":       bson.M{"$lte": *query.MaxDuration},
+		}}
+	case query.TransportType != nil:
+		filter["durations.transportType"] = *query.TransportType
+	case query.MaxDuration != nil:
+		filter["durations.minutes"] = bson.M{"$lte": *query.MaxDuration}
+	}
+	if query.Tag != "" {
+		filter["tags"] = query.Tag
+	}
+	if query.AuthorId != "" {
+		filter["authorId"] = query.AuthorId
+	}
+	if query.MinPrice != nil || query.MaxPrice != nil {
+		price := bson.M{}
+		if query.MinPrice != nil {
+			price["$gte"] = *query.MinPrice
 		}
-		tours = append(tours, tour)
+		if query.MaxPrice != nil {
+			price["$lte"] = *query.MaxPrice
+		}
+		filter["price"] = price
 	}
+	return filter
+}
 
-	if err := cursor.Err(); err != nil {
-		return nil, err
+// sort orders by the requested field with _id as a tie-breaker, so pages stay stable.
+func (query TourQuery) sort() bson.D {
+	if query.SortBy == "" {
+		return bson.D{{Key: "_id", Value: 1}}
+	}
+	direction := 1
+	if query.Descending {
+		direction = -1
+	}
+	field := string(query.SortBy)
+	if query.SortBy == SortByRating {
+		field = "ratingStats.average"
+	}
+	return bson.D{{Key: field, Value: direction}, {Key: "_id", Value: 1}}
+}
+
+// Find returns the requested page together with the total number of matches.
+func (repo *TourRepository) Find(query TourQuery) ([]model.Tour, int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := query.filter()
+	total, err := repo.Collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
 	}
 
-	return tours, nil
+	opts := options.Find().
+		SetSort(query.sort()).
+		SetSkip(int64((query.Page - 1) * query.PageSize)).
+		SetLimit(int64(query.PageSize))
+	cursor, err := repo.Collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	tours := []model.Tour{}
+	if err := cursor.All(ctx, &tours); err != nil {
+		return nil, 0, err
+	}
+	return tours, total, nil
 }
 
-func (repo *TourRepository) GetAllByAuthor(authorId string) ([]model.Tour, error) {
-	filter := bson.M{"authorId": authorId} // assuming you store it as "user_id" in MongoDB
-	cursor, err := repo.Collection.Find(context.TODO(), filter)
+// GetPublishedByIds returns the published tours among ids, keyed by id.
+func (repo *TourRepository) GetPublishedByIds(ids []uuid.UUID) (map[uuid.UUID]model.Tour, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cursor, err := repo.Collection.Find(ctx, bson.M{"_id": bson.M{"$in": ids}, "status": model.Published})
 	if err != nil {
 		return nil, err
 	}
-	defer cursor.Close(context.TODO())
+	defer cursor.Close(ctx)
 
 	var tours []model.Tour
-	for cursor.Next(context.TODO()) {
-		var tour model.Tour
-		if err := cursor.Decode(&tour); err != nil {
-			return nil, err
-		}
-		tours = append(tours, tour)
-	}
-
-	if err := cursor.Err(); err != nil {
+	if err := cursor.All(ctx, &tours); err != nil {
 		return nil, err
 	}
 
-	return tours, nil
+	byId := make(map[uuid.UUID]model.Tour, len(tours))
+	for _, tour := range tours {
+		byId[tour.Id] = tour
+	}
+	return byId, nil
 }
 
 func (repo *TourRepository) Create(tour *model.Tour) error {
@@ -76,7 +188,7 @@ func (repo *TourRepository) Delete(id uuid.UUID) error {
 		return err
 	}
 	if res.DeletedCount == 0 {
-		return errors.New("tour not found")
+		return ErrTourNotFound
 	}
 	return nil
 }
@@ -84,18 +196,13 @@ func (repo *TourRepository) Delete(id uuid.UUID) error {
 func (repo *TourRepository) Update(id uuid.UUID, updatedTour model.Tour) error {
 	update := bson.M{
 		"$set": bson.M{
-			"authorId":      updatedTour.AuthorId,
-			"title":         updatedTour.Title,
-			"description":   updatedTour.Description,
-			"difficulty":    updatedTour.Difficulty,
-			"tags":          updatedTour.Tags,
-			"status":        updatedTour.Status,
-			"price":         updatedTour.Price,
-			"distance":      updatedTour.Distance,
-			"publishedAt":   updatedTour.PublishedAt,
-			"archivedAt":    updatedTour.ArchivedAt,
-			"duration":      updatedTour.Duration,
-			"transportType": updatedTour.TransportType,
+			"authorId":    updatedTour.AuthorId,
+			"title":       updatedTour.Title,
+			"description": updatedTour.Description,
+			"difficulty":  updatedTour.Difficulty,
+			"tags":        updatedTour.Tags,
+			"price":       updatedTour.Price,
+			"durations":   updatedTour.Durations,
 		},
 	}
 
@@ -109,13 +216,150 @@ func (repo *TourRepository) Update(id uuid.UUID, updatedTour model.Tour) error {
 	return nil
 }
 
+// GetDraftRevision returns the pending draft revision of a tour, or nil if
+// there is none.
+func (repo *TourRepository) GetDraftRevision(id uuid.UUID) (*model.Tour, error) {
+	var tour model.Tour
+	err := repo.Collection.FindOne(context.TODO(), bson.M{"revisionOf": id}).Decode(&tour)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &tour, nil
+}
+
+// ApplyRevision makes revision the published content of tour id, leaving its
+// author, status, publish date and rating stats alone.
+func (repo *TourRepository) ApplyRevision(id uuid.UUID, revision model.Tour) error {
+	update := bson.M{
+		"$set": bson.M{
+			"title":       revision.Title,
+			"description": revision.Description,
+			"difficulty":  revision.Difficulty,
+			"tags":        revision.Tags,
+			"price":       revision.Price,
+			"distance":    revision.Distance,
+			"durations":   revision.Durations,
+			"revision":    revision.Revision,
+		},
+	}
+
+	res, err := repo.Collection.UpdateOne(context.TODO(), bson.M{"_id": id}, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// UpdateStatus is the only way status and lifecycle timestamps are written;
+// TourService decides which transitions are allowed.
+func (repo *TourRepository) UpdateStatus(id uuid.UUID, from model.TourStatus, status model.TourStatus, publishedAt time.Time, archivedAt time.Time) error {
+	update := bson.M{
+		"$set": bson.M{
+			"status":      status,
+			"publishedAt": publishedAt,
+			"archivedAt":  archivedAt,
+		},
+	}
+	// only one of two concurrent transitions from the same status gets through
+	res, err := repo.Collection.UpdateOne(context.TODO(), bson.M{"_id": id, "status": from}, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrStatusChanged
+	}
+	return nil
+}
+
+// SetRevision records the number of the tour's live revision.
+func (repo *TourRepository) SetRevision(id uuid.UUID, revision int) error {
+	return repo.update(id, bson.M{"$set": bson.M{"revision": revision}})
+}
+
+func (repo *TourRepository) update(id uuid.UUID, update bson.M) error {
+	res, err := repo.Collection.UpdateOne(context.TODO(), bson.M{"_id": id}, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// UpdateDistance stores the route length computed from the tour's key points.
+func (repo *TourRepository) UpdateDistance(id uuid.UUID, distance float64) error {
+	res, err := repo.Collection.UpdateOne(context.TODO(), bson.M{"_id": id}, bson.M{"$set": bson.M{"distance": distance}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (repo *TourRepository) UpdateRatingStats(id uuid.UUID, stats model.RatingStats) error {
+	res, err := repo.Collection.UpdateOne(context.TODO(), bson.M{"_id": id}, bson.M{"$set": bson.M{"ratingStats": stats}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// InitMissingRatingStats gives tours stored before rating stats existed an empty summary.
+func (repo *TourRepository) InitMissingRatingStats() (int64, error) {
+	res, err := repo.Collection.UpdateMany(context.TODO(),
+		bson.M{"ratingStats": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"ratingStats": model.RatingStats{}}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.ModifiedCount, nil
+}
+
+// BackfillDurations turns the single duration and transport type of tours
+// stored before per-transport durations existed into a durations entry.
+func (repo *TourRepository) BackfillDurations() (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	res, err := repo.Collection.UpdateMany(ctx,
+		bson.M{"durations": bson.M{"$exists": false}},
+		mongo.Pipeline{
+			{{Key: "$set", Value: bson.M{
+				"durations": bson.M{"$cond": bson.A{
+					bson.M{"$gt": bson.A{"$duration", 0}},
+					bson.A{bson.M{"transportType": bson.M{"$ifNull": bson.A{"$transportType", model.Walking}}, "minutes": "$duration"}},
+					bson.A{},
+				}},
+			}}},
+			{{Key: "$unset", Value: bson.A{"duration", "transportType"}}},
+		},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.ModifiedCount, nil
+}
+
 func (repo *TourRepository) GetById(id uuid.UUID) (model.Tour, error) {
 	var tour model.Tour
 	filter := bson.M{"_id": id}
 	err := repo.Collection.FindOne(context.TODO(), filter).Decode(&tour)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			return model.Tour{}, errors.New("tour not found")
+			return model.Tour{}, ErrTourNotFound
 		}
 		return model.Tour{}, err
 	}
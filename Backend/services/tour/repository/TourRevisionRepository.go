@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"tour.xws.com/model"
+)
+
+var ErrTourRevisionNotFound = errors.New("tour revision not found")
+
+type TourRevisionRepository struct {
+	Collection *mongo.Collection
+}
+
+// Create stores a snapshot; a snapshot of the same revision is kept as it is.
+func (repo *TourRevisionRepository) Create(revision *model.TourRevision) error {
+	_, err := repo.Collection.InsertOne(context.TODO(), revision)
+	if mongo.IsDuplicateKeyError(err) {
+		return nil
+	}
+	return err
+}
+
+// Replace stores the snapshot, overwriting an earlier one of the same revision.
+func (repo *TourRevisionRepository) Replace(revision *model.TourRevision) error {
+	_, err := repo.Collection.UpdateOne(context.TODO(),
+		bson.M{"tourId": revision.TourId, "revision": revision.Revision},
+		bson.M{
+			"$set": bson.M{
+				"tour":        revision.Tour,
+				"keyPoints":   revision.KeyPoints,
+				"publishedAt": revision.PublishedAt,
+			},
+			"$setOnInsert": bson.M{"_id": revision.Id},
+		},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+func (repo *TourRevisionRepository) Get(tourId uuid.UUID, revision int) (*model.TourRevision, error) {
+	var snapshot model.TourRevision
+	err := repo.Collection.FindOne(context.TODO(), bson.M{"tourId": tourId, "revision": revision}).Decode(&snapshot)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrTourRevisionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+// GetAllByTour lists the tour's published revisions, newest first.
+func (repo *TourRevisionRepository) GetAllByTour(tourId uuid.UUID) ([]model.TourRevision, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "revision", Value: -1}})
+	cursor, err := repo.Collection.Find(context.TODO(), bson.M{"tourId": tourId}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.TODO())
+
+	revisions := []model.TourRevision{}
+	if err := cursor.All(context.TODO(), &revisions); err != nil {
+		return nil, err
+	}
+	return revisions, nil
+}
+
+// DeleteAllByTour removes every snapshot of the tour.
+func (repo *TourRevisionRepository) DeleteAllByTour(tourId uuid.UUID) error {
+	_, err := repo.Collection.DeleteMany(context.TODO(), bson.M{"tourId": tourId})
+	return err
+}
@@ -0,0 +1,42 @@
+package service
+
+import (
+	"errors"
+	"strings"
+
+	"tour.xws.com/model"
+)
+
+var ErrForbidden = errors.New("only the author of the tour can change it")
+
+// Caller is the authenticated user a request is made on behalf of, as
+// forwarded by the gateway.
+type Caller struct {
+	Id    string
+	Roles []string
+}
+
+func (c Caller) HasRole(role string) bool {
+	for _, r := range c.Roles {
+		if strings.EqualFold(strings.TrimSpace(r), role) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c Caller) IsAdmin() bool {
+	return c.HasRole("admin")
+}
+
+// authorizeAuthor lets only the tour's author (or an admin) mutate the tour
+// and its key points.
+func authorizeAuthor(tour model.Tour, caller Caller) error {
+	if caller.IsAdmin() {
+		return nil
+	}
+	if caller.Id == "" || caller.Id != tour.AuthorId {
+		return ErrForbidden
+	}
+	return nil
+}
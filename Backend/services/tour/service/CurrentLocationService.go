@@ -14,5 +14,11 @@ func (s *CurrentLocationService) Get(userId string) (*model.CurrentLocation, err
 }
 
 func (s *CurrentLocationService) Set(userId string, coords model.Coordinates) error {
+	var v validator
+	v.check(userId != "", "userId", "is required")
+	v.coordinates(coords, "coordinates")
+	if err := v.err(); err != nil {
+		return err
+	}
 	return s.Repo.Upsert(userId, coords)
 }
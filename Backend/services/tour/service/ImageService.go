@@ -0,0 +1,158 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log"
+	"path"
+
+	"github.com/google/uuid"
+	"github.com/zopuu/soa-team-20/Backend/pkg/blobstore"
+	"github.com/zopuu/soa-team-20/Backend/pkg/imaging"
+	"tour.xws.com/model"
+)
+
+var (
+	ErrImageNotFound        = errors.New("image not found")
+	ErrUnsupportedImageType = errors.New("Invalid image type. Only JPEG, PNG, GIF, and WebP are allowed")
+	ErrImageTooLarge        = errors.New("image dimensions are too large")
+)
+
+// ImageService keeps uploaded images in the blob store.
+type ImageService struct {
+	Store blobstore.Store
+}
+
+// Save stores the image read from r under a new key below prefix, together
+// with its thumbnail and medium variants. The type is detected from the
+// content, and metadata such as EXIF location tags is removed.
+//
+// The upload is read into memory whole. Stripping the metadata rewrites the
+// original, and the variants are rendered from the decoded image, which at
+// up to imaging.MaxPixels outweighs the encoded file, so streaming the bytes
+// would not lower the peak. Callers must bound r: the HTTP handlers wrap the
+// body in http.MaxBytesReader and gRPC messages are capped by the server's
+// receive limit.
+func (service *ImageService) Save(ctx context.Context, prefix, filename string, r io.Reader) (model.Image, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return model.Image{}, err
+	}
+	processed, err := imaging.Process(data)
+	if errors.Is(err, imaging.ErrUnsupported) {
+		return model.Image{}, ErrUnsupportedImageType
+	}
+	if errors.Is(err, imaging.ErrTooLarge) {
+		return model.Image{}, ErrImageTooLarge
+	}
+	if err != nil {
+		return model.Image{}, err
+	}
+
+	key := path.Join(prefix, uuid.New().String())
+	object, err := service.put(ctx, key, processed.Original)
+	if err != nil {
+		return model.Image{}, err
+	}
+	image := model.Image{
+		Key:      object.Key,
+		MimeType: processed.Original.ContentType,
+		Filename: filename,
+		Size:     object.Size,
+		Checksum: object.Checksum,
+	}
+
+	for size, encoded := range processed.Variants {
+		variant, err := service.put(ctx, key+"-"+string(size), encoded)
+		if err != nil {
+			service.Delete(image)
+			return model.Image{}, err
+		}
+		if image.Variants == nil {
+			image.Variants = map[string]model.ImageVariant{}
+		}
+		image.Variants[string(size)] = model.ImageVariant{Key: variant.Key, MimeType: encoded.ContentType, Size: variant.Size, Checksum: variant.Checksum}
+	}
+	return image, nil
+}
+
+func (service *ImageService) put(ctx context.Context, key string, encoded imaging.Encoded) (blobstore.Object, error) {
+	return service.Store.Put(ctx, key, bytes.NewReader(encoded.Data), int64(len(encoded.Data)), encoded.ContentType)
+}
+
+// Open returns the content of the requested rendition; the caller must close it.
+func (service *ImageService) Open(ctx context.Context, image model.Image, size imaging.Size) (io.ReadSeekCloser, model.ImageVariant, error) {
+	if image.IsEmpty() {
+		return nil, model.ImageVariant{}, ErrImageNotFound
+	}
+	variant := image.Variant(string(size))
+	content, _, err := service.Store.Get(ctx, variant.Key)
+	if errors.Is(err, blobstore.ErrNotFound) {
+		return nil, model.ImageVariant{}, ErrImageNotFound
+	}
+	return content, variant, err
+}
+
+// Delete removes images that are no longer referenced, variants included.
+// Failures are only logged: an orphaned blob is harmless, while failing the
+// request that already changed the document would not be.
+func (service *ImageService) Delete(images ...model.Image) {
+	for _, image := range images {
+		if image.IsEmpty() {
+			continue
+		}
+		keys := []string{image.Key}
+		for _, variant := range image.Variants {
+			keys = append(keys, variant.Key)
+		}
+		for _, key := range keys {
+			if err := service.Store.Delete(context.Background(), key); err != nil {
+				log.Printf("failed to delete image %s: %v", key, err)
+			}
+		}
+	}
+}
+
+// Copy duplicates an image, variants included, under a new key below prefix,
+// so the copy can be changed or deleted independently of the original.
+func (service *ImageService) Copy(ctx context.Context, prefix string, image model.Image) (model.Image, error) {
+	if image.IsEmpty() {
+		return image, nil
+	}
+	key := path.Join(prefix, uuid.New().String())
+	copied := image
+	object, err := service.copy(ctx, image.Key, key, image.MimeType)
+	if err != nil {
+		return model.Image{}, err
+	}
+	copied.Key = object.Key
+	copied.Variants = nil
+
+	for size, variant := range image.Variants {
+		object, err := service.copy(ctx, variant.Key, key+"-"+size, variant.MimeType)
+		if err != nil {
+			service.Delete(copied)
+			return model.Image{}, err
+		}
+		if copied.Variants == nil {
+			copied.Variants = map[string]model.ImageVariant{}
+		}
+		variant.Key = object.Key
+		copied.Variants[size] = variant
+	}
+	return copied, nil
+}
+
+func (service *ImageService) copy(ctx context.Context, from, to, contentType string) (blobstore.Object, error) {
+	content, object, err := service.Store.Get(ctx, from)
+	if errors.Is(err, blobstore.ErrNotFound) {
+		return blobstore.Object{}, ErrImageNotFound
+	}
+	if err != nil {
+		return blobstore.Object{}, err
+	}
+	defer content.Close()
+	return service.Store.Put(ctx, to, content, object.Size, contentType)
+}
@@ -1,43 +1,262 @@
 package service
 
 import (
+	"errors"
+	"log"
+
 	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/mongo"
 	"tour.xws.com/model"
 	"tour.xws.com/repository"
 )
 
+var (
+	ErrInvalidKeyPointOrder = errors.New("key point order must list every key point of the tour exactly once")
+	ErrTourNotPurchased     = errors.New("tour has to be purchased to see all of its key points")
+	ErrTourNotEditable      = errors.New("key points of a published tour can only be changed in a draft revision")
+)
+
 type KeyPointService struct {
 	KeyPointRepository *repository.KeyPointRepository
+	TourRepository     *repository.TourRepository
+	TokenRepository    *repository.TourPurchaseTokenRepository
+	Images             *ImageService
 }
 
 func (service *KeyPointService) GetAllKeyPoints() ([]model.KeyPoint, error) {
 	return service.KeyPointRepository.GetAll()
 }
 
-func (service *KeyPointService) GetAllByTour(tourId uuid.UUID) ([]model.KeyPoint, error) {
-	return service.KeyPointRepository.GetAllByTour(tourId)
+// GetAllByTour returns the tour's route as viewerId is allowed to see it:
+// the full route for the author and buyers, only the first key point otherwise.
+func (service *KeyPointService) GetAllByTour(tourId uuid.UUID, viewerId string) ([]model.KeyPoint, error) {
+	keyPoints, err := service.KeyPointRepository.GetAllByTour(tourId)
+	if err != nil {
+		return nil, err
+	}
+	return service.visibleKeyPoints(tourId, viewerId, keyPoints)
 }
 
-func (service *KeyPointService) GetAllByTourSortedByCreatedAt(tourId uuid.UUID) ([]model.KeyPoint, error) {
-	return service.KeyPointRepository.GetAllByTourSortedByCreatedAt(tourId)
+func (service *KeyPointService) GetAllByTourSortedByCreatedAt(tourId uuid.UUID, viewerId string) ([]model.KeyPoint, error) {
+	keyPoints, err := service.KeyPointRepository.GetAllByTourSortedByCreatedAt(tourId)
+	if err != nil {
+		return nil, err
+	}
+	return service.visibleKeyPoints(tourId, viewerId, keyPoints)
 }
 
 func (service *KeyPointService) GetById(id uuid.UUID) (*model.KeyPoint, error) {
 	return service.KeyPointRepository.GetById(id)
 }
 
-func (service *KeyPointService) Create(keyPoint *model.KeyPoint) error {
-	err := service.KeyPointRepository.Create(model.BeforeCreateKeyPoint(keyPoint.TourId, keyPoint.Coordinates, keyPoint.Title, keyPoint.Description, keyPoint.Image))
+// GetDetail returns a single key point if viewerId may see it.
+func (service *KeyPointService) GetDetail(id uuid.UUID, viewerId string) (*model.KeyPoint, error) {
+	keyPoint, err := service.KeyPointRepository.GetById(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := service.authorizeView(keyPoint, viewerId); err != nil {
+		return nil, err
+	}
+	return keyPoint, nil
+}
+
+// GetImage returns the image reference of a key point if viewerId may see it,
+// without loading the rest of the key point.
+func (service *KeyPointService) GetImage(id uuid.UUID, viewerId string) (model.Image, error) {
+	keyPoint, err := service.KeyPointRepository.GetImageById(id)
+	if err != nil {
+		return model.Image{}, err
+	}
+	if err := service.authorizeView(keyPoint, viewerId); err != nil {
+		return model.Image{}, err
+	}
+	return keyPoint.Image, nil
+}
+
+// authorizeView lets the tour's author and buyers see every key point, and
+// everyone else only the starting one.
+func (service *KeyPointService) authorizeView(keyPoint *model.KeyPoint, viewerId string) error {
+	fullAccess, err := service.HasFullAccess(keyPoint.TourId, viewerId)
 	if err != nil {
 		return err
 	}
-	return nil
+	if fullAccess {
+		return nil
+	}
+
+	firstId, err := service.KeyPointRepository.GetFirstIdOfTour(keyPoint.TourId)
+	if err != nil {
+		return err
+	}
+	if firstId == keyPoint.Id {
+		return nil
+	}
+	return ErrTourNotPurchased
+}
+
+// HasFullAccess reports whether viewerId authored or bought the tour.
+func (service *KeyPointService) HasFullAccess(tourId uuid.UUID, viewerId string) (bool, error) {
+	if viewerId == "" {
+		return false, nil
+	}
+	tour, err := service.TourRepository.GetById(tourId)
+	if err != nil {
+		return false, err
+	}
+	if tour.AuthorId == viewerId {
+		return true, nil
+	}
+	return service.TokenRepository.Exists(viewerId, tourId)
+}
+
+func (service *KeyPointService) visibleKeyPoints(tourId uuid.UUID, viewerId string, keyPoints []model.KeyPoint) ([]model.KeyPoint, error) {
+	if len(keyPoints) == 0 {
+		return keyPoints, nil
+	}
+	fullAccess, err := service.HasFullAccess(tourId, viewerId)
+	if err != nil {
+		return nil, err
+	}
+	if fullAccess {
+		return keyPoints, nil
+	}
+	return keyPoints[:1], nil
+}
+
+// Create appends the key point to the end of its tour's route.
+func (service *KeyPointService) Create(keyPoint *model.KeyPoint, caller Caller) error {
+	if err := validateKeyPoint(*keyPoint, true); err != nil {
+		return err
+	}
+	err := service.authorize(keyPoint.TourId, caller)
+	if errors.Is(err, repository.ErrTourNotFound) {
+		return InvalidField("tourId", "does not exist")
+	}
+	if err != nil {
+		return err
+	}
+	existing, err := service.KeyPointRepository.GetAllByTour(keyPoint.TourId)
+	if err != nil {
+		return err
+	}
+
+	newKeyPoint := model.BeforeCreateKeyPoint(keyPoint.TourId, keyPoint.Coordinates, keyPoint.Title, keyPoint.Description, keyPoint.Image)
+	if len(existing) > 0 {
+		newKeyPoint.Order = existing[len(existing)-1].Order + 1
+	}
+	if err := service.KeyPointRepository.Create(newKeyPoint); err != nil {
+		return err
+	}
+	*keyPoint = *newKeyPoint
+	return service.refreshTourDistance(keyPoint.TourId)
+}
+
+// Reorder sets the route order of a tour's key points. orderedIds must list
+// every key point of the tour exactly once.
+func (service *KeyPointService) Reorder(tourId uuid.UUID, orderedIds []uuid.UUID, caller Caller) ([]model.KeyPoint, error) {
+	if err := service.authorize(tourId, caller); err != nil {
+		return nil, err
+	}
+	existing, err := service.KeyPointRepository.GetAllByTour(tourId)
+	if err != nil {
+		return nil, err
+	}
+	if len(orderedIds) != len(existing) {
+		return nil, ErrInvalidKeyPointOrder
+	}
+
+	remaining := make(map[uuid.UUID]bool, len(existing))
+	for _, keyPoint := range existing {
+		remaining[keyPoint.Id] = true
+	}
+	for _, id := range orderedIds {
+		if !remaining[id] {
+			return nil, ErrInvalidKeyPointOrder
+		}
+		delete(remaining, id)
+	}
+
+	if err := service.KeyPointRepository.SetOrder(tourId, orderedIds); err != nil {
+		return nil, err
+	}
+	if err := service.refreshTourDistance(tourId); err != nil {
+		return nil, err
+	}
+	return service.KeyPointRepository.GetAllByTour(tourId)
 }
 
-func (service *KeyPointService) Delete(id uuid.UUID) error {
-	return service.KeyPointRepository.Delete(id)
+func (service *KeyPointService) Delete(id uuid.UUID, caller Caller) error {
+	keyPoint, err := service.KeyPointRepository.GetById(id)
+	if err != nil {
+		return err
+	}
+	if err := service.authorize(keyPoint.TourId, caller); err != nil {
+		return err
+	}
+	if err := service.KeyPointRepository.Delete(id); err != nil {
+		return err
+	}
+	service.Images.Delete(keyPoint.Image)
+	return service.refreshTourDistance(keyPoint.TourId)
 }
 
-func (service *KeyPointService) Update(id uuid.UUID, updatedKeyPoint model.KeyPoint) error {
-	return service.KeyPointRepository.Update(id, updatedKeyPoint)
+// Update replaces the key point's details; an empty image keeps the stored one.
+func (service *KeyPointService) Update(id uuid.UUID, updatedKeyPoint model.KeyPoint, caller Caller) error {
+	if err := validateKeyPoint(updatedKeyPoint, false); err != nil {
+		return err
+	}
+	keyPoint, err := service.KeyPointRepository.GetById(id)
+	if err != nil {
+		return err
+	}
+	if err := service.authorize(keyPoint.TourId, caller); err != nil {
+		return err
+	}
+	if updatedKeyPoint.Image.IsEmpty() {
+		updatedKeyPoint.Image = keyPoint.Image
+	}
+	if err := service.KeyPointRepository.Update(id, updatedKeyPoint); err != nil {
+		return err
+	}
+	if keyPoint.Image.Key != updatedKeyPoint.Image.Key {
+		service.Images.Delete(keyPoint.Image)
+	}
+	return service.refreshTourDistance(keyPoint.TourId)
+}
+
+// authorize checks that caller may change the key points of tourId. Only
+// drafts can be changed, so tourists on a published route never see it move.
+func (service *KeyPointService) authorize(tourId uuid.UUID, caller Caller) error {
+	tour, err := service.TourRepository.GetById(tourId)
+	if err != nil {
+		return err
+	}
+	if err := authorizeAuthor(tour, caller); err != nil {
+		return err
+	}
+	if tour.Status != model.Draft {
+		return ErrTourNotEditable
+	}
+	return nil
+}
+
+// refreshTourDistance recomputes the tour's route length from its stored key points,
+// so Tour.Distance never drifts from what the map actually shows.
+func (service *KeyPointService) refreshTourDistance(tourId uuid.UUID) error {
+	keyPoints, err := service.KeyPointRepository.GetAllByTour(tourId)
+	if err != nil {
+		return err
+	}
+	distance := model.RouteDistance(keyPoints)
+	err = service.TourRepository.UpdateDistance(tourId, distance)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		// orphaned key points (tour already deleted) have no distance to maintain
+		return nil
+	}
+	if err != nil {
+		log.Printf("Failed to update distance of tour %s: %v", tourId, err)
+		return err
+	}
+	return nil
 }
@@ -0,0 +1,296 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"sort"
+
+	"tour.xws.com/model"
+)
+
+// RouteFormat is a file format tours can be exported to and imported from.
+type RouteFormat string
+
+const (
+	GPX     RouteFormat = "gpx"
+	GeoJSON RouteFormat = "geojson"
+)
+
+// maxImportedKeyPoints keeps a dense track from turning into thousands of key points.
+const maxImportedKeyPoints = 500
+
+var (
+	ErrUnsupportedRouteFormat = errors.New("unsupported route format, expected gpx or geojson")
+	ErrInvalidRouteFile       = errors.New("invalid route file")
+)
+
+// ContentType is the media type of files in the format.
+func (format RouteFormat) ContentType() string {
+	if format == GeoJSON {
+		return "application/geo+json"
+	}
+	return "application/gpx+xml"
+}
+
+// Extension is the usual file name extension of the format.
+func (format RouteFormat) Extension() string {
+	if format == GeoJSON {
+		return ".geojson"
+	}
+	return ".gpx"
+}
+
+// ParseRouteFormat accepts the format names used in query parameters.
+func ParseRouteFormat(raw string) (RouteFormat, error) {
+	switch RouteFormat(raw) {
+	case GPX, GeoJSON:
+		return RouteFormat(raw), nil
+	}
+	return "", ErrUnsupportedRouteFormat
+}
+
+// route is a tour's route as read from or written to a file. Only the
+// coordinates, titles and descriptions of its key points are used.
+type route struct {
+	Title       string
+	Description string
+	KeyPoints   []model.KeyPoint
+}
+
+func encodeRoute(format RouteFormat, r route) ([]byte, error) {
+	switch format {
+	case GPX:
+		return encodeGPX(r)
+	case GeoJSON:
+		return encodeGeoJSON(r)
+	}
+	return nil, ErrUnsupportedRouteFormat
+}
+
+// decodeRoute reads a GPX or GeoJSON file, telling them apart by their content.
+func decodeRoute(data []byte) (route, error) {
+	trimmed := bytes.TrimLeft(data, " \t\r\n\ufeff")
+	if len(trimmed) == 0 {
+		return route{}, fmt.Errorf("%w: file is empty", ErrInvalidRouteFile)
+	}
+
+	var r route
+	var err error
+	switch trimmed[0] {
+	case '<':
+		r, err = decodeGPX(trimmed)
+	case '{':
+		r, err = decodeGeoJSON(trimmed)
+	default:
+		return route{}, ErrUnsupportedRouteFormat
+	}
+	if err != nil {
+		return route{}, err
+	}
+
+	if len(r.KeyPoints) == 0 {
+		return route{}, fmt.Errorf("%w: no points found", ErrInvalidRouteFile)
+	}
+	if len(r.KeyPoints) > maxImportedKeyPoints {
+		return route{}, fmt.Errorf("%w: more than %d points", ErrInvalidRouteFile, maxImportedKeyPoints)
+	}
+	for _, keyPoint := range r.KeyPoints {
+		if !keyPoint.Coordinates.Valid() {
+			return route{}, fmt.Errorf("%w: coordinates out of range", ErrInvalidRouteFile)
+		}
+	}
+	return r, nil
+}
+
+type gpxFile struct {
+	XMLName   xml.Name     `xml:"gpx"`
+	Version   string       `xml:"version,attr,omitempty"`
+	Creator   string       `xml:"creator,attr,omitempty"`
+	Namespace string       `xml:"xmlns,attr,omitempty"`
+	Metadata  *gpxMetadata `xml:"metadata"`
+	Waypoints []gpxPoint   `xml:"wpt"`
+	Routes    []gpxRoute   `xml:"rte"`
+}
+
+type gpxMetadata struct {
+	Name string `xml:"name,omitempty"`
+	Desc string `xml:"desc,omitempty"`
+}
+
+type gpxRoute struct {
+	Name   string     `xml:"name,omitempty"`
+	Desc   string     `xml:"desc,omitempty"`
+	Points []gpxPoint `xml:"rtept"`
+}
+
+type gpxPoint struct {
+	Lat  float64 `xml:"lat,attr"`
+	Lon  float64 `xml:"lon,attr"`
+	Name string  `xml:"name,omitempty"`
+	Desc string  `xml:"desc,omitempty"`
+}
+
+func encodeGPX(r route) ([]byte, error) {
+	file := gpxFile{
+		Version:   "1.1",
+		Creator:   "tour-service",
+		Namespace: "http://www.topografix.com/GPX/1/1",
+		Metadata:  &gpxMetadata{Name: r.Title, Desc: r.Description},
+		Waypoints: make([]gpxPoint, 0, len(r.KeyPoints)),
+	}
+	for _, keyPoint := range r.KeyPoints {
+		file.Waypoints = append(file.Waypoints, gpxPoint{
+			Lat:  keyPoint.Coordinates.Latitude,
+			Lon:  keyPoint.Coordinates.Longitude,
+			Name: keyPoint.Title,
+			Desc: keyPoint.Description,
+		})
+	}
+
+	out, err := xml.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), out...), nil
+}
+
+// decodeGPX takes the waypoints in document order, or the points of the first
+// route when the file has no waypoints. Tracks are not imported.
+func decodeGPX(data []byte) (route, error) {
+	var file gpxFile
+	if err := xml.Unmarshal(data, &file); err != nil {
+		return route{}, fmt.Errorf("%w: %v", ErrInvalidRouteFile, err)
+	}
+
+	var r route
+	if file.Metadata != nil {
+		r.Title, r.Description = file.Metadata.Name, file.Metadata.Desc
+	}
+	points := file.Waypoints
+	if len(points) == 0 && len(file.Routes) > 0 {
+		points = file.Routes[0].Points
+		if r.Title == "" {
+			r.Title, r.Description = file.Routes[0].Name, file.Routes[0].Desc
+		}
+	}
+	for _, point := range points {
+		r.KeyPoints = append(r.KeyPoints, model.KeyPoint{
+			Coordinates: model.Coordinates{Latitude: point.Lat, Longitude: point.Lon},
+			Title:       point.Name,
+			Description: point.Desc,
+		})
+	}
+	return r, nil
+}
+
+// geoJSONCollection is a FeatureCollection of Point features. The tour's
+// title and description travel as foreign members.
+type geoJSONCollection struct {
+	Type        string           `json:"type"`
+	Title       string           `json:"title,omitempty"`
+	Description string           `json:"description,omitempty"`
+	Features    []geoJSONFeature `json:"features"`
+}
+
+type geoJSONFeature struct {
+	Type       string            `json:"type"`
+	Geometry   *geoJSONGeometry  `json:"geometry"`
+	Properties geoJSONProperties `json:"properties"`
+}
+
+type geoJSONGeometry struct {
+	Type        string          `json:"type"`
+	Coordinates json.RawMessage `json:"coordinates"`
+}
+
+// geoJSONProperties also accepts name and desc, as written by other tools.
+type geoJSONProperties struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Order       *int   `json:"order,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Desc        string `json:"desc,omitempty"`
+}
+
+func encodeGeoJSON(r route) ([]byte, error) {
+	collection := geoJSONCollection{
+		Type:        "FeatureCollection",
+		Title:       r.Title,
+		Description: r.Description,
+		Features:    make([]geoJSONFeature, 0, len(r.KeyPoints)),
+	}
+	for i, keyPoint := range r.KeyPoints {
+		coordinates, err := json.Marshal([]float64{keyPoint.Coordinates.Longitude, keyPoint.Coordinates.Latitude})
+		if err != nil {
+			return nil, err
+		}
+		order := i
+		collection.Features = append(collection.Features, geoJSONFeature{
+			Type:       "Feature",
+			Geometry:   &geoJSONGeometry{Type: "Point", Coordinates: coordinates},
+			Properties: geoJSONProperties{Title: keyPoint.Title, Description: keyPoint.Description, Order: &order},
+		})
+	}
+	return json.MarshalIndent(collection, "", "  ")
+}
+
+// decodeGeoJSON takes the Point features, sorted by their order property when
+// every one of them has it and in document order otherwise.
+func decodeGeoJSON(data []byte) (route, error) {
+	var collection geoJSONCollection
+	if err := json.Unmarshal(data, &collection); err != nil {
+		return route{}, fmt.Errorf("%w: %v", ErrInvalidRouteFile, err)
+	}
+	if collection.Type != "FeatureCollection" {
+		return route{}, fmt.Errorf("%w: expected a FeatureCollection", ErrInvalidRouteFile)
+	}
+
+	r := route{Title: collection.Title, Description: collection.Description}
+	var orders []int
+	for _, feature := range collection.Features {
+		if feature.Geometry == nil || feature.Geometry.Type != "Point" {
+			continue
+		}
+		var position []float64
+		if err := json.Unmarshal(feature.Geometry.Coordinates, &position); err != nil || len(position) < 2 {
+			return route{}, fmt.Errorf("%w: invalid point coordinates", ErrInvalidRouteFile)
+		}
+
+		properties := feature.Properties
+		keyPoint := model.KeyPoint{
+			Coordinates: model.Coordinates{Latitude: position[1], Longitude: position[0]},
+			Title:       firstNonEmpty(properties.Title, properties.Name),
+			Description: firstNonEmpty(properties.Description, properties.Desc),
+		}
+		r.KeyPoints = append(r.KeyPoints, keyPoint)
+		if properties.Order != nil {
+			orders = append(orders, *properties.Order)
+		}
+	}
+
+	if len(orders) == len(r.KeyPoints) {
+		indexes := make([]int, len(r.KeyPoints))
+		for i := range indexes {
+			indexes[i] = i
+		}
+		sort.SliceStable(indexes, func(a, b int) bool { return orders[indexes[a]] < orders[indexes[b]] })
+		sorted := make([]model.KeyPoint, 0, len(r.KeyPoints))
+		for _, i := range indexes {
+			sorted = append(sorted, r.KeyPoints[i])
+		}
+		r.KeyPoints = sorted
+	}
+	return r, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"tour.xws.com/model"
+)
+
+func testRoute() route {
+	return route{
+		Title:       "Old town & river <walk>",
+		Description: "Čaršija, the fortress and back along the Danube",
+		KeyPoints: []model.KeyPoint{
+			{Coordinates: model.Coordinates{Latitude: 45.2551, Longitude: 19.8452}, Title: "Start", Description: "Freedom square"},
+			{Coordinates: model.Coordinates{Latitude: 45.2517, Longitude: 19.8619}},
+			{Coordinates: model.Coordinates{Latitude: -33.8568, Longitude: 151.2153}, Title: "Far away", Description: "Negative \"latitude\""},
+			{Coordinates: model.Coordinates{Latitude: 45.2465, Longitude: 19.8524}, Description: "unnamed, described"},
+		},
+	}
+}
+
+func TestRouteRoundTrip(t *testing.T) {
+	for _, format := range []RouteFormat{GPX, GeoJSON} {
+		t.Run(string(format), func(t *testing.T) {
+			want := testRoute()
+			data, err := encodeRoute(format, want)
+			if err != nil {
+				t.Fatalf("encode: %v", err)
+			}
+			got, err := decodeRoute(data)
+			if err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("round trip changed the route\n got: %+v\nwant: %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestDecodeGeoJSONSortsByOrder(t *testing.T) {
+	want := testRoute()
+	data, err := encodeRoute(GeoJSON, want)
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+
+	var collection map[string]any
+	if err := json.Unmarshal(data, &collection); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	features := collection["features"].([]any)
+	shuffled := []any{features[2], features[0], features[3], features[1]}
+	collection["features"] = shuffled
+	if data, err = json.Marshal(collection); err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+
+	got, err := decodeRoute(data)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("key points not restored to their order\n got: %+v\nwant: %+v", got.KeyPoints, want.KeyPoints)
+	}
+}
+
+func TestDecodeGeoJSONWithoutOrderKeepsDocumentOrder(t *testing.T) {
+	data := []byte(`{
+		"type": "FeatureCollection",
+		"features": [
+			{"type": "Feature", "geometry": {"type": "Point", "coordinates": [19.1, 45.1]}, "properties": {"name": "B", "order": 1}},
+			{"type": "Feature", "geometry": {"type": "LineString", "coordinates": [[19, 45], [20, 46]]}, "properties": {}},
+			{"type": "Feature", "geometry": {"type": "Point", "coordinates": [19.2, 45.2]}, "properties": {"name": "A", "desc": "no order"}}
+		]
+	}`)
+
+	got, err := decodeRoute(data)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	want := []model.KeyPoint{
+		{Coordinates: model.Coordinates{Latitude: 45.1, Longitude: 19.1}, Title: "B"},
+		{Coordinates: model.Coordinates{Latitude: 45.2, Longitude: 19.2}, Title: "A", Description: "no order"},
+	}
+	if !reflect.DeepEqual(got.KeyPoints, want) {
+		t.Errorf("got %+v, want %+v", got.KeyPoints, want)
+	}
+}
+
+func TestDecodeGPXFallsBackToFirstRoute(t *testing.T) {
+	data := []byte(`<?xml version="1.0"?>
+<gpx version="1.1" xmlns="http://www.topografix.com/GPX/1/1">
+  <rte>
+    <name>Route name</name>
+    <desc>Route description</desc>
+    <rtept lat="45.1" lon="19.1"><name>First</name></rtept>
+    <rtept lat="45.2" lon="19.2"></rtept>
+  </rte>
+</gpx>`)
+
+	got, err := decodeRoute(data)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	want := route{
+		Title:       "Route name",
+		Description: "Route description",
+		KeyPoints: []model.KeyPoint{
+			{Coordinates: model.Coordinates{Latitude: 45.1, Longitude: 19.1}, Title: "First"},
+			{Coordinates: model.Coordinates{Latitude: 45.2, Longitude: 19.2}},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
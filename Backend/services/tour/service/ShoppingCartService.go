@@ -0,0 +1,119 @@
+package service
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"tour.xws.com/model"
+	"tour.xws.com/repository"
+)
+
+var (
+	ErrTourNotForSale       = errors.New("only published tours can be bought")
+	ErrTourAlreadyInCart    = errors.New("tour is already in the shopping cart")
+	ErrTourAlreadyPurchased = errors.New("tour has already been purchased")
+	ErrTourNotInCart        = errors.New("tour is not in the shopping cart")
+	ErrCartEmpty            = errors.New("shopping cart is empty")
+)
+
+type ShoppingCartService struct {
+	CartRepository  *repository.ShoppingCartRepository
+	TokenRepository *repository.TourPurchaseTokenRepository
+	TourRepository  *repository.TourRepository
+}
+
+func (s *ShoppingCartService) GetCart(touristId string) (*model.ShoppingCart, error) {
+	return s.CartRepository.GetByTourist(touristId)
+}
+
+func (s *ShoppingCartService) GetPurchases(touristId string) ([]model.TourPurchaseToken, error) {
+	return s.TokenRepository.GetByTourist(touristId)
+}
+
+func (s *ShoppingCartService) AddItem(touristId string, tourId uuid.UUID) (*model.ShoppingCart, error) {
+	tour, err := s.TourRepository.GetById(tourId)
+	if err != nil {
+		return nil, err
+	}
+	if tour.Status != model.Published {
+		return nil, ErrTourNotForSale
+	}
+
+	purchased, err := s.TokenRepository.Exists(touristId, tourId)
+	if err != nil {
+		return nil, err
+	}
+	if purchased {
+		return nil, ErrTourAlreadyPurchased
+	}
+
+	cart, err := s.CartRepository.GetByTourist(touristId)
+	if err != nil {
+		return nil, err
+	}
+	if cart.Contains(tourId) {
+		return nil, ErrTourAlreadyInCart
+	}
+
+	cart.Items = append(cart.Items, model.OrderItem{TourId: tour.Id, TourName: tour.Title, Price: tour.Price})
+	cart.RecalculateTotal()
+	return cart, s.CartRepository.Save(cart)
+}
+
+func (s *ShoppingCartService) RemoveItem(touristId string, tourId uuid.UUID) (*model.ShoppingCart, error) {
+	cart, err := s.CartRepository.GetByTourist(touristId)
+	if err != nil {
+		return nil, err
+	}
+	if !cart.Contains(tourId) {
+		return nil, ErrTourNotInCart
+	}
+
+	items := make([]model.OrderItem, 0, len(cart.Items)-1)
+	for _, item := range cart.Items {
+		if item.TourId != tourId {
+			items = append(items, item)
+		}
+	}
+	cart.Items = items
+	cart.RecalculateTotal()
+	return cart, s.CartRepository.Save(cart)
+}
+
+// Checkout issues a purchase token for every tour in the cart that is still
+// for sale and empties the cart.
+func (s *ShoppingCartService) Checkout(touristId string) ([]*model.TourPurchaseToken, error) {
+	cart, err := s.CartRepository.GetByTourist(touristId)
+	if err != nil {
+		return nil, err
+	}
+	if len(cart.Items) == 0 {
+		return nil, ErrCartEmpty
+	}
+
+	purchasedAt := time.Now().UTC()
+	tokens := make([]*model.TourPurchaseToken, 0, len(cart.Items))
+	for _, item := range cart.Items {
+		tour, err := s.TourRepository.GetById(item.TourId)
+		if err != nil || tour.Status != model.Published {
+			continue
+		}
+		purchased, err := s.TokenRepository.Exists(touristId, item.TourId)
+		if err != nil {
+			return nil, err
+		}
+		if purchased {
+			continue
+		}
+		tokens = append(tokens, model.BeforeCreateTourPurchaseToken(touristId, item, purchasedAt))
+	}
+
+	if err := s.TokenRepository.CreateMany(tokens); err != nil {
+		return nil, err
+	}
+
+	cart.Items = []model.OrderItem{}
+	cart.RecalculateTotal()
+	return tokens, s.CartRepository.Save(cart)
+}
@@ -0,0 +1,205 @@
+package service
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/mongo"
+	"tour.xws.com/model"
+	"tour.xws.com/repository"
+)
+
+var (
+	ErrTourNotStartable       = errors.New("only published or archived tours can be started")
+	ErrExecutionAlreadyActive = errors.New("tourist already has an active tour execution")
+	ErrExecutionNotActive     = errors.New("tour execution is not active")
+	ErrExecutionIncomplete    = errors.New("not all key points of the tour have been reached")
+	ErrNotExecutionOwner      = errors.New("tour execution belongs to another tourist")
+)
+
+type TourExecutionService struct {
+	Repo               *repository.TourExecutionRepository
+	TourRepository     *repository.TourRepository
+	KeyPointRepository *repository.KeyPointRepository
+	LocationRepository *repository.CurrentLocationRepository
+	TokenRepository    *repository.TourPurchaseTokenRepository
+	RevisionRepository *repository.TourRevisionRepository
+	// ProximityRadius is how close, in metres, a tourist has to get to the
+	// next key point for it to count as reached.
+	ProximityRadius float64
+}
+
+// GetById returns one of the tourist's own executions.
+func (s *TourExecutionService) GetById(id uuid.UUID, touristId string) (*model.TourExecution, error) {
+	return s.owned(id, touristId)
+}
+
+func (s *TourExecutionService) GetActive(touristId string) (*model.TourExecution, error) {
+	return s.Repo.GetActiveByTourist(touristId)
+}
+
+// Start begins a purchased tour from the tourist's last simulated position.
+func (s *TourExecutionService) Start(touristId string, tourId uuid.UUID) (*model.TourExecution, error) {
+	tour, err := s.TourRepository.GetById(tourId)
+	if err != nil {
+		return nil, err
+	}
+	if tour.Status != model.Published && tour.Status != model.Archived {
+		return nil, ErrTourNotStartable
+	}
+	purchased, err := s.TokenRepository.Exists(touristId, tourId)
+	if err != nil {
+		return nil, err
+	}
+	if !purchased {
+		return nil, ErrTourNotPurchased
+	}
+
+	active, err := s.Repo.GetActiveByTourist(touristId)
+	if err != nil {
+		return nil, err
+	}
+	if active != nil {
+		return nil, ErrExecutionAlreadyActive
+	}
+
+	var startLocation model.Coordinates
+	location, err := s.LocationRepository.GetByUserId(touristId)
+	if err != nil {
+		return nil, err
+	}
+	if location != nil {
+		startLocation = location.Coordinates
+	}
+
+	execution := model.BeforeStartTourExecution(tourId, tour.Revision, touristId, startLocation)
+	if err := s.Repo.Create(execution); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			// started concurrently
+			return nil, ErrExecutionAlreadyActive
+		}
+		return nil, err
+	}
+	if location == nil {
+		return execution, nil
+	}
+	return s.advance(execution, startLocation)
+}
+
+func (s *TourExecutionService) Abandon(id uuid.UUID, touristId string) (*model.TourExecution, error) {
+	execution, err := s.owned(id, touristId)
+	if err != nil {
+		return nil, err
+	}
+	return s.finish(execution, model.Abandoned)
+}
+
+// Complete closes an execution once every key point has been reached.
+func (s *TourExecutionService) Complete(id uuid.UUID, touristId string) (*model.TourExecution, error) {
+	execution, err := s.owned(id, touristId)
+	if err != nil {
+		return nil, err
+	}
+	keyPoints, err := s.keyPointsFor(execution)
+	if err != nil {
+		return nil, err
+	}
+	for _, keyPoint := range keyPoints {
+		if !execution.IsKeyPointCompleted(keyPoint.Id) {
+			return nil, ErrExecutionIncomplete
+		}
+	}
+	return s.finish(execution, model.Completed)
+}
+
+// CheckProximity records the tourist's new position against their active
+// execution, if any. Returns nil when the tourist is not on a tour.
+func (s *TourExecutionService) CheckProximity(touristId string, coords model.Coordinates) (*model.TourExecution, error) {
+	execution, err := s.Repo.GetActiveByTourist(touristId)
+	if err != nil || execution == nil {
+		return nil, err
+	}
+	return s.advance(execution, coords)
+}
+
+// advance marks key points as completed, in route order, for as long as the
+// next one is within ProximityRadius of coords.
+func (s *TourExecutionService) advance(execution *model.TourExecution, coords model.Coordinates) (*model.TourExecution, error) {
+	keyPoints, err := s.keyPointsFor(execution)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	for _, keyPoint := range keyPoints {
+		if execution.IsKeyPointCompleted(keyPoint.Id) {
+			continue
+		}
+		if coords.DistanceTo(keyPoint.Coordinates)*1000 > s.ProximityRadius {
+			break
+		}
+		execution.CompletedKeyPoints = append(execution.CompletedKeyPoints, model.CompletedKeyPoint{
+			KeyPointId:  keyPoint.Id,
+			CompletedAt: now,
+		})
+	}
+
+	execution.LastActivity = now
+	if err := s.Repo.UpdateProgress(execution.Id, execution.CompletedKeyPoints, execution.LastActivity); err != nil {
+		return nil, err
+	}
+	return execution, nil
+}
+
+// KeyPoints returns the route of the tour revision the tourist's execution follows.
+func (s *TourExecutionService) KeyPoints(id uuid.UUID, touristId string) ([]model.KeyPoint, error) {
+	execution, err := s.owned(id, touristId)
+	if err != nil {
+		return nil, err
+	}
+	return s.keyPointsFor(execution)
+}
+
+// owned loads the execution and checks that it is touristId's.
+func (s *TourExecutionService) owned(id uuid.UUID, touristId string) (*model.TourExecution, error) {
+	execution, err := s.Repo.GetById(id)
+	if err != nil {
+		return nil, err
+	}
+	if execution.TouristId != touristId {
+		return nil, ErrNotExecutionOwner
+	}
+	return execution, nil
+}
+
+// keyPointsFor returns the route the execution was started on: the tour's
+// current key points, or those of the published snapshot if the tour has been
+// republished since.
+func (s *TourExecutionService) keyPointsFor(execution *model.TourExecution) ([]model.KeyPoint, error) {
+	tour, err := s.TourRepository.GetById(execution.TourId)
+	if err == nil && tour.Revision != execution.Revision {
+		snapshot, err := s.RevisionRepository.Get(execution.TourId, execution.Revision)
+		if err != nil {
+			return nil, err
+		}
+		return snapshot.KeyPoints, nil
+	}
+	// a deleted tour only leaves its stored key points behind, if any
+	return s.KeyPointRepository.GetAllByTour(execution.TourId)
+}
+
+func (s *TourExecutionService) finish(execution *model.TourExecution, status model.TourExecutionStatus) (*model.TourExecution, error) {
+	if execution.Status != model.Active {
+		return nil, ErrExecutionNotActive
+	}
+
+	endedAt := time.Now().UTC()
+	if err := s.Repo.Finish(execution.Id, status, endedAt); err != nil {
+		return nil, ErrExecutionNotActive
+	}
+	execution.Status = status
+	execution.EndedAt = endedAt
+	execution.LastActivity = endedAt
+	return execution, nil
+}
@@ -1,16 +1,283 @@
 package service
 
 import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
 	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/mongo"
 	"tour.xws.com/model"
 	"tour.xws.com/repository"
 )
 
+var (
+	ErrInvalidRating       = errors.New("rating must be between 1 and 5")
+	ErrAlreadyReviewed     = errors.New("tour already reviewed by this tourist, edit the existing review instead")
+	ErrNotReviewAuthor     = errors.New("only the author of a review can edit it")
+	ErrTourNotReviewable   = errors.New("only published tours can be reviewed")
+	ErrNotEligibleToReview = errors.New("only tourists who bought the tour or recently went on it can review it")
+	ErrTooManyReviewImages = fmt.Errorf("a review can have at most %d images", model.MaxReviewImages)
+	ErrEmptyReply          = errors.New("reply text is required")
+)
+
 type TourRatingService struct {
-	Repo *repository.TourRatingRepository
+	Repo                *repository.TourRatingRepository
+	TourRepository      *repository.TourRepository
+	TokenRepository     *repository.TourPurchaseTokenRepository
+	ExecutionRepository *repository.TourExecutionRepository
+	Images              *ImageService
+	// ActivityWindow is how long after their last activity on a tour a
+	// tourist who did not buy it may still review it.
+	ActivityWindow time.Duration
+}
+
+// Create stores the first review of a published tour by a tourist who bought
+// it or recently went on it.
+func (s *TourRatingService) Create(m *model.TourRating) error {
+	if err := validateRating(m.Rating); err != nil {
+		return err
+	}
+	tour, err := s.TourRepository.GetById(m.TourId)
+	if err != nil {
+		return err
+	}
+	if tour.Status != model.Published {
+		return ErrTourNotReviewable
+	}
+	if err := s.checkEligible(m.TouristId, m.TourId); err != nil {
+		return err
+	}
+	existing, err := s.Repo.GetByTourist(m.TourId, m.TouristId)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return ErrAlreadyReviewed
+	}
+
+	if err := s.Repo.Create(m); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrAlreadyReviewed
+		}
+		return err
+	}
+	return s.refreshStats(m.TourId)
+}
+
+// Update replaces the rating, comment and dates of the tourist's own review.
+func (s *TourRatingService) Update(m *model.TourRating) error {
+	if err := validateRating(m.Rating); err != nil {
+		return err
+	}
+	if _, err := s.ownReview(m.TourId, m.Id, m.TouristId); err != nil {
+		return err
+	}
+
+	if err := s.Repo.Update(m); err != nil {
+		return err
+	}
+	return s.refreshStats(m.TourId)
 }
 
-func (s *TourRatingService) Create(m *model.TourRating) error { return s.Repo.Create(m) }
 func (s *TourRatingService) GetByTour(tourId uuid.UUID) ([]model.TourRating, error) {
-	return s.Repo.GetByTour(tourId)
+	items, err := s.Repo.GetByTour(tourId)
+	if err != nil {
+		return nil, err
+	}
+	for i := range items {
+		setImageUrls(&items[i])
+	}
+	return items, nil
+}
+
+// AddImages attaches photos to the tourist's own review.
+func (s *TourRatingService) AddImages(tourId, reviewId uuid.UUID, touristId string, images []model.Image) ([]model.ReviewImage, error) {
+	review, err := s.ownReview(tourId, reviewId, touristId)
+	if err != nil {
+		return nil, err
+	}
+	if len(review.Images)+len(images) > model.MaxReviewImages {
+		return nil, ErrTooManyReviewImages
+	}
+
+	added := make([]model.ReviewImage, 0, len(images))
+	for _, image := range images {
+		added = append(added, model.ReviewImage{Id: uuid.New(), Image: image})
+	}
+	err = s.Repo.AddImages(reviewId, added)
+	if errors.Is(err, repository.ErrReviewImagesFull) {
+		return nil, ErrTooManyReviewImages
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	review.Images = added
+	setImageUrls(review)
+	return review.Images, nil
+}
+
+func (s *TourRatingService) RemoveImage(tourId, reviewId, imageId uuid.UUID, touristId string) error {
+	review, err := s.ownReview(tourId, reviewId, touristId)
+	if err != nil {
+		return err
+	}
+	image, err := reviewImage(review, imageId)
+	if err != nil {
+		return err
+	}
+	if err := s.Repo.RemoveImage(reviewId, imageId); err != nil {
+		return err
+	}
+	s.Images.Delete(image)
+	return nil
+}
+
+// GetImage returns the stored reference of a review photo.
+func (s *TourRatingService) GetImage(tourId, reviewId, imageId uuid.UUID) (model.Image, error) {
+	review, err := s.review(tourId, reviewId)
+	if err != nil {
+		return model.Image{}, err
+	}
+	return reviewImage(review, imageId)
+}
+
+func reviewImage(review *model.TourRating, imageId uuid.UUID) (model.Image, error) {
+	for _, image := range review.Images {
+		if image.Id == imageId {
+			return image.Image, nil
+		}
+	}
+	return model.Image{}, repository.ErrReviewImageNotFound
+}
+
+// SetReply posts or edits the tour author's single reply to a review.
+func (s *TourRatingService) SetReply(tourId, reviewId uuid.UUID, text string, caller Caller) (*model.ReviewReply, error) {
+	if strings.TrimSpace(text) == "" {
+		return nil, ErrEmptyReply
+	}
+	review, err := s.review(tourId, reviewId)
+	if err != nil {
+		return nil, err
+	}
+	tour, err := s.TourRepository.GetById(tourId)
+	if err != nil {
+		return nil, err
+	}
+	if caller.Id == "" || tour.AuthorId != caller.Id {
+		return nil, ErrForbidden
+	}
+
+	now := time.Now().UTC()
+	reply := &model.ReviewReply{AuthorId: caller.Id, Text: text, CreatedAt: now}
+	if review.Reply != nil {
+		reply.CreatedAt = review.Reply.CreatedAt
+		reply.UpdatedAt = now
+	}
+	return reply, s.Repo.SetReply(reviewId, reply)
+}
+
+// DeleteReply removes the reply; besides its author, admins may take it down.
+func (s *TourRatingService) DeleteReply(tourId, reviewId uuid.UUID, caller Caller) error {
+	if _, err := s.review(tourId, reviewId); err != nil {
+		return err
+	}
+	tour, err := s.TourRepository.GetById(tourId)
+	if err != nil {
+		return err
+	}
+	if err := authorizeAuthor(tour, caller); err != nil {
+		return err
+	}
+	return s.Repo.SetReply(reviewId, nil)
+}
+
+// RefreshAllStats recomputes the rating summary of every reviewed tour.
+func (s *TourRatingService) RefreshAllStats() error {
+	histograms, err := s.Repo.Histograms(nil)
+	if err != nil {
+		return err
+	}
+	for tourId, histogram := range histograms {
+		err := s.TourRepository.UpdateRatingStats(tourId, model.NewRatingStats(histogram))
+		if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+			return err
+		}
+	}
+	_, err = s.TourRepository.InitMissingRatingStats()
+	return err
+}
+
+// refreshStats recomputes the tour's rating summary from its reviews.
+func (s *TourRatingService) refreshStats(tourId uuid.UUID) error {
+	histograms, err := s.Repo.Histograms(&tourId)
+	if err != nil {
+		return err
+	}
+	err = s.TourRepository.UpdateRatingStats(tourId, model.NewRatingStats(histograms[tourId]))
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		// reviews of a deleted tour have no summary to keep
+		log.Printf("skipping rating stats of missing tour %s", tourId)
+		return nil
+	}
+	return err
+}
+
+// review loads a review of the given tour.
+func (s *TourRatingService) review(tourId, reviewId uuid.UUID) (*model.TourRating, error) {
+	review, err := s.Repo.GetById(reviewId)
+	if err != nil {
+		return nil, err
+	}
+	if review.TourId != tourId {
+		return nil, repository.ErrReviewNotFound
+	}
+	return review, nil
+}
+
+// ownReview loads a review of the given tour written by touristId.
+func (s *TourRatingService) ownReview(tourId, reviewId uuid.UUID, touristId string) (*model.TourRating, error) {
+	review, err := s.review(tourId, reviewId)
+	if err != nil {
+		return nil, err
+	}
+	if review.TouristId == "" || review.TouristId != touristId {
+		return nil, ErrNotReviewAuthor
+	}
+	return review, nil
+}
+
+func setImageUrls(review *model.TourRating) {
+	for i := range review.Images {
+		review.Images[i].Url = review.Images[i].Image.VersionedUrl(fmt.Sprintf("/tours/%s/reviews/%s/images/%s", review.TourId, review.Id, review.Images[i].Id))
+	}
+}
+
+func (s *TourRatingService) checkEligible(touristId string, tourId uuid.UUID) error {
+	purchased, err := s.TokenRepository.Exists(touristId, tourId)
+	if err != nil {
+		return err
+	}
+	if purchased {
+		return nil
+	}
+
+	active, err := s.ExecutionRepository.HasActivitySince(touristId, tourId, time.Now().UTC().Add(-s.ActivityWindow))
+	if err != nil {
+		return err
+	}
+	if !active {
+		return ErrNotEligibleToReview
+	}
+	return nil
+}
+
+func validateRating(rating int) error {
+	if rating < model.MinRating || rating > model.MaxRating {
+		return ErrInvalidRating
+	}
+	return nil
 }
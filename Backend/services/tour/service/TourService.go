@@ -1,38 +1,630 @@
 package service
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
 	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/mongo"
 	"tour.xws.com/model"
 	"tour.xws.com/repository"
 )
 
+var (
+	ErrInvalidStatusTransition = errors.New("invalid tour status transition")
+	ErrTourNotPublishable      = errors.New("tour needs at least two key points, a price and a duration for at least one transport type to be published")
+	ErrInvalidTourQuery        = errors.New("invalid tour query")
+	ErrInvalidNearbyQuery      = errors.New("nearby search needs a valid location and a radius of up to 100 km")
+	ErrUnpublishedListing      = errors.New("only the author can list their draft and archived tours")
+	ErrTourInUse               = errors.New("tour has been bought or is being toured, archive it instead")
+)
+
 type TourService struct {
-	TourRepository *repository.TourRepository
+	TourRepository      *repository.TourRepository
+	KeyPointRepository  *repository.KeyPointRepository
+	RevisionRepository  *repository.TourRevisionRepository
+	TokenRepository     *repository.TourPurchaseTokenRepository
+	ExecutionRepository *repository.TourExecutionRepository
+	Images              *ImageService
 }
 
-func (service *TourService) GetAllTours() ([]model.Tour, error) {
-	return service.TourRepository.GetAll()
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// Find returns one page of tours; paging values out of range fall back to defaults.
+// Draft and archived tours are only listed for their author and for admins,
+// everyone else gets published tours when no status is asked for.
+func (service *TourService) Find(query repository.TourQuery, caller Caller) (model.TourPage, error) {
+	if !canListUnpublished(query, caller) {
+		if query.Status == nil {
+			published := model.Published
+			query.Status = &published
+		}
+		if *query.Status != model.Published {
+			return model.TourPage{}, ErrUnpublishedListing
+		}
+	}
+	if !query.SortBy.Valid() {
+		return model.TourPage{}, fmt.Errorf("%w: unknown sort field %q", ErrInvalidTourQuery, query.SortBy)
+	}
+	if query.MinPrice != nil && query.MaxPrice != nil && *query.MinPrice > *query.MaxPrice {
+		return model.TourPage{}, fmt.Errorf("%w: minPrice is greater than maxPrice", ErrInvalidTourQuery)
+	}
+	if query.MaxDuration != nil && *query.MaxDuration < 0 {
+		return model.TourPage{}, fmt.Errorf("%w: maxDuration is negative", ErrInvalidTourQuery)
+	}
+	if query.Page < 1 {
+		query.Page = 1
+	}
+	if query.PageSize < 1 {
+		query.PageSize = defaultPageSize
+	}
+	if query.PageSize > maxPageSize {
+		query.PageSize = maxPageSize
+	}
+
+	query.CallerId = caller.Id
+	tours, total, err := service.TourRepository.Find(query)
+	if err != nil {
+		return model.TourPage{}, err
+	}
+	return model.TourPage{Items: tours, Page: query.Page, PageSize: query.PageSize, Total: total}, nil
 }
-func (service *TourService) GetAllByAuthor(userId string) ([]model.Tour, error) {
-	return service.TourRepository.GetAllByAuthor(userId)
+
+// canListUnpublished reports whether the caller may see the draft and archived
+// tours the query selects.
+func canListUnpublished(query repository.TourQuery, caller Caller) bool {
+	if caller.IsAdmin() {
+		return true
+	}
+	return caller.Id != "" && caller.Id == query.AuthorId
 }
 
+// Create stores a new draft tour; tour is updated with the stored values.
 func (service *TourService) Create(tour *model.Tour) error {
-	err := service.TourRepository.Create(model.BeforeCreateTour(tour.AuthorId, tour.Title, tour.Description, tour.Tags, tour.Difficulty))
+	if err := validateTour(*tour); err != nil {
+		return err
+	}
+	newTour := model.BeforeCreateTour(tour.AuthorId, tour.Title, tour.Description, tour.Tags, tour.Difficulty)
+	if tour.Durations != nil {
+		newTour.Durations = tour.Durations
+	}
+	err := service.TourRepository.Create(newTour)
+	if err != nil {
+		return err
+	}
+	*tour = *newTour
+	return nil
+}
+
+// Delete removes the tour together with its pending draft revision, the key
+// points of both, its published snapshots and every image any of them refers
+// to. Tours that were bought or that someone is on right now can only be
+// archived.
+func (service *TourService) Delete(id uuid.UUID, caller Caller) error {
+	if _, err := service.authorizedTour(id, caller); err != nil {
+		return err
+	}
+	if err := service.checkUnused(id); err != nil {
+		return err
+	}
+
+	ids := []uuid.UUID{id}
+	// a pending draft revision has nothing left to replace
+	draft, err := service.TourRepository.GetDraftRevision(id)
+	if err != nil {
+		return err
+	}
+	if draft != nil {
+		ids = append(ids, draft.Id)
+	}
+
+	var keyPoints []model.KeyPoint
+	for _, tourId := range ids {
+		current, err := service.KeyPointRepository.GetAllByTour(tourId)
+		if err != nil {
+			return err
+		}
+		keyPoints = append(keyPoints, current...)
+	}
+	// older revisions can refer to images no current key point has anymore
+	revisions, err := service.RevisionRepository.GetAllByTour(id)
+	if err != nil {
+		return err
+	}
+	for _, revision := range revisions {
+		keyPoints = append(keyPoints, revision.KeyPoints...)
+	}
+
+	for _, tourId := range ids {
+		if err := service.TourRepository.Delete(tourId); err != nil {
+			return err
+		}
+		if err := service.KeyPointRepository.DeleteAllByTour(tourId); err != nil {
+			return err
+		}
+	}
+	if err := service.RevisionRepository.DeleteAllByTour(id); err != nil {
+		return err
+	}
+	service.Images.Delete(distinctImages(keyPoints)...)
+	return nil
+}
+
+// distinctImages lists the images of the key points once each; snapshots
+// share them with the key points they were taken of.
+func distinctImages(keyPoints []model.KeyPoint) []model.Image {
+	seen := map[string]bool{}
+	var images []model.Image
+	for _, keyPoint := range keyPoints {
+		if keyPoint.Image.IsEmpty() || seen[keyPoint.Image.Key] {
+			continue
+		}
+		seen[keyPoint.Image.Key] = true
+		images = append(images, keyPoint.Image)
+	}
+	return images
+}
+
+// checkUnused refuses to delete a tour tourists depend on.
+func (service *TourService) checkUnused(id uuid.UUID) error {
+	bought, err := service.TokenRepository.ExistsForTour(id)
+	if err != nil {
+		return err
+	}
+	if bought {
+		return ErrTourInUse
+	}
+	active, err := service.ExecutionRepository.HasActiveOnTour(id)
+	if err != nil {
+		return err
+	}
+	if active {
+		return ErrTourInUse
+	}
+	return nil
+}
+
+// Update changes a draft in place. Edits to a published or archived tour go
+// to its draft revision instead, which is started if needed; the returned
+// tour is the one that was written.
+func (service *TourService) Update(id uuid.UUID, updatedTour model.Tour, caller Caller) (model.Tour, error) {
+	if err := validateTour(updatedTour); err != nil {
+		return model.Tour{}, err
+	}
+	if updatedTour.Durations == nil {
+		updatedTour.Durations = []model.TransportDuration{}
+	}
+	tour, err := service.authorizedTour(id, caller)
+	if err != nil {
+		return model.Tour{}, err
+	}
+	if tour.Status != model.Draft {
+		if tour, err = service.draftRevision(tour); err != nil {
+			return model.Tour{}, err
+		}
+	}
+	// authorship never changes through an update
+	updatedTour.AuthorId = tour.AuthorId
+	if err := service.TourRepository.Update(tour.Id, updatedTour); err != nil {
+		return model.Tour{}, err
+	}
+	return service.TourRepository.GetById(tour.Id)
+}
+
+// Export writes the tour's key points, in route order, as waypoints of a GPX
+// or GeoJSON file. Like the full route, it is only available to the tour's
+// author, admins and tourists who bought it.
+func (service *TourService) Export(id uuid.UUID, format RouteFormat, caller Caller) (model.Tour, []byte, error) {
+	tour, err := service.TourRepository.GetById(id)
+	if err != nil {
+		return model.Tour{}, nil, err
+	}
+	if authorizeAuthor(tour, caller) != nil {
+		purchased, err := service.TokenRepository.Exists(caller.Id, id)
+		if err != nil {
+			return model.Tour{}, nil, err
+		}
+		if caller.Id == "" || !purchased {
+			return model.Tour{}, nil, ErrTourNotPurchased
+		}
+	}
+
+	keyPoints, err := service.KeyPointRepository.GetAllByTour(id)
+	if err != nil {
+		return model.Tour{}, nil, err
+	}
+	data, err := encodeRoute(format, route{Title: tour.Title, Description: tour.Description, KeyPoints: keyPoints})
+	return tour, data, err
+}
+
+// Import creates a draft tour of authorId with a key point, in order, for
+// every point of a GPX or GeoJSON file. A non-empty title replaces the one
+// found in the file; unnamed points are numbered.
+func (service *TourService) Import(authorId string, title string, data []byte) (model.Tour, error) {
+	r, err := decodeRoute(data)
+	if err != nil {
+		return model.Tour{}, err
+	}
+	if title == "" {
+		title = r.Title
+	}
+	if title == "" {
+		title = "Imported tour"
+	}
+
+	tour := model.BeforeCreateTour(authorId, title, r.Description, []string{}, model.Beginner)
+	if err := validateTour(*tour); err != nil {
+		return model.Tour{}, err
+	}
+	keyPoints := make([]model.KeyPoint, 0, len(r.KeyPoints))
+	for i, point := range r.KeyPoints {
+		if point.Title == "" {
+			point.Title = fmt.Sprintf("Key point %d", i+1)
+		}
+		keyPoint := model.BeforeCreateKeyPoint(tour.Id, point.Coordinates, point.Title, point.Description, model.Image{})
+		keyPoint.Order = i
+		if err := validateKeyPoint(*keyPoint, true); err != nil {
+			return model.Tour{}, fmt.Errorf("%w: point %d: %v", ErrInvalidRouteFile, i+1, err)
+		}
+		keyPoints = append(keyPoints, *keyPoint)
+	}
+	tour.Distance = model.RouteDistance(keyPoints)
+
+	if err := service.TourRepository.Create(tour); err != nil {
+		return model.Tour{}, err
+	}
+	if err := service.KeyPointRepository.CreateMany(keyPoints); err != nil {
+		if deleteErr := service.TourRepository.Delete(tour.Id); deleteErr != nil {
+			log.Printf("failed to remove tour %s after a failed import: %v", tour.Id, deleteErr)
+		}
+		return model.Tour{}, err
+	}
+	return *tour, nil
+}
+
+// Clone copies the tour and its key points, images included, into a new
+// draft of the same author.
+func (service *TourService) Clone(id uuid.UUID, caller Caller) (model.Tour, error) {
+	source, err := service.authorizedTour(id, caller)
+	if err != nil {
+		return model.Tour{}, err
+	}
+
+	clone := source
+	clone.Id = uuid.New()
+	clone.Status = model.Draft
+	clone.PublishedAt = time.Time{}
+	clone.ArchivedAt = time.Time{}
+	clone.RatingStats = model.RatingStats{}
+	clone.Revision = 0
+	clone.RevisionOf = nil
+	if err := service.TourRepository.Create(&clone); err != nil {
+		return model.Tour{}, err
+	}
+	if err := service.copyKeyPoints(source.Id, clone.Id); err != nil {
+		service.TourRepository.Delete(clone.Id)
+		return model.Tour{}, err
+	}
+	return clone, nil
+}
+
+// StartRevision returns the draft in which changes to a published or archived
+// tour are prepared, creating it from the live tour if there is none yet.
+// Drafts are edited directly and are returned as they are.
+func (service *TourService) StartRevision(id uuid.UUID, caller Caller) (model.Tour, error) {
+	tour, err := service.authorizedTour(id, caller)
+	if err != nil {
+		return model.Tour{}, err
+	}
+	if tour.Status == model.Draft {
+		return tour, nil
+	}
+	return service.draftRevision(tour)
+}
+
+// Revisions lists the published versions of the tour, newest first.
+func (service *TourService) Revisions(id uuid.UUID, caller Caller) ([]model.TourRevision, error) {
+	if _, err := service.authorizedTour(id, caller); err != nil {
+		return nil, err
+	}
+	return service.RevisionRepository.GetAllByTour(id)
+}
+
+func (service *TourService) draftRevision(live model.Tour) (model.Tour, error) {
+	existing, err := service.TourRepository.GetDraftRevision(live.Id)
+	if err != nil {
+		return model.Tour{}, err
+	}
+	if existing != nil {
+		return *existing, nil
+	}
+
+	draft := live
+	draft.Id = uuid.New()
+	draft.Status = model.Draft
+	draft.PublishedAt = time.Time{}
+	draft.ArchivedAt = time.Time{}
+	draft.RatingStats = model.RatingStats{}
+	draft.RevisionOf = &live.Id
+	if err := service.TourRepository.Create(&draft); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			// started concurrently, use the draft that won
+			return service.draftRevision(live)
+		}
+		return model.Tour{}, err
+	}
+	if err := service.copyKeyPoints(live.Id, draft.Id); err != nil {
+		service.TourRepository.Delete(draft.Id)
+		return model.Tour{}, err
+	}
+	return draft, nil
+}
+
+// copyKeyPoints gives tour to a copy of every key point of tour from, each
+// with a new id and its own copy of the image.
+func (service *TourService) copyKeyPoints(from uuid.UUID, to uuid.UUID) error {
+	keyPoints, err := service.KeyPointRepository.GetAllByTour(from)
 	if err != nil {
 		return err
 	}
+
+	copies := make([]model.KeyPoint, 0, len(keyPoints))
+	images := make([]model.Image, 0, len(keyPoints))
+	for _, keyPoint := range keyPoints {
+		image, err := service.Images.Copy(context.Background(), "keypoints", keyPoint.Image)
+		if err != nil {
+			service.Images.Delete(images...)
+			return err
+		}
+		images = append(images, image)
+
+		keyPoint.Id = uuid.New()
+		keyPoint.TourId = to
+		keyPoint.Image = image
+		copies = append(copies, keyPoint)
+	}
+
+	if err := service.KeyPointRepository.CreateMany(copies); err != nil {
+		service.Images.Delete(images...)
+		return err
+	}
 	return nil
 }
 
-func (service *TourService) Delete(id uuid.UUID) error {
-	return service.TourRepository.Delete(id)
+// GetById returns the tour if viewer may see it; drafts and archived tours
+// of other authors are reported as not found.
+func (service *TourService) GetById(id uuid.UUID, viewer Caller) (model.Tour, error) {
+	tour, err := service.TourRepository.GetById(id)
+	if err != nil {
+		return model.Tour{}, err
+	}
+	if tour.Status != model.Published && authorizeAuthor(tour, viewer) != nil {
+		return model.Tour{}, repository.ErrTourNotFound
+	}
+	return tour, nil
+}
+
+const maxNearbyRadiusKm = 100
+
+// Nearby returns published tours with a key point within radiusKm of center,
+// closest first. With firstOnly, only a tour's starting key point counts.
+func (service *TourService) Nearby(center model.Coordinates, radiusKm float64, firstOnly bool) ([]model.NearbyTour, error) {
+	if !center.Valid() || radiusKm <= 0 || radiusKm > maxNearbyRadiusKm {
+		return nil, ErrInvalidNearbyQuery
+	}
+
+	matches, err := service.KeyPointRepository.FindNearby(center, radiusKm*1000)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]uuid.UUID, 0, len(matches))
+	for _, match := range matches {
+		ids = append(ids, match.TourId)
+	}
+	tours, err := service.TourRepository.GetPublishedByIds(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	nearby := []model.NearbyTour{}
+	for _, match := range matches {
+		tour, ok := tours[match.TourId]
+		if !ok {
+			continue
+		}
+		distance := match.DistanceMeters
+		if firstOnly {
+			var found bool
+			if distance, found, err = service.startDistance(match); err != nil {
+				return nil, err
+			}
+			if !found {
+				continue
+			}
+		}
+		nearby = append(nearby, model.NearbyTour{Tour: tour, DistanceKm: distance / 1000})
+	}
+
+	if firstOnly {
+		// Start distances can reorder tours that were sorted by their closest key point.
+		sort.SliceStable(nearby, func(i, j int) bool { return nearby[i].DistanceKm < nearby[j].DistanceKm })
+	}
+	return nearby, nil
 }
 
-func (service *TourService) Update(id uuid.UUID, updatedTour model.Tour) error {
-	return service.TourRepository.Update(id, updatedTour)
+// startDistance reports the distance to the tour's first key point if it is among the matches.
+func (service *TourService) startDistance(match repository.NearbyTourKeyPoints) (float64, bool, error) {
+	route, err := service.KeyPointRepository.GetAllByTour(match.TourId)
+	if err != nil || len(route) == 0 {
+		return 0, false, err
+	}
+	for _, keyPoint := range match.KeyPoints {
+		if keyPoint.Id == route[0].Id {
+			return keyPoint.DistanceMeters, true, nil
+		}
+	}
+	return 0, false, nil
 }
 
-func (service *TourService) GetById(id uuid.UUID) (model.Tour, error) {
-	return service.TourRepository.GetById(id)
+// Publish moves a draft tour to Published once it has everything a tourist
+// needs. Publishing a draft revision makes it the new live version of the tour
+// it was started from.
+func (service *TourService) Publish(id uuid.UUID, caller Caller) (model.Tour, error) {
+	tour, err := service.authorizedTour(id, caller)
+	if err != nil {
+		return model.Tour{}, err
+	}
+	if tour.Status != model.Draft {
+		return model.Tour{}, ErrInvalidStatusTransition
+	}
+
+	keyPoints, err := service.KeyPointRepository.GetAllByTour(id)
+	if err != nil {
+		return model.Tour{}, err
+	}
+	if len(keyPoints) < 2 || tour.Price <= 0 || len(tour.Durations) == 0 {
+		return model.Tour{}, ErrTourNotPublishable
+	}
+	if tour.IsDraftRevision() {
+		return service.publishRevision(tour, keyPoints)
+	}
+
+	tour.Status = model.Published
+	tour.PublishedAt = time.Now().UTC()
+	tour.ArchivedAt = time.Time{}
+	tour.Revision = 1
+	// The status changes last, so a failed publish leaves a draft that can be
+	// published again; its snapshot is rewritten then.
+	if err := service.RevisionRepository.Replace(newSnapshot(tour, keyPoints)); err != nil {
+		return model.Tour{}, err
+	}
+	if err := service.TourRepository.SetRevision(id, tour.Revision); err != nil {
+		return model.Tour{}, err
+	}
+	if err := service.updateStatus(model.Draft, tour); err != nil {
+		return model.Tour{}, err
+	}
+	return tour, nil
+}
+
+// publishRevision replaces the live tour's content and key points with the
+// draft's, keeping the live tour's id, status, publish date and reviews, and
+// removes the draft. An archived tour stays archived until it is reactivated. Both versions are kept as snapshots so executions started on the
+// old one can be finished.
+func (service *TourService) publishRevision(draft model.Tour, keyPoints []model.KeyPoint) (model.Tour, error) {
+	live, err := service.TourRepository.GetById(*draft.RevisionOf)
+	if err != nil {
+		return model.Tour{}, err
+	}
+	liveKeyPoints, err := service.KeyPointRepository.GetAllByTour(live.Id)
+	if err != nil {
+		return model.Tour{}, err
+	}
+	// tours published before revisions existed have no snapshot yet
+	if err := service.snapshot(live, liveKeyPoints); err != nil {
+		return model.Tour{}, err
+	}
+
+	published := draft
+	published.Id = live.Id
+	published.AuthorId = live.AuthorId
+	published.Status = live.Status
+	published.PublishedAt = live.PublishedAt
+	published.ArchivedAt = live.ArchivedAt
+	published.RatingStats = live.RatingStats
+	published.Revision = live.Revision + 1
+	published.RevisionOf = nil
+	for i := range keyPoints {
+		keyPoints[i].TourId = live.Id
+	}
+	if err := service.snapshot(published, keyPoints); err != nil {
+		return model.Tour{}, err
+	}
+
+	// the old key points' images stay, the snapshot still refers to them
+	if err := service.KeyPointRepository.DeleteAllByTour(live.Id); err != nil {
+		return model.Tour{}, err
+	}
+	if err := service.KeyPointRepository.MoveToTour(draft.Id, live.Id); err != nil {
+		return model.Tour{}, err
+	}
+	if err := service.TourRepository.ApplyRevision(live.Id, published); err != nil {
+		return model.Tour{}, err
+	}
+	return published, service.TourRepository.Delete(draft.Id)
+}
+
+func (service *TourService) snapshot(tour model.Tour, keyPoints []model.KeyPoint) error {
+	return service.RevisionRepository.Create(newSnapshot(tour, keyPoints))
+}
+
+func newSnapshot(tour model.Tour, keyPoints []model.KeyPoint) *model.TourRevision {
+	return &model.TourRevision{
+		Id:          uuid.New(),
+		TourId:      tour.Id,
+		Revision:    tour.Revision,
+		Tour:        tour,
+		KeyPoints:   keyPoints,
+		PublishedAt: time.Now().UTC(),
+	}
+}
+
+// Archive takes a published tour off sale without deleting it.
+func (service *TourService) Archive(id uuid.UUID, caller Caller) (model.Tour, error) {
+	tour, err := service.authorizedTour(id, caller)
+	if err != nil {
+		return model.Tour{}, err
+	}
+	if tour.Status != model.Published {
+		return model.Tour{}, ErrInvalidStatusTransition
+	}
+
+	tour.Status = model.Archived
+	tour.ArchivedAt = time.Now().UTC()
+	return tour, service.updateStatus(model.Published, tour)
+}
+
+// Reactivate puts an archived tour back on sale, keeping its original publish date.
+func (service *TourService) Reactivate(id uuid.UUID, caller Caller) (model.Tour, error) {
+	tour, err := service.authorizedTour(id, caller)
+	if err != nil {
+		return model.Tour{}, err
+	}
+	if tour.Status != model.Archived {
+		return model.Tour{}, ErrInvalidStatusTransition
+	}
+
+	tour.Status = model.Published
+	tour.ArchivedAt = time.Time{}
+	return tour, service.updateStatus(model.Archived, tour)
+}
+
+// updateStatus stores the tour's new status and dates, provided it is still in
+// status from; a concurrent transition that got there first makes this one invalid.
+func (service *TourService) updateStatus(from model.TourStatus, tour model.Tour) error {
+	err := service.TourRepository.UpdateStatus(tour.Id, from, tour.Status, tour.PublishedAt, tour.ArchivedAt)
+	if errors.Is(err, repository.ErrStatusChanged) {
+		return ErrInvalidStatusTransition
+	}
+	return err
+}
+
+// authorizedTour loads the tour and checks that caller may change it.
+func (service *TourService) authorizedTour(id uuid.UUID, caller Caller) (model.Tour, error) {
+	tour, err := service.TourRepository.GetById(id)
+	if err != nil {
+		return model.Tour{}, err
+	}
+	if err := authorizeAuthor(tour, caller); err != nil {
+		return model.Tour{}, err
+	}
+	return tour, nil
 }
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"tour.xws.com/model"
+)
+
+// ErrValidation is matched by every *ValidationError, so callers can map
+// invalid input with errors.Is like the other service errors.
+var ErrValidation = errors.New("validation failed")
+
+const (
+	maxTitleLength       = 200
+	maxDescriptionLength = 5000
+	maxTags              = 20
+)
+
+// FieldError explains why one input field was rejected. Field uses the JSON
+// names of the request, e.g. "coordinates.latitude" or "durations[1].minutes".
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError lists every rejected field of a request.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, 0, len(e.Fields))
+	for _, field := range e.Fields {
+		messages = append(messages, field.Field+" "+field.Message)
+	}
+	return ErrValidation.Error() + ": " + strings.Join(messages, "; ")
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+// InvalidField reports a single rejected field, for input that is already
+// malformed before it reaches a service, such as a latitude that is not a number.
+func InvalidField(field, message string) *ValidationError {
+	return &ValidationError{Fields: []FieldError{{Field: field, Message: message}}}
+}
+
+// validator collects field errors so a request is rejected with all of its
+// problems at once.
+type validator struct {
+	fields []FieldError
+}
+
+func (v *validator) check(ok bool, field, message string) {
+	if !ok {
+		v.fields = append(v.fields, FieldError{Field: field, Message: message})
+	}
+}
+
+func (v *validator) required(value, field string, maxLength int) {
+	value = strings.TrimSpace(value)
+	v.check(value != "", field, "is required")
+	v.check(len(value) <= maxLength, field, fmt.Sprintf("must be at most %d characters", maxLength))
+}
+
+func (v *validator) coordinates(c model.Coordinates, field string) {
+	v.check(c.Latitude >= -90 && c.Latitude <= 90, field+".latitude", "must be between -90 and 90")
+	v.check(c.Longitude >= -180 && c.Longitude <= 180, field+".longitude", "must be between -180 and 180")
+}
+
+func (v *validator) err() error {
+	if len(v.fields) == 0 {
+		return nil
+	}
+	return &ValidationError{Fields: v.fields}
+}
+
+// validateTour checks the fields an author sets when creating or editing a tour.
+func validateTour(tour model.Tour) error {
+	var v validator
+	v.required(tour.Title, "title", maxTitleLength)
+	v.check(len(tour.Description) <= maxDescriptionLength, "description", fmt.Sprintf("must be at most %d characters", maxDescriptionLength))
+	v.check(tour.Difficulty >= model.Beginner && tour.Difficulty <= model.Pro, "difficulty",
+		fmt.Sprintf("must be between %d and %d", model.Beginner, model.Pro))
+	v.check(tour.Price >= 0, "price", "must not be negative")
+	v.check(len(tour.Tags) <= maxTags, "tags", fmt.Sprintf("must have at most %d entries", maxTags))
+	for i, tag := range tour.Tags {
+		v.check(strings.TrimSpace(tag) != "", fmt.Sprintf("tags[%d]", i), "must not be empty")
+	}
+
+	seen := map[model.TransportType]bool{}
+	for i, duration := range tour.Durations {
+		field := fmt.Sprintf("durations[%d]", i)
+		v.check(duration.TransportType.Valid(), field+".transportType",
+			fmt.Sprintf("must be between %d and %d", model.Walking, model.Bus))
+		v.check(duration.Minutes > 0, field+".minutes", "must be positive")
+		v.check(!seen[duration.TransportType], field+".transportType", "is listed more than once")
+		seen[duration.TransportType] = true
+	}
+	return v.err()
+}
+
+// validateKeyPoint checks the fields of a new or edited key point; the tour
+// itself is looked up by the caller.
+func validateKeyPoint(keyPoint model.KeyPoint, requireTour bool) error {
+	var v validator
+	if requireTour {
+		v.check(keyPoint.TourId != uuid.Nil, "tourId", "is required")
+	}
+	v.required(keyPoint.Title, "title", maxTitleLength)
+	v.check(len(keyPoint.Description) <= maxDescriptionLength, "description", fmt.Sprintf("must be at most %d characters", maxDescriptionLength))
+	v.coordinates(keyPoint.Coordinates, "coordinates")
+	return v.err()
+}
@@ -1,5 +1,7 @@
 export interface BlogImage {
-  data?: string; // Base64 encoded data from backend
+  id: string;
+  url: string; // Path of the stored image, served by the backend
   mimeType?: string;
   filename?: string;
+  size?: number;
 }
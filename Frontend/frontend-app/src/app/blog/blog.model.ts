@@ -1,13 +1,32 @@
-import { Like } from "./like.model";
-
 import { BlogImage } from './blog-image.model';
 
 export interface Blog {
   id: string;
   userId: string;
   title: string;
-  description: string;
+  description: string; // Markdown source
+  descriptionHtml: string; // sanitized rendering of description
   date_of_creation: string;
-  images: string[];
-  likes: Like[];           // array of Like objects
+  images: BlogImage[];
+  likeCount: number;
+  likedByMe: boolean; // whether the current user likes the blog
+  status: BlogStatus;
+  publishedAt: string;
+  closedAt: string;
+}
+
+// One page of a blog listing, such as the feed.
+export interface BlogPage {
+  items: Blog[];
+  page: number;
+  pageSize: number;
+  total: number;
+}
+
+// Drafts are only visible to their author; closed blogs stay readable but
+// take no new comments or likes.
+export enum BlogStatus {
+  Draft = 0,
+  Published = 1,
+  Closed = 2,
 }
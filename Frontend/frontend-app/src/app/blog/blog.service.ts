@@ -1,7 +1,7 @@
 import { Injectable } from '@angular/core';
 import { HttpClient } from '@angular/common/http';
 import { Observable } from 'rxjs';
-import { Blog } from './blog.model';
+import { Blog, BlogPage } from './blog.model';
 import { BlogDto } from './blog.dto';
 
 @Injectable({
@@ -16,6 +16,13 @@ export class BlogService {
     return this.http.get<Blog[]>(`${this.apiUrl}`);
   }
 
+  // Published blogs of the users the caller follows, newest first
+  getFeed(page = 1, pageSize = 20): Observable<BlogPage> {
+    return this.http.get<BlogPage>(`${this.apiUrl}/feed`, {
+      params: { page, pageSize },
+    });
+  }
+
   getById(id: string): Observable<Blog> {
     return this.http.get<Blog>(`${this.apiUrl}/${id}`);
   }
@@ -57,4 +64,16 @@ export class BlogService {
     return this.http.get<Blog[]>(`${this.apiUrl}/users/${userId}`);
   }
 
+  publish(id: string): Observable<Blog> {
+    return this.http.post<Blog>(`${this.apiUrl}/${id}/publish`, {});
+  }
+
+  close(id: string): Observable<Blog> {
+    return this.http.post<Blog>(`${this.apiUrl}/${id}/close`, {});
+  }
+
+  reopen(id: string): Observable<Blog> {
+    return this.http.post<Blog>(`${this.apiUrl}/${id}/reopen`, {});
+  }
+
 }
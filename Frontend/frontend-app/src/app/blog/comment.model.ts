@@ -1,7 +1,27 @@
 export interface Comment {
+  id: string;
   userId: string;
   blogId: string;
+  parentId?: string; // set on replies
+  depth: number; // 0 for top-level comments
   dateOfCreation: string;
   text: string;
   lastEdit: string;
-}
\ No newline at end of file
+  deleted: boolean; // deleted comments stay in the thread as "[deleted]"
+  likeCount: number;
+  likedByMe: boolean;
+  replies: Comment[];
+}
+
+// One page of a blog's top-level comments, each with its replies
+export interface CommentPage {
+  items: Comment[];
+  page: number;
+  pageSize: number;
+  total: number;
+}
+
+export type CommentSort = 'newest' | 'oldest' | 'top';
+
+// Replies can be nested this many levels below a top-level comment
+export const MAX_COMMENT_DEPTH = 3;
@@ -1,29 +1,40 @@
 import { Injectable } from '@angular/core';
 import { HttpClient } from '@angular/common/http';
 import { Observable } from 'rxjs';
-
-export interface Comment {
-  id: string;
-  userId: string;
-  blogId: string;
-  dateOfCreation: string;
-  text: string;
-  lastEdit: string;
-}
+import { Comment, CommentPage, CommentSort } from './comment.model';
 
 @Injectable({
   providedIn: 'root'
 })
 export class CommentService {
-  private apiUrl = 'http://localhost:5100/blogs/comments';
+  private apiUrl = 'http://localhost:7000/blogs/comments';
 
   constructor(private http: HttpClient) {}
 
-  getCommentsByBlog(blogId: string): Observable<Comment[]> {
-    return this.http.get<Comment[]>(`${this.apiUrl}/${blogId}`);
+  getCommentsByBlog(
+    blogId: string,
+    sort: CommentSort = 'newest',
+    page = 1,
+    pageSize = 20
+  ): Observable<CommentPage> {
+    return this.http.get<CommentPage>(`${this.apiUrl}/${blogId}`, {
+      params: { sort, page, pageSize },
+    });
   }
 
-  addComment(comment: { blogId: string; text: string; userId?: string }): Observable<Comment> {
+  addComment(comment: { blogId: string; text: string; userId?: string; parentId?: string }): Observable<Comment> {
     return this.http.post<Comment>(this.apiUrl, comment);
   }
-}
\ No newline at end of file
+
+  deleteComment(id: string): Observable<void> {
+    return this.http.delete<void>(`${this.apiUrl}/${id}`);
+  }
+
+  likeComment(id: string): Observable<Comment> {
+    return this.http.post<Comment>(`${this.apiUrl}/${id}/likes`, {});
+  }
+
+  unlikeComment(id: string): Observable<Comment> {
+    return this.http.delete<Comment>(`${this.apiUrl}/${id}/likes`);
+  }
+}
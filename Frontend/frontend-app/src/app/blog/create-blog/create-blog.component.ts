@@ -3,6 +3,7 @@ import { FormBuilder, FormGroup, Validators } from '@angular/forms';
 import { BlogService } from '../blog.service';
 import { AuthService } from 'src/app/auth/auth.service';
 import { BlogDto } from '../blog.dto';
+import { of, switchMap } from 'rxjs';
 
 @Component({
   selector: 'app-create-blog',
@@ -31,7 +32,9 @@ export class CreateBlogComponent {
     }
   }
 
-  onSubmit(): void {
+  // New blogs start as drafts; unless saved as a draft they are published
+  // right after they are created.
+  onSubmit(publish = true): void {
     if (this.blogForm.valid) {
       const newBlog: BlogDto = {
         userId: '1',
@@ -39,17 +42,24 @@ export class CreateBlogComponent {
         description: this.blogForm.value.description,
       };
 
-      this.blogService.create(newBlog, this.selectedImages).subscribe({
-        next: () => {
-          console.log('Blog created successfully!');
-          console.log('selected images:', this.selectedImages);
-          this.blogForm.reset();
-          this.selectedImages = [];
-        },
-        error: (err) => {
-          console.error('Error creating blog:', err);
-        },
-      });
+      this.blogService
+        .create(newBlog, this.selectedImages)
+        .pipe(
+          switchMap((created: { id: string }) =>
+            publish ? this.blogService.publish(created.id) : of(created)
+          )
+        )
+        .subscribe({
+          next: () => {
+            console.log('Blog created successfully!');
+            console.log('selected images:', this.selectedImages);
+            this.blogForm.reset();
+            this.selectedImages = [];
+          },
+          error: (err) => {
+            console.error('Error creating blog:', err);
+          },
+        });
     }
   }
 }
@@ -2,4 +2,11 @@ export interface Like {
   userId: string;
   blogId: string;
   dateOfCreation: string;
-}
\ No newline at end of file
+}
+
+// A blog's like count as seen by the current user
+export interface LikeSummary {
+  blogId: string;
+  likeCount: number;
+  likedByMe: boolean;
+}
@@ -1,13 +1,13 @@
 import { Injectable } from '@angular/core';
 import { HttpClient } from '@angular/common/http';
 import { Observable } from 'rxjs';
-import { Like } from './like.model';
+import { Like, LikeSummary } from './like.model';
 
 @Injectable({
   providedIn: 'root'
 })
 export class LikeService {
-  private apiUrl = 'http://localhost:5100/blogs/likes';
+  private apiUrl = 'http://localhost:7000/blogs/likes';
 
   constructor(private http: HttpClient) {}
 
@@ -15,11 +15,11 @@ export class LikeService {
     return this.http.get<Like[]>(`${this.apiUrl}/${blogId}`);
   }
 
-  createLike(like: { blogId: string; userId: string }): Observable<Like> {
-    return this.http.post<Like>(this.apiUrl, like);
+  createLike(like: { blogId: string; userId: string }): Observable<LikeSummary> {
+    return this.http.post<LikeSummary>(this.apiUrl, like);
   }
 
-  removeLike(blogId: string, userId: string): Observable<void> {
-    return this.http.delete<void>(`${this.apiUrl}/${userId}/${blogId}`);
+  removeLike(blogId: string, userId: string): Observable<LikeSummary> {
+    return this.http.delete<LikeSummary>(`${this.apiUrl}/${userId}/${blogId}`);
   }
 }
\ No newline at end of file
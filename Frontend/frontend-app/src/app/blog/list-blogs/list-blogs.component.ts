@@ -6,13 +6,18 @@ import {
   ChangeDetectionStrategy,
 } from '@angular/core';
 import { BlogService } from '../blog.service';
-import { Blog } from '../blog.model';
+import { Blog, BlogStatus } from '../blog.model';
 import { CommentService } from '../comment.service';
-import { Like } from '../like.model';
+import { LikeSummary } from '../like.model';
 import { LikeService } from '../like.service';
-import { Comment } from '../comment.model';
+import {
+  Comment,
+  CommentSort,
+  MAX_COMMENT_DEPTH,
+} from '../comment.model';
 import { AuthService } from 'src/app/auth/auth.service';
-import { FollowersService } from 'src/app/services/followers.service';
+import { ImageService } from 'src/app/services/image.service';
+import { BlogImage } from '../blog-image.model';
 
 @Component({
   selector: 'app-blog-list',
@@ -26,18 +31,30 @@ export class ListBlogsComponent implements OnChanges {
   loading = false;
   error = '';
   usersBlogsNumber = 0;
-  // Map blogId to comments array
+  // Paging of the feed of blogs by followed users
+  feedPage = 0;
+  feedTotal = 0;
+  // Map blogId to the loaded comment threads
   blogComments: { [blogId: string]: Comment[] } = {};
-  blogLikes: { [blogId: string]: Like[] } = {};
-  // Map blogId to new comment text
-  newCommentText: { [blogId: string]: string } = {};
+  // Map blogId to the paging and order of its comment threads
+  commentTotals: { [blogId: string]: number } = {};
+  commentPages: { [blogId: string]: number } = {};
+  commentSort: { [blogId: string]: CommentSort } = {};
+  // Map blogId, or commentId for replies, to new comment text
+  newCommentText: { [id: string]: string } = {};
+  // Map blogId to why the last comment was rejected
+  commentErrors: { [blogId: string]: string } = {};
+  // The comment a reply form is open for
+  replyingTo = '';
+  // Map image url to the loaded object URL
+  imageUrls: { [url: string]: string } = {};
 
   constructor(
     private blogService: BlogService,
     private commentService: CommentService,
     private likeService: LikeService,
     private authService: AuthService,
-    private followersService: FollowersService
+    private imageService: ImageService
   ) {}
 
   ngOnInit() {
@@ -51,11 +68,28 @@ export class ListBlogsComponent implements OnChanges {
     this.load();
   }
 
-  createImageDataUrl(base64Data: string, mimeType: string): string {
-    if (base64Data.startsWith('data:')) {
-      return base64Data;
-    }
-    return `data:${mimeType};base64,${base64Data}`;
+  loadImages(images: BlogImage[] | undefined): void {
+    (images || []).forEach((img) => {
+      if (!img.url || this.imageUrls[img.url]) return;
+      this.imageService
+        .objectUrl(img.url, 'medium')
+        .subscribe((url) => (this.imageUrls[img.url] = url));
+    });
+  }
+
+  // The service renders the Markdown description to sanitized HTML whose
+  // images point at the blog's own image URLs; those are swapped for the
+  // object URLs loaded with the JWT, and left out until they are loaded.
+  descriptionHtml(blog: Blog): string {
+    let html = blog.descriptionHtml || '';
+    (blog.images || []).forEach((img) => {
+      if (img.url) {
+        html = html
+          .split(`src="${img.url}"`)
+          .join(`src="${this.imageUrls[img.url] || ''}"`);
+      }
+    });
+    return html;
   }
 
   onImageError(event: Event): void {
@@ -69,37 +103,21 @@ export class ListBlogsComponent implements OnChanges {
     this.loading = true;
     this.error = '';
     if (!this.userId) return;
-    this.followersService.getFollowees(this.userId.toString()).subscribe({
-      next: (result) => {
-        console.log('Followees result: ', result);
-        if (result.user_ids && Array.isArray(result.user_ids)) {
-          console.log('Followees including self: ', result.user_ids);
-          result.user_ids.forEach((f: any) => {
-            this.blogService
-              .getAllByUser(f.toString())
-              .subscribe((followeeBlogs) => {
-                this.blogs = this.blogs.concat(followeeBlogs || []);
-              });
-          });
-        }
-        if (this.userId) {
-          this.blogService
-            .getAllByUser(this.userId)
-            .subscribe((followeeBlogs) => {
-              this.blogs = this.blogs.concat(followeeBlogs || []);
-              this.usersBlogsNumber = followeeBlogs?.length;
-
-              // Fetch comments for each blog
-              this.blogs.forEach((blog) => {
-                this.loadComments(blog.id);
-                this.loadLikes(blog.id);
-              });
-            });
-        }
-        this.loading = false;
+    this.blogs = [];
+    this.feedPage = 0;
+    this.feedTotal = 0;
+
+    // The caller's own blogs, drafts included, come first
+    this.blogService.getAllByUser(this.userId).subscribe({
+      next: (ownBlogs) => {
+        this.usersBlogsNumber = ownBlogs?.length || 0;
+        this.addBlogs(ownBlogs || []);
+        this.loadFeed();
       },
       error: (err) => {
-        console.error('Failed to load followees', err);
+        console.error('Failed to load blogs', err);
+        this.error = 'Failed to load blogs.';
+        this.loading = false;
       },
     });
 
@@ -124,60 +142,143 @@ export class ListBlogsComponent implements OnChanges {
       },
     });*/
   }
-  loadComments(blogId: string) {
-    console.log('Loading comments for blog:', blogId);
-    this.commentService.getCommentsByBlog(blogId).subscribe({
-      next: (comments) => {
-        this.blogComments[blogId] = comments || [];
+  // Loads the next page of the feed of blogs by followed users
+  loadFeed(): void {
+    this.blogService.getFeed(this.feedPage + 1).subscribe({
+      next: (page) => {
+        this.feedPage = page.page;
+        this.feedTotal = page.total;
+        this.addBlogs(page.items || []);
+        this.loading = false;
+      },
+      error: (err) => {
+        console.error('Failed to load feed', err);
+        this.error = 'Failed to load blogs of the people you follow.';
+        this.loading = false;
+      },
+    });
+  }
+
+  hasMoreFeed(): boolean {
+    return this.blogs.length - this.usersBlogsNumber < this.feedTotal;
+  }
+
+  private addBlogs(blogs: Blog[]): void {
+    this.blogs = this.blogs.concat(blogs);
+    blogs.forEach((blog) => {
+      this.loadImages(blog.images);
+      this.loadComments(blog.id);
+    });
+  }
+
+  // Loads the first page of the blog's comment threads, or the next one
+  loadComments(blogId: string, more = false) {
+    const page = more ? (this.commentPages[blogId] || 0) + 1 : 1;
+    const sort = this.commentSort[blogId] || 'newest';
+    this.commentService.getCommentsByBlog(blogId, sort, page).subscribe({
+      next: (result) => {
+        const loaded = more ? this.blogComments[blogId] || [] : [];
+        this.blogComments[blogId] = loaded.concat(result.items || []);
+        this.commentPages[blogId] = result.page;
+        this.commentTotals[blogId] = result.total;
       },
       error: () => {
         this.blogComments[blogId] = [];
+        this.commentTotals[blogId] = 0;
       },
     });
   }
 
-  loadLikes(blogId: string) {
-    console.log('Loading likes for blog:', blogId);
-    this.likeService.getLikesByBlog(blogId).subscribe({
-      next: (likes) => {
-        this.blogLikes[blogId] = likes || [];
+  hasMoreComments(blogId: string): boolean {
+    return (
+      (this.blogComments[blogId] || []).length < (this.commentTotals[blogId] || 0)
+    );
+  }
+
+  sortComments(blogId: string, sort: CommentSort) {
+    this.commentSort[blogId] = sort;
+    this.loadComments(blogId);
+  }
+
+  canReply(blog: Blog, comment: Comment): boolean {
+    return (
+      !!this.userId &&
+      blog.status === BlogStatus.Published &&
+      !comment.deleted &&
+      comment.depth < MAX_COMMENT_DEPTH
+    );
+  }
+
+  toggleReply(comment: Comment) {
+    this.replyingTo = this.replyingTo === comment.id ? '' : comment.id;
+  }
+
+  toggleCommentLike(comment: Comment) {
+    const request$ = comment.likedByMe
+      ? this.commentService.unlikeComment(comment.id)
+      : this.commentService.likeComment(comment.id);
+    request$.subscribe({
+      next: (updated) => {
+        comment.likeCount = updated.likeCount;
+        comment.likedByMe = updated.likedByMe;
       },
-      error: () => {
-        this.blogLikes[blogId] = [];
+      error: (err) => console.error('Failed to update comment like:', err),
+    });
+  }
+
+  // Deleted comments stay in their thread so the replies keep their place
+  deleteComment(comment: Comment) {
+    this.commentService.deleteComment(comment.id).subscribe({
+      next: () => {
+        comment.deleted = true;
+        comment.text = '[deleted]';
+        comment.userId = '';
+        comment.likeCount = 0;
+        comment.likedByMe = false;
       },
+      error: (err) => console.error('Failed to delete comment:', err),
     });
-    console.log('Blog likes:', this.blogLikes);
   }
 
   hasLiked(blog: Blog): boolean {
-    const likes = this.blogLikes[blog.id] || [];
-    return likes.some((like) => like.userId === this.userId);
+    return blog.likedByMe;
   }
 
   toggleLike(blog: Blog) {
-    if (this.hasLiked(blog) && this.userId) {
-      // Unlike
-      this.likeService.removeLike(blog.id, this.userId).subscribe({
-        next: () => {
-          this.blogLikes[blog.id] = (this.blogLikes[blog.id] || []).filter(
-            (like) => like.userId !== this.userId
-          );
-        },
-      });
-    } else if (this.userId) {
-      // Like
-      this.likeService
-        .createLike({ userId: this.userId, blogId: blog.id })
-        .subscribe({
-          next: (newLike) => {
-            this.blogLikes[blog.id] = [
-              ...(this.blogLikes[blog.id] || []),
-              newLike,
-            ];
-          },
-        });
-    }
+    if (!this.userId) return;
+    const request$ = this.hasLiked(blog)
+      ? this.likeService.removeLike(blog.id, this.userId)
+      : this.likeService.createLike({ userId: this.userId, blogId: blog.id });
+    request$.subscribe({
+      next: (summary) => this.applyLikes(summary),
+      error: (err) => console.error('Failed to update like:', err),
+    });
+  }
+
+  // Replaces the blog's like count and flag with the ones the service returned
+  private applyLikes(summary: LikeSummary) {
+    this.blogs = this.blogs.map((b) =>
+      b.id === summary.blogId
+        ? { ...b, likeCount: summary.likeCount, likedByMe: summary.likedByMe }
+        : b
+    );
+  }
+  readonly BlogStatus = BlogStatus;
+
+  isAuthor(blog: Blog): boolean {
+    return !!this.userId && blog.userId === this.userId;
+  }
+
+  // Publish, close or reopen the blog, replacing it with the returned copy
+  changeStatus(blog: Blog, action: 'publish' | 'close' | 'reopen') {
+    this.blogService[action](blog.id).subscribe({
+      next: (updated) => {
+        this.blogs = this.blogs.map((b) => (b.id === blog.id ? updated : b));
+      },
+      error: (err) => console.error(`Failed to ${action} blog:`, err),
+    });
   }
+
   delete(blog: Blog) {
     this.blogService.delete(blog.id).subscribe({
       next: () => {
@@ -189,20 +290,35 @@ export class ListBlogsComponent implements OnChanges {
       },
     });
   }
-  addComment(blog: Blog) {
-    const text = this.newCommentText[blog.id];
+  // Posts a comment on the blog, or a reply to parent
+  addComment(blog: Blog, parent?: Comment) {
+    const key = parent ? parent.id : blog.id;
+    const text = this.newCommentText[key];
     if (!text) return;
     const commentPayload = {
       blogId: blog.id,
       userId: this.userId,
       text,
-      // userId: ... // set current user id here
+      parentId: parent?.id,
     };
     console.log('Adding comment:', commentPayload);
     this.commentService.addComment(commentPayload).subscribe({
-      next: () => {
-        this.newCommentText[blog.id] = '';
-        this.loadComments(blog.id); // Refresh comments
+      next: (created) => {
+        this.newCommentText[key] = '';
+        this.commentErrors[blog.id] = '';
+        if (parent) {
+          parent.replies = [...(parent.replies || []), created];
+          this.replyingTo = '';
+        } else {
+          this.loadComments(blog.id); // Refresh comments
+        }
+      },
+      error: (err) => {
+        // only followers of the author may comment
+        this.commentErrors[blog.id] =
+          typeof err?.error === 'string' && err.error
+            ? err.error
+            : 'Failed to add comment.';
       },
     });
   }
@@ -0,0 +1,25 @@
+// src/app/services/image.service.ts
+import { Injectable } from '@angular/core';
+import { HttpClient } from '@angular/common/http';
+import { Observable, map } from 'rxjs';
+
+export type ImageSize = 'thumb' | 'medium' | 'original';
+
+// Images are served behind the gateway, so they are fetched with the JWT
+// attached by the interceptor and shown through object URLs.
+@Injectable({
+  providedIn: 'root',
+})
+export class ImageService {
+  private baseUrl = 'http://localhost:7000';
+
+  constructor(private http: HttpClient) {}
+
+  // size picks a smaller variant generated on upload; the original is the default
+  objectUrl(url: string, size?: ImageSize): Observable<string> {
+    const params: Record<string, string> = size ? { size } : {};
+    return this.http
+      .get(`${this.baseUrl}${url}`, { responseType: 'blob', params })
+      .pipe(map((blob) => URL.createObjectURL(blob)));
+  }
+}
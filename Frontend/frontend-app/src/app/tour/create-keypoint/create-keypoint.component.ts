@@ -14,6 +14,7 @@ import { KeypointService } from '../keypoint.service';
 import { KeyPointDto } from '../keypoint.dto';
 import { AuthService } from 'src/app/auth/auth.service';
 import { TourService } from '../tour.service';
+import { ImageService } from 'src/app/services/image.service';
 @Component({
   selector: 'app-create-keypoint',
   templateUrl: './create-keypoint.component.html',
@@ -50,7 +51,8 @@ export class CreateKeypointComponent
     private router: Router,
     private keypointService: KeypointService,
     private auth: AuthService,
-    private tourService: TourService
+    private tourService: TourService,
+    private imageService: ImageService
   ) {
     this.form = this.fb.group({
       tourId: ['', Validators.required],
@@ -166,17 +168,15 @@ export class CreateKeypointComponent
             const title = kp.title || 'Keypoint';
             const desc = kp.description ? `<div>${kp.description}</div>` : '';
 
-            // Create image HTML if keypoint has binary image data
-            let imageHtml = '';
-            if (kp.image?.data && kp.image?.mimeType) {
-              const imageDataUrl = this.createImageDataUrl(
-                kp.image.data,
-                kp.image.mimeType
-              );
-              imageHtml = `<div><img src="${imageDataUrl}" alt="Keypoint image" style="max-width: 200px; max-height: 150px; border-radius: 4px; margin: 8px 0;"></div>`;
-            }
+            m.bindPopup(`<b>${title}</b>${desc}`);
 
-            m.bindPopup(`<b>${title}</b>${desc}${imageHtml}`);
+            // Add the stored image to the popup once it has loaded
+            if (kp.image?.url) {
+              this.imageService.objectUrl(kp.image.url, 'thumb').subscribe((url) => {
+                const imageHtml = `<div><img src="${url}" alt="Keypoint image" style="max-width: 200px; max-height: 150px; border-radius: 4px; margin: 8px 0;"></div>`;
+                m.setPopupContent(`<b>${title}</b>${desc}${imageHtml}`);
+              });
+            }
             this.existingMarkers.push(m);
           } catch (e) {
             console.warn('Failed to render keypoint', kp, e);
@@ -238,11 +238,14 @@ export class CreateKeypointComponent
     // Get current tour data first
     this.tourService.getById(this.tourId).subscribe({
       next: (tour) => {
-        // Update the tour with new distance and duration
+        // The route is drawn walking, so only the walking duration is replaced
+        const durations = (tour.durations || []).filter(
+          (d) => d.transportType !== 0
+        );
         const updatedTour = {
           ...tour,
           distance: distanceKm,
-          duration: durationMinutes,
+          durations: [...durations, { transportType: 0, minutes: durationMinutes }],
         };
         console.log('Updating tour with new metrics:', updatedTour);
         this.tourService.update(this.tourId!, updatedTour).subscribe({
@@ -304,12 +307,4 @@ export class CreateKeypointComponent
       },
     });
   }
-
-  private createImageDataUrl(base64Data: string, mimeType: string): string {
-    // If the base64 data doesn't have the data URL prefix, add it
-    if (base64Data.startsWith('data:')) {
-      return base64Data;
-    }
-    return `data:${mimeType};base64,${base64Data}`;
-  }
 }
@@ -1,5 +1,6 @@
 export interface Image {
-  data?: string; // Base64 encoded data from backend
+  url?: string; // Path of the stored image, served by the backend
   mimeType?: string;
   filename?: string;
+  size?: number;
 }
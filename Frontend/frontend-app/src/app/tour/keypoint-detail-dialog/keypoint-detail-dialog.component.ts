@@ -4,6 +4,7 @@ import { Router } from '@angular/router';
 import { KeypointService } from '../keypoint.service';
 import { MatDialog } from '@angular/material/dialog';
 import { KeypointEditDialogComponent } from '../keypoint-edit-dialog/keypoint-edit-dialog.component';
+import { ImageService } from 'src/app/services/image.service';
 
 @Component({
   selector: 'app-keypoint-detail-dialog',
@@ -19,26 +20,18 @@ export class KeypointDetailDialogComponent {
     @Inject(MAT_DIALOG_DATA) public data: any,
     private router: Router,
     private keypointService: KeypointService,
-    private dialog: MatDialog
+    private dialog: MatDialog,
+    private imageService: ImageService
   ) {
-    // Set image URL if keypoint has binary image data
-    if (data?.image?.data && data?.image?.mimeType) {
-      this.imageUrl = this.createImageDataUrl(
-        data.image.data,
-        data.image.mimeType
-      );
-      console.log('Image data found:', data.image);
+    // Load the stored image if the keypoint has one
+    if (data?.image?.url) {
+      this.imageService.objectUrl(data.image.url, 'medium').subscribe({
+        next: (url) => (this.imageUrl = url),
+        error: () => this.onImageError(),
+      });
     }
   }
 
-  private createImageDataUrl(base64Data: string, mimeType: string): string {
-    // If the base64 data doesn't have the data URL prefix, add it
-    if (base64Data.startsWith('data:')) {
-      return base64Data;
-    }
-    return `data:${mimeType};base64,${base64Data}`;
-  }
-
   onImageError(): void {
     this.imageError = true;
     console.error('Failed to load image');
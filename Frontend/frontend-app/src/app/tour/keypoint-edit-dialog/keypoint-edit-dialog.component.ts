@@ -14,6 +14,7 @@ import {
 import { FormBuilder, FormGroup, Validators } from '@angular/forms';
 import { KeypointService } from '../keypoint.service';
 import { MapPickerDialogComponent } from '../map-picker-dialog/map-picker-dialog.component';
+import { ImageService } from 'src/app/services/image.service';
 
 @Component({
   selector: 'app-keypoint-edit-dialog',
@@ -30,7 +31,8 @@ export class KeypointEditDialogComponent implements OnInit {
     private dialogRef: MatDialogRef<KeypointEditDialogComponent>,
     @Inject(MAT_DIALOG_DATA) public data: any,
     private keypointService: KeypointService,
-    private dialog: MatDialog
+    private dialog: MatDialog,
+    private imageService: ImageService
   ) {
     this.form = this.fb.group({
       title: ['', Validators.required],
@@ -50,24 +52,15 @@ export class KeypointEditDialogComponent implements OnInit {
         this.form.get('latitude')?.setValue(this.data.coordinates.latitude);
         this.form.get('longitude')?.setValue(this.data.coordinates.longitude);
       }
-      // Set current image URL if keypoint has binary image data
-      if (this.data.image?.data && this.data.image?.mimeType) {
-        this.currentImageUrl = this.createImageDataUrl(
-          this.data.image.data,
-          this.data.image.mimeType
-        );
+      // Load the current image if the keypoint has one
+      if (this.data.image?.url) {
+        this.imageService
+          .objectUrl(this.data.image.url, 'thumb')
+          .subscribe((url) => (this.currentImageUrl = url));
       }
     }
   }
 
-  private createImageDataUrl(base64Data: string, mimeType: string): string {
-    // If the base64 data doesn't have the data URL prefix, add it
-    if (base64Data.startsWith('data:')) {
-      return base64Data;
-    }
-    return `data:${mimeType};base64,${base64Data}`;
-  }
-
   onImageSelected(event: Event): void {
     const input = event.target as HTMLInputElement;
     if (input.files && input.files.length > 0) {
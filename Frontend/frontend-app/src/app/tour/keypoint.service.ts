@@ -1,28 +1,22 @@
 import { Injectable } from '@angular/core';
 import { HttpClient } from '@angular/common/http';
-import { Observable } from 'rxjs';
+import { Observable, from, map, of, switchMap } from 'rxjs';
 import { KeyPoint } from './keypoint.model';
 import { KeyPointDto } from './keypoint.dto';
 
 @Injectable({ providedIn: 'root' })
 export class KeypointService {
   private api = 'http://localhost:7000/keyPoints';
+  private grpcUrl = 'http://localhost:7000/api/tours';
 
   constructor(private http: HttpClient) {}
 
   create(dto: KeyPointDto, imageFile?: File): Observable<KeyPoint> {
-    const formData = new FormData();
-    formData.append('tourId', dto.tourId);
-    formData.append('title', dto.title);
-    formData.append('description', dto.description);
-    formData.append('latitude', dto.coordinates.latitude.toString());
-    formData.append('longitude', dto.coordinates.longitude.toString());
-
-    if (imageFile) {
-      formData.append('image', imageFile);
-    }
-
-    return this.http.post<KeyPoint>(this.api, formData);
+    return this.toBody(dto, imageFile).pipe(
+      switchMap((body) =>
+        this.http.post<KeyPoint>(`${this.grpcUrl}/${dto.tourId}/keyPoints`, body)
+      )
+    );
   }
 
   getAll(): Observable<KeyPoint[]> {
@@ -34,7 +28,7 @@ export class KeypointService {
   }
 
   getByTour(tourId: string): Observable<KeyPoint[]> {
-    return this.http.get<KeyPoint[]>(`${this.api}/tours/${tourId}`);
+    return this.http.get<KeyPoint[]>(`${this.grpcUrl}/${tourId}/keyPoints`);
   }
 
   getByTourSorted(tourId: string): Observable<KeyPoint[]> {
@@ -44,25 +38,48 @@ export class KeypointService {
   }
 
   update(id: string, dto: KeyPointDto, imageFile?: File): Observable<KeyPoint> {
-    const formData = new FormData();
-    formData.append('tourId', dto.tourId);
-    formData.append('title', dto.title);
-    formData.append('description', dto.description);
-    formData.append('latitude', dto.coordinates.latitude.toString());
-    formData.append('longitude', dto.coordinates.longitude.toString());
-
-    if (imageFile) {
-      formData.append('image', imageFile);
-    }
-
-    return this.http.put<KeyPoint>(`${this.api}/${id}`, formData);
+    return this.toBody(dto, imageFile).pipe(
+      switchMap((body) =>
+        this.http.put<KeyPoint>(`${this.grpcUrl}/keyPoints/${id}`, body)
+      )
+    );
   }
 
   delete(id: string): Observable<void> {
-    return this.http.delete<void>(`${this.api}/${id}`);
+    return this.http.delete<void>(`${this.grpcUrl}/keyPoints/${id}`);
   }
 
   getImageUrl(id: string): string {
     return `${this.api}/${id}/image`;
   }
+
+  // The /api/tours endpoints take JSON, so the image travels base64-encoded.
+  private toBody(dto: KeyPointDto, imageFile?: File): Observable<object> {
+    const body = {
+      title: dto.title,
+      description: dto.description,
+      coordinates: dto.coordinates,
+    };
+    if (!imageFile) {
+      return of(body);
+    }
+    return from(this.readBase64(imageFile)).pipe(
+      map((data) => ({
+        ...body,
+        image: { data, mimeType: imageFile.type, filename: imageFile.name },
+      }))
+    );
+  }
+
+  private readBase64(file: File): Promise<string> {
+    return new Promise((resolve, reject) => {
+      const reader = new FileReader();
+      reader.onload = () => {
+        const url = reader.result as string;
+        resolve(url.substring(url.indexOf(',') + 1));
+      };
+      reader.onerror = () => reject(reader.error);
+      reader.readAsDataURL(file);
+    });
+  }
 }
@@ -5,6 +5,7 @@ import { AuthService } from '../../auth/auth.service';
 import { KeypointService } from '../keypoint.service';
 import { Tour, TourStatus, TransportType } from '../tour.model';
 import { FormBuilder, FormGroup, Validators } from '@angular/forms';
+import { of, switchMap } from 'rxjs';
 
 @Component({
   selector: 'app-list-tours',
@@ -70,8 +71,6 @@ export class ListToursComponent {
   submittingReview = false;
 
   // (opciono) auto-popuna iz whoAmI
-  userFullName?: string;
-  userEmail?: string;
 
 
   constructor(
@@ -119,7 +118,12 @@ export class ListToursComponent {
     this.error = '';
     const req$ = this.userId
       ? this.tourService.getAllByUser(this.userId)
-      : this.tourService.getAllTours();
+      : this.tourService.getAllTours({
+          status: 1,
+          sort: 'publishedAt',
+          order: 'desc',
+          pageSize: 100,
+        });
 
     req$.subscribe({
       next: (data) => {
@@ -156,6 +160,20 @@ export class ListToursComponent {
     }
   }
 
+  transportTypeLabel(t: number): string {
+    // backend: 0=Walking,1=Bicycle,2=Bus
+    switch (t) {
+      case 0:
+        return 'Walking';
+      case 1:
+        return 'Bicycle';
+      case 2:
+        return 'Bus';
+      default:
+        return 'Unknown';
+    }
+  }
+
   statusLabel(s: number): string {
     switch (s) {
       case 0:
@@ -188,8 +206,6 @@ rateTour(tourId: string) {
   this.reviewForm = this.fb.group({
     rating: [5],
     comment: [''],
-    touristName: [this.userFullName ?? ''],
-    touristEmail: [this.userEmail ?? ''],
     visitedAt: [todayISO],
     commentedAt: [todayISO]
   });
@@ -217,13 +233,22 @@ submitReview() {
   const payload = {
     rating: this.reviewForm.value.rating,
     comment: this.reviewForm.value.comment,
-    touristName: this.reviewForm.value.touristName,
-    touristEmail: this.reviewForm.value.touristEmail,
     visitedAt: this.reviewForm.value.visitedAt,
     commentedAt: this.reviewForm.value.commentedAt
   };
 
-  this.tourService.createReview(this.selectedTourId, payload).subscribe({
+  const tourId = this.selectedTourId;
+  const images = this.selectedImages;
+  this.tourService
+    .createReview(tourId, payload)
+    .pipe(
+      switchMap((created: { id: string }) =>
+        images.length
+          ? this.tourService.uploadReviewImages(tourId, created.id, images)
+          : of([])
+      )
+    )
+    .subscribe({
     next: () => {
       this.submittingReview = false;
       this.closeRateModal();
@@ -231,7 +256,10 @@ submitReview() {
     error: (err) => {
       console.error('Slanje recenzije neuspešno', err);
       this.submittingReview = false;
-      alert('Nismo uspeli da sačuvamo recenziju. Pokušaj ponovo.');
+      alert(
+        err?.error?.error ||
+          'Nismo uspeli da sačuvamo recenziju. Pokušaj ponovo.'
+      );
     }
   });
 }
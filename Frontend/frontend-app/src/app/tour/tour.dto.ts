@@ -1,10 +1,10 @@
-import { TourDifficulty } from './tour.model';
+import { TourDifficulty, TransportDuration } from './tour.model';
 
 export interface TourDto {
   authorId: string;
   title: string;
   description: string;
   difficulty: number;
-  transportType: number;
+  durations: TransportDuration[];
   tags: string[];
 }
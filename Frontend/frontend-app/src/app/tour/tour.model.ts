@@ -27,8 +27,31 @@ export interface Tour {
   status: TourStatus;
   price: number;
   distance: number;
-  duration: number;
   publishedAt: string;
   archivedAt: string;
+  durations: TransportDuration[];
+  ratingStats?: RatingStats;
+  revision?: number; // published versions so far
+  revisionOf?: string; // set on a draft revision of a published tour
+}
+
+export interface TourRevision {
+  id: string;
+  tourId: string;
+  revision: number;
+  tour: Tour;
+  keyPoints: any[];
+  publishedAt: string;
+}
+
+// How long the tour takes with one transport type (0=Walking, 1=Bicycle, 2=Bus).
+export interface TransportDuration {
   transportType: number;
+  minutes: number;
+}
+
+export interface RatingStats {
+  average: number;
+  count: number;
+  histogram: number[]; // histogram[i] counts reviews rated i + 1
 }
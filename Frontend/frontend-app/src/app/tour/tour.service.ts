@@ -1,9 +1,31 @@
 import { Injectable } from '@angular/core';
-import { HttpClient } from '@angular/common/http';
-import { Observable } from 'rxjs';
-import { Tour } from './tour.model';
+import { HttpClient, HttpParams } from '@angular/common/http';
+import { Observable, map } from 'rxjs';
+import { Tour, TourRevision } from './tour.model';
 import { TourDto } from './tour.dto';
 
+export interface TourQuery {
+  page?: number;
+  pageSize?: number;
+  status?: number;
+  difficulty?: number;
+  transportType?: number;
+  maxDuration?: number; // minutes, with transportType if set
+  tag?: string;
+  authorId?: string;
+  minPrice?: number;
+  maxPrice?: number;
+  sort?: 'publishedAt' | 'price' | 'distance' | 'rating';
+  order?: 'asc' | 'desc';
+}
+
+export interface TourPage {
+  items: Tour[];
+  page: number;
+  pageSize: number;
+  total: number;
+}
+
 @Injectable({
   providedIn: 'root',
 })
@@ -13,12 +35,39 @@ export class TourService {
 
   constructor(private http: HttpClient) {}
 
-  getAllTours(): Observable<Tour[]> {
-    return this.http.get<any[]>(`${this.apiUrl}`);
+  getAllTours(query: TourQuery = {}): Observable<Tour[]> {
+    return this.getPage(query).pipe(map((page) => page.items));
+  }
+
+  getPage(query: TourQuery = {}): Observable<TourPage> {
+    let params = new HttpParams();
+    for (const [key, value] of Object.entries(query)) {
+      if (value !== undefined && value !== null && value !== '') {
+        params = params.set(key, String(value));
+      }
+    }
+    return this.http.get<TourPage>(`${this.grpcUrl}`, { params });
+  }
+
+  getNearby(
+    latitude: number,
+    longitude: number,
+    radiusKm = 10,
+    match: 'first' | 'any' = 'any'
+  ): Observable<(Tour & { distanceKm: number })[]> {
+    const params = new HttpParams()
+      .set('lat', latitude)
+      .set('lng', longitude)
+      .set('radiusKm', radiusKm)
+      .set('match', match);
+    return this.http.get<(Tour & { distanceKm: number })[]>(
+      `${this.apiUrl}/nearby`,
+      { params }
+    );
   }
 
   getById(id: string): Observable<Tour> {
-    return this.http.get<any>(`${this.apiUrl}/${id}`);
+    return this.http.get<any>(`${this.grpcUrl}/${id}`);
   }
 
   create(tour: TourDto): Observable<any> {
@@ -26,15 +75,48 @@ export class TourService {
   }
 
   update(id: string, tour: any): Observable<any> {
-    return this.http.put(`${this.apiUrl}/${id}`, tour);
+    return this.http.put(`${this.grpcUrl}/${id}`, tour);
   }
 
   delete(id: string): Observable<any> {
     return this.http.delete(`${this.grpcUrl}/${id}`);
   }
 
+  clone(id: string): Observable<Tour> {
+    return this.http.post<Tour>(`${this.apiUrl}/${id}/clone`, {});
+  }
+
+  // Returns the draft in which changes to a published tour are prepared.
+  startRevision(id: string): Observable<Tour> {
+    return this.http.post<Tour>(`${this.apiUrl}/${id}/revisions`, {});
+  }
+
+  getRevisions(id: string): Observable<TourRevision[]> {
+    return this.http.get<TourRevision[]>(`${this.apiUrl}/${id}/revisions`);
+  }
+
+  exportRoute(id: string, format: 'gpx' | 'geojson' = 'gpx'): Observable<Blob> {
+    const params = new HttpParams().set('format', format);
+    return this.http.get(`${this.apiUrl}/${id}/export`, {
+      params,
+      responseType: 'blob',
+    });
+  }
+
+  // Creates a draft tour from a GPX or GeoJSON file.
+  importRoute(file: File, title?: string): Observable<Tour> {
+    const form = new FormData();
+    form.append('file', file, file.name);
+    if (title) {
+      form.append('title', title);
+    }
+    return this.http.post<Tour>(`${this.apiUrl}/import`, form);
+  }
+
   getAllByUser(userId: string): Observable<Tour[]> {
-    return this.http.get<any[]>(`${this.apiUrl}/users/${userId}`);
+    return this.http
+      .get<any>(`${this.grpcUrl}/users/${userId}?pageSize=100`)
+      .pipe(map((page) => page.items));
   }
 
   createReview(
@@ -42,8 +124,6 @@ export class TourService {
     review: {
       rating: number;
       comment?: string;
-      touristName?: string;
-      touristEmail?: string;
       visitedAt?: string; // yyyy-mm-dd
       commentedAt?: string; // yyyy-mm-dd
     }
@@ -51,6 +131,57 @@ export class TourService {
     return this.http.post(`${this.apiUrl}/${tourId}/reviews`, review);
   }
 
+  updateReview(
+    tourId: string,
+    reviewId: string,
+    review: {
+      rating: number;
+      comment?: string;
+      visitedAt?: string; // yyyy-mm-dd
+      commentedAt?: string; // yyyy-mm-dd
+    }
+  ): Observable<any> {
+    return this.http.put(
+      `${this.apiUrl}/${tourId}/reviews/${reviewId}`,
+      review
+    );
+  }
+
+  uploadReviewImages(
+    tourId: string,
+    reviewId: string,
+    images: File[]
+  ): Observable<any[]> {
+    const form = new FormData();
+    images.forEach((img) => form.append('images', img, img.name));
+    return this.http.post<any[]>(
+      `${this.apiUrl}/${tourId}/reviews/${reviewId}/images`,
+      form
+    );
+  }
+
+  deleteReviewImage(
+    tourId: string,
+    reviewId: string,
+    imageId: string
+  ): Observable<void> {
+    return this.http.delete<void>(
+      `${this.apiUrl}/${tourId}/reviews/${reviewId}/images/${imageId}`
+    );
+  }
+
+  setReviewReply(tourId: string, reviewId: string, text: string): Observable<any> {
+    return this.http.put(`${this.apiUrl}/${tourId}/reviews/${reviewId}/reply`, {
+      text,
+    });
+  }
+
+  deleteReviewReply(tourId: string, reviewId: string): Observable<void> {
+    return this.http.delete<void>(
+      `${this.apiUrl}/${tourId}/reviews/${reviewId}/reply`
+    );
+  }
+
   getReviews(tourId: string): Observable<any[]> {
     return this.http.get<any[]>(`${this.apiUrl}/${tourId}/reviews`);
   }
@@ -14,6 +14,7 @@ import { TourService } from '../tour.service';
 import { AuthService } from '../../auth/auth.service';
 import { MatDialog } from '@angular/material/dialog';
 import { KeypointDetailDialogComponent } from '../keypoint-detail-dialog/keypoint-detail-dialog.component';
+import { TransportDuration } from '../tour.model';
 
 @Component({
   selector: 'app-view-tour',
@@ -191,9 +192,21 @@ export class ViewTourComponent implements OnInit, AfterViewInit, OnDestroy {
     if (this.isEditMode && this.editForm.transportType) {
       return this.editForm.transportType;
     }
-    return this.tour
-      ? this.transportTypeLabel(this.tour.transportType)
-      : 'Walking';
+    // the route is drawn for the first transport type the tour has a duration for
+    const first = this.tour?.durations?.[0];
+    return first ? this.transportTypeLabel(first.transportType) : 'Walking';
+  }
+
+  // Records the routed duration for one transport type, replacing any earlier value.
+  private setDuration(transportType: string, minutes: number) {
+    const type = this.transportTypeToNumber(transportType);
+    const durations = (this.tour.durations || []).filter(
+      (d: TransportDuration) => d.transportType !== type
+    );
+    if (minutes > 0) {
+      durations.push({ transportType: type, minutes });
+    }
+    this.tour.durations = durations;
   }
 
   private drawRouteForTransportType(
@@ -249,7 +262,7 @@ export class ViewTourComponent implements OnInit, AfterViewInit, OnDestroy {
         // Update tour object if we're in edit mode or if this is the owner
         if (this.isOwner && this.tour) {
           this.tour.distance = Math.round(distanceKm * 100) / 100; // round to 2 decimals
-          this.tour.duration = durationMin;
+          this.setDuration(transportType, durationMin);
         }
       }
     });
@@ -368,7 +381,7 @@ export class ViewTourComponent implements OnInit, AfterViewInit, OnDestroy {
         this.tour.tags.includes(tag)
       ),
       price: this.tour.price,
-      transportType: this.transportTypeLabel(this.tour.transportType),
+      transportType: this.getCurrentTransportType(),
     };
   }
 
@@ -395,10 +408,9 @@ export class ViewTourComponent implements OnInit, AfterViewInit, OnDestroy {
       tags: selectedTags,
       price: parseFloat(this.editForm.price),
       distance: this.tour.distance, // Will be updated by route calculation
-      duration: this.tour.duration, // Will be updated by route calculation
+      durations: this.tour.durations, // Will be updated by route calculation
       status: this.statusToNumber(this.tour.status),
       difficulty: this.difficultyToNumber(this.editForm.difficulty),
-      transportType: this.transportTypeToNumber(this.editForm.transportType),
       publishedAt: this.tour.publishedAt,
       archivedAt: this.tour.archivedAt,
     };
@@ -411,9 +423,6 @@ export class ViewTourComponent implements OnInit, AfterViewInit, OnDestroy {
         this.tour.difficulty = this.editForm.difficulty;
         this.tour.tags = selectedTags;
         this.tour.price = updatedTourDto.price;
-        this.tour.transportType = this.transportTypeToNumber(
-          this.editForm.transportType
-        );
 
         this.isEditMode = false;
         this.editForm = {};
@@ -427,7 +436,7 @@ export class ViewTourComponent implements OnInit, AfterViewInit, OnDestroy {
             const finalUpdateDto = {
               ...updatedTourDto,
               distance: this.tour.distance,
-              duration: this.tour.duration,
+              durations: this.tour.durations,
             };
 
             this.tourService.update(this.tourId, finalUpdateDto).subscribe({
@@ -548,12 +557,9 @@ export class ViewTourComponent implements OnInit, AfterViewInit, OnDestroy {
                 tags: this.tour.tags,
                 price: this.tour.price,
                 distance: this.tour.distance,
-                duration: this.tour.duration,
+                durations: this.tour.durations,
                 status: this.statusToNumber(this.tour.status),
                 difficulty: this.difficultyToNumber(this.tour.difficulty),
-                transportType: this.transportTypeToNumber(
-                  this.tour.transportType
-                ),
                 publishedAt: this.tour.publishedAt,
                 archivedAt: this.tour.archivedAt,
               };
@@ -572,7 +578,7 @@ export class ViewTourComponent implements OnInit, AfterViewInit, OnDestroy {
           // If less than 2 keypoints, reset distance and duration
           if (this.tour && this.tourId) {
             this.tour.distance = 0;
-            this.tour.duration = 0;
+            this.tour.durations = [];
             const updatedTourDto = {
               id: this.tour.id,
               authorId: this.tour.authorId,
@@ -581,12 +587,9 @@ export class ViewTourComponent implements OnInit, AfterViewInit, OnDestroy {
               tags: this.tour.tags,
               price: this.tour.price,
               distance: 0,
-              duration: 0,
+              durations: [],
               status: this.statusToNumber(this.tour.status),
               difficulty: this.difficultyToNumber(this.tour.difficulty),
-              transportType: this.transportTypeToNumber(
-                this.tour.transportType
-              ),
               publishedAt: this.tour.publishedAt,
               archivedAt: this.tour.archivedAt,
             };